@@ -0,0 +1,492 @@
+package click
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ColumnDefinition is a column declaration, like `name Type DEFAULT expr CODEC(ZSTD) COMMENT 'text'`.
+// Only Name and Type are required, at most one of Default, Materialized and Alias may be set.
+// See https://clickhouse.com/docs/sql-reference/statements/create/table#default_values
+type ColumnDefinition struct {
+	Name         Column
+	Type         string
+	Default      Expression
+	Materialized Expression
+	Alias        Expression
+	Codec        string // e.g. `ZSTD(3)` or `Delta, LZ4`
+	TTL          Expression
+	Comment      string
+}
+
+func (d ColumnDefinition) definition() (string, error) {
+	if d.Name == "" {
+		return "", errors.New("empty column name")
+	}
+	if d.Type == "" {
+		return "", fmt.Errorf("column %s: empty type", d.Name)
+	}
+	var sb strings.Builder
+	sb.WriteString(string(d.Name))
+	sb.WriteByte(' ')
+	sb.WriteString(d.Type)
+	var defaults int
+	for _, v := range []struct {
+		keyword string
+		expr    Expression
+	}{
+		{"DEFAULT", d.Default},
+		{"MATERIALIZED", d.Materialized},
+		{"ALIAS", d.Alias},
+	} {
+		if v.expr != nil {
+			defaults++
+			sb.WriteByte(' ')
+			sb.WriteString(v.keyword)
+			sb.WriteByte(' ')
+			sb.WriteString(v.expr.Expression())
+		}
+	}
+	if defaults > 1 {
+		return "", fmt.Errorf("column %s: at most one of DEFAULT, MATERIALIZED and ALIAS is allowed", d.Name)
+	}
+	if d.Codec != "" {
+		sb.WriteString(" CODEC(")
+		sb.WriteString(d.Codec)
+		sb.WriteByte(')')
+	}
+	if d.TTL != nil {
+		sb.WriteString(" TTL ")
+		sb.WriteString(d.TTL.Expression())
+	}
+	if d.Comment != "" {
+		sb.WriteString(" COMMENT ")
+		sb.WriteString(quoted(d.Comment).Expression())
+	}
+	return sb.String(), nil
+}
+
+// Assignment is `column = value` in UPDATE mutations.
+type Assignment struct {
+	Column Column
+	Value  Expression
+}
+
+// Set is a shortcut of Assignment.
+func Set(column Column, value Expression) Assignment {
+	return Assignment{Column: column, Value: value}
+}
+
+// data skipping index types, see https://clickhouse.com/docs/optimize/skipping-indexes
+
+// IndexMinMax stores minimum and maximum values of the expression per granule.
+func IndexMinMax() Expression { return Alias("minmax") }
+
+// IndexSet stores distinct values of the expression per granule, up to maxRows, 0 means unlimited.
+func IndexSet(maxRows int) Expression { return Fn("set", LiteralExpression(maxRows)) }
+
+// IndexBloomFilter stores a bloom filter of values. The false positive rate is optional, 0.025 by default.
+func IndexBloomFilter(falsePositive ...float64) Expression {
+	if len(falsePositive) == 0 {
+		return Alias("bloom_filter")
+	}
+	args := make([]Expression, len(falsePositive))
+	for i, v := range falsePositive {
+		args[i] = LiteralExpression(v)
+	}
+	return Fn("bloom_filter", args...)
+}
+
+// IndexTokenBF stores a bloom filter of tokens split by non-alphanumeric characters.
+func IndexTokenBF(sizeBytes, hashFunctions, seed int) Expression {
+	return Fn("tokenbf_v1", LiteralExpression(sizeBytes), LiteralExpression(hashFunctions), LiteralExpression(seed))
+}
+
+// IndexNgramBF stores a bloom filter of n-grams.
+func IndexNgramBF(n, sizeBytes, hashFunctions, seed int) Expression {
+	return Fn("ngrambf_v1", LiteralExpression(n), LiteralExpression(sizeBytes), LiteralExpression(hashFunctions), LiteralExpression(seed))
+}
+
+// PartitionID refers a partition by its ID, rendering `ID 'id'`, instead of the partition expression value.
+// See https://clickhouse.com/docs/sql-reference/statements/alter/partition#how-to-set-partition-expression
+func PartitionID(id string) Expression {
+	return Alias("ID " + quoted(id).Expression())
+}
+
+// AlterTable creates an ALTER TABLE query, executing commands in order.
+// See https://clickhouse.com/docs/sql-reference/statements/alter
+func AlterTable(table Table) *AlterTableBuilder {
+	return &AlterTableBuilder{
+		table: table,
+	}
+}
+
+// AlterTableBuilder implements builder pattern for constructing ALTER TABLE SQLs.
+type AlterTableBuilder struct {
+	table      Table
+	cluster    string
+	idempotent bool
+	allowAll   bool
+	commands   []alterCommand
+	settings   querySettings
+	style      RenderStyle
+	styleSet   bool
+}
+
+// alterCommand renders a command with options of the builder.
+type alterCommand func(o alterOptions) (string, error)
+
+type alterOptions struct {
+	idempotent bool // adds IF [NOT] EXISTS clause
+	allowAll   bool // allows mutations of all rows
+}
+
+func (b *AlterTableBuilder) OnCluster(cluster string) *AlterTableBuilder {
+	b.cluster = cluster
+	return b
+}
+
+// Idempotent adds IF NOT EXISTS to ADD commands, and IF EXISTS to DROP, MODIFY, RENAME and COMMENT commands,
+// so that the query can be retried safely.
+func (b *AlterTableBuilder) Idempotent() *AlterTableBuilder {
+	b.idempotent = true
+	return b
+}
+
+// AllowEmptyWhere allows Delete and Update commands to affect all rows, when WHERE clause is absent or always true.
+func (b *AlterTableBuilder) AllowEmptyWhere() *AlterTableBuilder {
+	b.allowAll = true
+	return b
+}
+
+// Setting adds a setting to SETTINGS clause, e.g. `Setting(SettingMutationsSync, 2)`.
+func (b *AlterTableBuilder) Setting(name string, value any) *AlterTableBuilder {
+	b.settings = b.settings.set(name, value)
+	return b
+}
+
+// clone returns a copy of the builder, which can be modified without affecting the original one.
+func (b *AlterTableBuilder) clone() *AlterTableBuilder {
+	c := *b
+	c.commands = append([]alterCommand(nil), b.commands...)
+	c.settings = append(querySettings(nil), b.settings...)
+	return &c
+}
+
+func (b *AlterTableBuilder) add(cmd alterCommand) *AlterTableBuilder {
+	b.commands = append(b.commands, cmd)
+	return b
+}
+
+// simple adds a command in form of `verb [IF [NOT] EXISTS] rest`.
+func (b *AlterTableBuilder) simple(verb, ifExists, rest string) *AlterTableBuilder {
+	return b.add(func(o alterOptions) (string, error) {
+		s := verb
+		if o.idempotent && ifExists != "" {
+			s += " " + ifExists
+		}
+		if rest == "" {
+			return s, nil
+		}
+		return s + " " + rest, nil
+	})
+}
+
+// AddColumn adds a column at the end, or after the given column.
+func (b *AlterTableBuilder) AddColumn(def ColumnDefinition, after ...Column) *AlterTableBuilder {
+	after = append([]Column(nil), after...)
+	return b.add(func(o alterOptions) (string, error) {
+		s, err := def.definition()
+		if err != nil {
+			return "", err
+		}
+		s = "ADD COLUMN " + ifNotExists(o.idempotent) + s
+		if len(after) > 0 {
+			s += " AFTER " + string(after[0])
+		}
+		return s, nil
+	})
+}
+
+// AddColumnFirst adds a column as the first column.
+func (b *AlterTableBuilder) AddColumnFirst(def ColumnDefinition) *AlterTableBuilder {
+	return b.add(func(o alterOptions) (string, error) {
+		s, err := def.definition()
+		if err != nil {
+			return "", err
+		}
+		return "ADD COLUMN " + ifNotExists(o.idempotent) + s + " FIRST", nil
+	})
+}
+
+func (b *AlterTableBuilder) DropColumn(column Column) *AlterTableBuilder {
+	return b.simple("DROP COLUMN", "IF EXISTS", string(column))
+}
+
+// ModifyColumn changes the type, default expression, codec, TTL or comment of a column.
+func (b *AlterTableBuilder) ModifyColumn(def ColumnDefinition) *AlterTableBuilder {
+	return b.add(func(o alterOptions) (string, error) {
+		s, err := def.definition()
+		if err != nil {
+			return "", err
+		}
+		return "MODIFY COLUMN " + ifExists(o.idempotent) + s, nil
+	})
+}
+
+func (b *AlterTableBuilder) RenameColumn(from, to Column) *AlterTableBuilder {
+	return b.simple("RENAME COLUMN", "IF EXISTS", string(from)+" TO "+string(to))
+}
+
+func (b *AlterTableBuilder) CommentColumn(column Column, comment string) *AlterTableBuilder {
+	return b.simple("COMMENT COLUMN", "IF EXISTS", string(column)+" "+quoted(comment).Expression())
+}
+
+// AddIndex adds a data skipping index, e.g. `AddIndex("idx", Column("a"), IndexBloomFilter(), 4)`.
+func (b *AlterTableBuilder) AddIndex(name string, expr Expression, typ Expression, granularity int) *AlterTableBuilder {
+	return b.add(func(o alterOptions) (string, error) {
+		if name == "" || expr == nil || typ == nil {
+			return "", errors.New("ADD INDEX: empty name, expression or type")
+		}
+		if granularity <= 0 {
+			return "", fmt.Errorf("ADD INDEX %s: granularity must be positive", name)
+		}
+		return fmt.Sprintf("ADD INDEX %s%s %s TYPE %s GRANULARITY %d",
+			ifNotExists(o.idempotent), name, expr.Expression(), typ.Expression(), granularity), nil
+	})
+}
+
+func (b *AlterTableBuilder) DropIndex(name string) *AlterTableBuilder {
+	return b.simple("DROP INDEX", "IF EXISTS", name)
+}
+
+// MaterializeIndex builds the index for existing data.
+func (b *AlterTableBuilder) MaterializeIndex(name string) *AlterTableBuilder {
+	return b.simple("MATERIALIZE INDEX", "IF EXISTS", name)
+}
+
+// AddProjection adds a projection. The query must not have FROM clause, e.g. `Select(Column("a"), Sum(Column("b"))).GroupBy(Column("a"))`.
+// See https://clickhouse.com/docs/sql-reference/statements/alter/projection
+func (b *AlterTableBuilder) AddProjection(name string, query *SelectBuilder) *AlterTableBuilder {
+	return b.add(func(o alterOptions) (string, error) {
+		if name == "" || query == nil {
+			return "", errors.New("ADD PROJECTION: empty name or query")
+		}
+		if query.from != nil {
+			return "", fmt.Errorf("ADD PROJECTION %s: query must not have FROM clause", name)
+		}
+		q, err := query.buildString(defaultStyle)
+		if err != nil {
+			return "", fmt.Errorf("ADD PROJECTION %s: %w", name, err)
+		}
+		return "ADD PROJECTION " + ifNotExists(o.idempotent) + name + " (" + q + ")", nil
+	})
+}
+
+func (b *AlterTableBuilder) DropProjection(name string) *AlterTableBuilder {
+	return b.simple("DROP PROJECTION", "IF EXISTS", name)
+}
+
+// MaterializeProjection builds the projection for existing data.
+func (b *AlterTableBuilder) MaterializeProjection(name string) *AlterTableBuilder {
+	return b.simple("MATERIALIZE PROJECTION", "IF EXISTS", name)
+}
+
+// ModifyTTL replaces table TTL rules, e.g. `ModifyTTL(Plus(Column("ts"), Interval(30, UnitDay)))`.
+// See https://clickhouse.com/docs/engines/table-engines/mergetree-family/mergetree#table_engine-mergetree-ttl
+func (b *AlterTableBuilder) ModifyTTL(rules ...Expression) *AlterTableBuilder {
+	rules = appendCopy(nil, rules...)
+	return b.add(func(alterOptions) (string, error) {
+		if len(rules) == 0 {
+			return "", errors.New("MODIFY TTL: no rules")
+		}
+		return "MODIFY TTL " + joinExpressions(rules), nil
+	})
+}
+
+func (b *AlterTableBuilder) RemoveTTL() *AlterTableBuilder {
+	return b.simple("REMOVE TTL", "", "")
+}
+
+// Delete is a mutation deleting rows matching the predicate, which rewrites affected data parts.
+// See https://clickhouse.com/docs/sql-reference/statements/alter/delete
+func (b *AlterTableBuilder) Delete(where Expression) *AlterTableBuilder {
+	return b.add(func(o alterOptions) (string, error) {
+		where, err := mutationWhere(where, o.allowAll)
+		if err != nil {
+			return "", fmt.Errorf("DELETE: %w", err)
+		}
+		return "DELETE WHERE " + where.Expression(), nil
+	})
+}
+
+// Update is a mutation updating rows matching the predicate.
+// See https://clickhouse.com/docs/sql-reference/statements/alter/update
+func (b *AlterTableBuilder) Update(where Expression, set ...Assignment) *AlterTableBuilder {
+	set = append([]Assignment(nil), set...)
+	return b.add(func(o alterOptions) (string, error) {
+		where, err := mutationWhere(where, o.allowAll)
+		if err != nil {
+			return "", fmt.Errorf("UPDATE: %w", err)
+		}
+		return updateCommand(set, nil, where)
+	})
+}
+
+// updateCommand renders `UPDATE a = x [IN PARTITION p] WHERE cond`.
+func updateCommand(set []Assignment, partition, where Expression) (string, error) {
+	if len(set) == 0 {
+		return "", errors.New("UPDATE: no assignments")
+	}
+	if where == nil {
+		return "", errors.New("UPDATE: empty WHERE")
+	}
+	var sb strings.Builder
+	sb.WriteString("UPDATE ")
+	for i, a := range set {
+		if a.Column == "" || a.Value == nil {
+			return "", fmt.Errorf("UPDATE: empty column or value at index %d", i)
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(string(a.Column))
+		sb.WriteString(" = ")
+		sb.WriteString(a.Value.Expression())
+	}
+	if partition != nil {
+		sb.WriteString(" IN PARTITION ")
+		sb.WriteString(partition.Expression())
+	}
+	sb.WriteString(" WHERE ")
+	sb.WriteString(where.Expression())
+	return sb.String(), nil
+}
+
+// partition operations, see https://clickhouse.com/docs/sql-reference/statements/alter/partition
+
+func (b *AlterTableBuilder) partition(verb string, partition Expression, suffix string) *AlterTableBuilder {
+	return b.add(func(alterOptions) (string, error) {
+		if partition == nil {
+			return "", errors.New(verb + ": empty partition")
+		}
+		return verb + " " + partition.Expression() + suffix, nil
+	})
+}
+
+func (b *AlterTableBuilder) DropPartition(partition Expression) *AlterTableBuilder {
+	return b.partition("DROP PARTITION", partition, "")
+}
+
+func (b *AlterTableBuilder) DetachPartition(partition Expression) *AlterTableBuilder {
+	return b.partition("DETACH PARTITION", partition, "")
+}
+
+func (b *AlterTableBuilder) AttachPartition(partition Expression) *AlterTableBuilder {
+	return b.partition("ATTACH PARTITION", partition, "")
+}
+
+// FreezePartition creates a local backup of the partition.
+func (b *AlterTableBuilder) FreezePartition(partition Expression) *AlterTableBuilder {
+	return b.partition("FREEZE PARTITION", partition, "")
+}
+
+// Freeze creates a local backup of all partitions.
+func (b *AlterTableBuilder) Freeze() *AlterTableBuilder {
+	return b.simple("FREEZE", "", "")
+}
+
+// MovePartitionToTable moves the partition to another table with the same structure.
+func (b *AlterTableBuilder) MovePartitionToTable(partition Expression, dest Table) *AlterTableBuilder {
+	return b.partition("MOVE PARTITION", partition, " TO TABLE "+string(dest))
+}
+
+func (b *AlterTableBuilder) MovePartitionToDisk(partition Expression, disk string) *AlterTableBuilder {
+	return b.partition("MOVE PARTITION", partition, " TO DISK "+quoted(disk).Expression())
+}
+
+func (b *AlterTableBuilder) MovePartitionToVolume(partition Expression, volume string) *AlterTableBuilder {
+	return b.partition("MOVE PARTITION", partition, " TO VOLUME "+quoted(volume).Expression())
+}
+
+// PrettyPrint renders every command in its own line.
+func (b *AlterTableBuilder) PrettyPrint(v ...bool) *AlterTableBuilder {
+	if len(v) == 0 || v[0] {
+		b.style = prettyStyle
+	} else {
+		b.style = defaultStyle
+	}
+	b.styleSet = true
+	return b
+}
+
+func (b *AlterTableBuilder) BuildString() (string, error) {
+	style := defaultStyle
+	if b.styleSet {
+		style = b.style
+	}
+	if b.table == "" {
+		return "", errors.New("no table")
+	}
+	if len(b.commands) == 0 {
+		return "", errors.New("no commands")
+	}
+	var sb strings.Builder
+	sb.WriteString("ALTER TABLE ")
+	sb.WriteString(string(b.table))
+	if b.cluster != "" {
+		sb.WriteString(" ON CLUSTER ")
+		sb.WriteString(b.cluster)
+	}
+	for i, cmd := range b.commands {
+		s, err := cmd(alterOptions{idempotent: b.idempotent, allowAll: b.allowAll})
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if style.Indent == "" {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteByte('\n')
+			sb.WriteString(style.Indent)
+		}
+		sb.WriteString(s)
+	}
+	settings, err := b.settings.clause()
+	if err != nil {
+		return "", err
+	}
+	if settings != "" && style.Indent != "" {
+		settings = "\n" + settings[1:]
+	}
+	sb.WriteString(settings)
+	return sb.String(), nil
+}
+
+func ifExists(idempotent bool) string {
+	if idempotent {
+		return "IF EXISTS "
+	}
+	return ""
+}
+
+func ifNotExists(idempotent bool) string {
+	if idempotent {
+		return "IF NOT EXISTS "
+	}
+	return ""
+}
+
+func joinExpressions(exprs []Expression) string {
+	var sb strings.Builder
+	for i, e := range exprs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(e.Expression())
+	}
+	return sb.String()
+}
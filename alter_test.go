@@ -0,0 +1,138 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestAlterTable(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *AlterTableBuilder
+		want string
+	}{
+		{
+			name: "columns",
+			b: AlterTable("db.events").OnCluster("main").
+				AddColumn(ColumnDefinition{Name: "b", Type: "String", Default: LiteralExpressionQuoted(""), Codec: "ZSTD(3)", Comment: "it's b"}, "a").
+				AddColumnFirst(ColumnDefinition{Name: "id", Type: "UInt64"}).
+				DropColumn("c").
+				ModifyColumn(ColumnDefinition{Name: "d", Type: "LowCardinality(String)"}).
+				RenameColumn("e", "f").
+				CommentColumn("f", "renamed"),
+			want: "ALTER TABLE db.events ON CLUSTER main ADD COLUMN b String DEFAULT '' CODEC(ZSTD(3)) COMMENT 'it\\'s b' AFTER a, " +
+				"ADD COLUMN id UInt64 FIRST, DROP COLUMN c, MODIFY COLUMN d LowCardinality(String), RENAME COLUMN e TO f, COMMENT COLUMN f 'renamed'",
+		},
+		{
+			name: "idempotent",
+			b: AlterTable("tbl").Idempotent().
+				AddColumn(ColumnDefinition{Name: "a", Type: "UInt8", Materialized: Fn("length", Column("s"))}).
+				DropColumn("b").
+				AddIndex("idx_s", Column("s"), IndexTokenBF(1024, 3, 0), 4).
+				DropProjection("p"),
+			want: "ALTER TABLE tbl ADD COLUMN IF NOT EXISTS a UInt8 MATERIALIZED length(s), DROP COLUMN IF EXISTS b, " +
+				"ADD INDEX IF NOT EXISTS idx_s s TYPE tokenbf_v1(1024, 3, 0) GRANULARITY 4, DROP PROJECTION IF EXISTS p",
+		},
+		{
+			name: "indices",
+			b: AlterTable("tbl").
+				AddIndex("idx_a", Column("a"), IndexMinMax(), 1).
+				AddIndex("idx_b", Column("b"), IndexSet(100), 2).
+				AddIndex("idx_c", Column("c"), IndexBloomFilter(0.01), 1).
+				MaterializeIndex("idx_a").
+				DropIndex("idx_old"),
+			want: "ALTER TABLE tbl ADD INDEX idx_a a TYPE minmax GRANULARITY 1, ADD INDEX idx_b b TYPE set(100) GRANULARITY 2, " +
+				"ADD INDEX idx_c c TYPE bloom_filter(0.01) GRANULARITY 1, MATERIALIZE INDEX idx_a, DROP INDEX idx_old",
+		},
+		{
+			name: "projection",
+			b: AlterTable("tbl").
+				AddProjection("p", Select(Column("a"), Sum(Column("b"))).GroupBy(Column("a"))).
+				MaterializeProjection("p"),
+			want: "ALTER TABLE tbl ADD PROJECTION p (SELECT a, sum(b) GROUP BY a), MATERIALIZE PROJECTION p",
+		},
+		{
+			name: "ttl",
+			b:    AlterTable("tbl").ModifyTTL(Plus(Column("ts"), Interval(30, UnitDay))),
+			want: "ALTER TABLE tbl MODIFY TTL (ts + INTERVAL 30 DAY)",
+		},
+		{
+			name: "mutations",
+			b: AlterTable("tbl").
+				Delete(Equal(Column("user_id"), LiteralExpression(42))).
+				Update(Equal(Column("id"), LiteralExpression(1)), Set("email", LiteralExpressionQuoted("")), Set("name", LiteralExpressionQuoted("deleted"))),
+			want: "ALTER TABLE tbl DELETE WHERE (user_id = 42), UPDATE email = '', name = 'deleted' WHERE (id = 1)",
+		},
+		{
+			name: "partitions",
+			b: AlterTable("tbl").
+				DropPartition(LiteralExpression(202401)).
+				DetachPartition(PartitionID("202402")).
+				AttachPartition(LiteralExpressionQuoted("2024-03-01")).
+				FreezePartition(Fn("tuple")).
+				MovePartitionToTable(LiteralExpression(202404), "archive").
+				MovePartitionToDisk(LiteralExpression(202405), "cold").
+				MovePartitionToVolume(LiteralExpression(202406), "slow"),
+			want: "ALTER TABLE tbl DROP PARTITION 202401, DETACH PARTITION ID '202402', ATTACH PARTITION '2024-03-01', FREEZE PARTITION tuple(), " +
+				"MOVE PARTITION 202404 TO TABLE archive, MOVE PARTITION 202405 TO DISK 'cold', MOVE PARTITION 202406 TO VOLUME 'slow'",
+		},
+		{
+			name: "freeze and remove ttl",
+			b:    AlterTable("tbl").Freeze().RemoveTTL(),
+			want: "ALTER TABLE tbl FREEZE, REMOVE TTL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := must(tt.b.BuildString()); got != tt.want {
+				t.Errorf("BuildString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlterTable_Errors(t *testing.T) {
+	for name, b := range map[string]*AlterTableBuilder{
+		"no table":          AlterTable("").DropColumn("a"),
+		"no commands":       AlterTable("tbl"),
+		"no type":           AlterTable("tbl").AddColumn(ColumnDefinition{Name: "a"}),
+		"multiple defaults": AlterTable("tbl").AddColumn(ColumnDefinition{Name: "a", Type: "UInt8", Default: LiteralExpression(1), Alias: Column("b")}),
+		"delete everything": AlterTable("tbl").Delete(nil),
+		"delete if true":    AlterTable("tbl").Delete(True()),
+		"update all":        AlterTable("tbl").Update(Equal(LiteralExpression(1), LiteralExpression(1)), Set("a", LiteralExpression(1))),
+		"no assignments":    AlterTable("tbl").Update(Column("a")),
+		"projection FROM":   AlterTable("tbl").AddProjection("p", Select(Column("a")).From(Table("tbl"))),
+		"zero granularity":  AlterTable("tbl").AddIndex("idx", Column("a"), IndexMinMax(), 0),
+	} {
+		if _, err := b.BuildString(); err == nil {
+			t.Errorf("%s: expected error, got nothing", name)
+		}
+	}
+}
+
+func TestAlterTable_AllowEmptyWhere(t *testing.T) {
+	b := AlterTable("tbl").Delete(nil).Update(True(), Set("a", LiteralExpression(1))).AllowEmptyWhere()
+	if v := must(b.BuildString()); v != "ALTER TABLE tbl DELETE WHERE true, UPDATE a = 1 WHERE true" {
+		t.Fatal(v)
+	}
+}
+
+func TestAlterTable_BuildTwice(t *testing.T) {
+	b := AlterTable("tbl").Idempotent().DropColumn("a").RenameColumn("b", "c").Delete(Column("x"))
+	first, second := must(b.BuildString()), must(b.BuildString())
+	if first != second {
+		t.Fatalf("BuildString() = %q, then %q", first, second)
+	}
+	if first != "ALTER TABLE tbl DROP COLUMN IF EXISTS a, RENAME COLUMN IF EXISTS b TO c, DELETE WHERE x" {
+		t.Fatal(first)
+	}
+}
+
+func TestAlterTable_Pretty(t *testing.T) {
+	b := AlterTable("tbl").OnCluster("main").DropColumn("a").DropColumn("b").Setting(SettingMutationsSync, 2)
+	if v := must(PrettyString(b)); v != "ALTER TABLE tbl ON CLUSTER main\n\tDROP COLUMN a,\n\tDROP COLUMN b\nSETTINGS mutations_sync = 2" {
+		t.Fatal(v)
+	}
+	if v := must(b.BuildString()); v != "ALTER TABLE tbl ON CLUSTER main DROP COLUMN a, DROP COLUMN b SETTINGS mutations_sync = 2" {
+		t.Fatalf("original builder is modified: %s", v)
+	}
+}
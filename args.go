@@ -0,0 +1,221 @@
+package click
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Arg is a value bound to the query. It is rendered inline as a literal in String and BuildString,
+// or as a placeholder with the value passed separately in BuildArgs.
+func Arg(v any) Expression {
+	return argExpr{val: v}
+}
+
+type argExpr struct {
+	val any
+}
+
+func (a argExpr) Expression() string {
+	if a.val == nil {
+		return "NULL"
+	}
+	if ts, ok := a.val.(interface{ Unix() int64 }); ok {
+		return strconv.FormatInt(ts.Unix(), 10)
+	}
+	if rv := reflect.ValueOf(a.val); rv.Kind() == reflect.String {
+		return "'" + clickhouseStringEscapeReplacer.Replace(rv.String()) + "'"
+	}
+	return fmt.Sprint(a.val)
+}
+
+// literalValue is implemented by literal expressions, returning the underlying value,
+// and whether the value is a quoted string rather than a SQL expression.
+type literalValue interface {
+	literalValue() (v any, quoted bool)
+}
+
+func (e literalExpr[T]) literalValue() (any, bool) {
+	return e.val, e.quoteString
+}
+
+// Placeholder renders the placeholder of the n-th argument, counting from 1,
+// in SQL passed to database/sql drivers.
+type Placeholder func(n int) string
+
+// PlaceholderQuestion renders placeholders as `?`.
+func PlaceholderQuestion(int) string {
+	return "?"
+}
+
+// PlaceholderDollar renders placeholders as `$1`, `$2`, ...
+func PlaceholderDollar(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// argCollector binds values to placeholders. Rendering is free of side effects, so that expressions may be rendered
+// any number of times: bound values are rendered as markers, which are replaced with placeholders by resolve,
+// in the order of their appearance in the final SQL.
+type argCollector struct {
+	placeholder Placeholder
+	bound       []boundArg
+}
+
+// boundArg is a value bound to a marker, rendering as text if not empty, or a placeholder otherwise.
+type boundArg struct {
+	val  any
+	text string
+}
+
+// argMarker is the index of a bound value, which never appears in valid SQL.
+type argMarker int
+
+func (m argMarker) Expression() string {
+	return "\x00" + strconv.Itoa(int(m)) + "\x00"
+}
+
+func (c *argCollector) marker(val any, text string) Expression {
+	c.bound = append(c.bound, boundArg{val: val, text: text})
+	return argMarker(len(c.bound) - 1)
+}
+
+// resolve replaces markers in sql with placeholders, returning the SQL and bound values in order.
+func (c *argCollector) resolve(sql string) (string, []any) {
+	var (
+		sb   strings.Builder
+		args []any
+	)
+	for {
+		i := strings.IndexByte(sql, 0)
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(sql[i+1:], 0)
+		if j < 0 {
+			break
+		}
+		n, err := strconv.Atoi(sql[i+1 : i+1+j])
+		if err != nil || n < 0 || n >= len(c.bound) {
+			// not a marker
+			sb.WriteString(sql[:i+1])
+			sql = sql[i+1:]
+			continue
+		}
+		sb.WriteString(sql[:i])
+		arg := c.bound[n]
+		args = append(args, arg.val)
+		if arg.text != "" {
+			sb.WriteString(arg.text)
+		} else {
+			sb.WriteString(c.placeholder(len(args)))
+		}
+		sql = sql[i+j+2:]
+	}
+	sb.WriteString(sql)
+	return sb.String(), args
+}
+
+// BuildArgs renders the query with placeholders instead of inline values, returning the SQL and arguments,
+// which can be passed to database/sql drivers. Values of Arg and LiteralExpressionQuoted are passed as arguments.
+// Other literals, which may be SQL expressions like column names, are still rendered inline.
+// Like String of q, DefaultPolicy and DefaultGuardrail are not applied again.
+func BuildArgs(q SelectQuery, placeholder Placeholder) (string, []any, error) {
+	if q == nil {
+		return "", nil, errors.New("nil query")
+	}
+	if placeholder == nil {
+		return "", nil, errors.New("nil placeholder")
+	}
+	b, ok := selectBuilderOf(q)
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported query type %T", q)
+	}
+	// q is a snapshot returned by Build, which has DefaultPolicy and DefaultGuardrail applied already
+	c := &argCollector{placeholder: placeholder}
+	sql, err := b.rewrite(c.bind).buildString(b.renderStyle())
+	if err != nil {
+		return "", nil, err
+	}
+	sql, args := c.resolve(sql)
+	return sql, args, nil
+}
+
+// ExpressionArgs renders the expression with placeholders instead of inline values, like BuildArgs.
+func ExpressionArgs(e Expression, placeholder Placeholder) (string, []any) {
+	c := &argCollector{placeholder: placeholder}
+	return c.resolve(rewriteExpression(e, c.bind).Expression())
+}
+
+// Raw is a SQL fragment with `?` placeholders bound to args, e.g. `Raw("a = ?", 1)`.
+// Question marks in quoted strings and identifiers are not placeholders.
+// Raw panics if the number of placeholders does not match the number of arguments.
+func Raw(sql string, args ...any) Expression {
+	parts := splitPlaceholders(sql)
+	if len(parts) != len(args)+1 {
+		panic(fmt.Sprintf("raw SQL has %d placeholders but %d arguments", len(parts)-1, len(args)))
+	}
+	r := rawExpr{
+		parts: parts,
+		args:  make([]Expression, len(args)),
+	}
+	for i := range args {
+		r.args[i] = Arg(args[i])
+	}
+	return r
+}
+
+type rawExpr struct {
+	parts []string
+	args  []Expression // len(args) == len(parts)-1
+}
+
+func (r rawExpr) Expression() string {
+	var sb strings.Builder
+	for i, p := range r.parts {
+		if i > 0 {
+			sb.WriteString(r.args[i-1].Expression())
+		}
+		sb.WriteString(p)
+	}
+	return sb.String()
+}
+
+// splitPlaceholders splits sql by `?` outside of quoted strings and identifiers.
+func splitPlaceholders(sql string) []string {
+	var (
+		parts []string
+		quote byte
+		start int
+	)
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			parts = append(parts, sql[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, sql[start:])
+}
+
+// bind replaces bound values with placeholders.
+func (c *argCollector) bind(e Expression) Expression {
+	switch e := e.(type) {
+	case argExpr:
+		return c.marker(e.val, "")
+	case literalValue:
+		if v, quoted := e.literalValue(); quoted {
+			return c.marker(v, "")
+		}
+	}
+	return e
+}
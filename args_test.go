@@ -0,0 +1,127 @@
+package click
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildArgs(t *testing.T) {
+	avgScore := As(Avg(If(Equal(Column("kind"), Arg("exam")), Column("score"), Arg(0))), LiteralExpression("avg_score"))
+	q := must(Select(Column("user"), avgScore).
+		From(Select(LiteralExpression("*")).From(Table("scores")).Where(GreaterThan(Column("ts"), Arg(time.Unix(100, 0))))).
+		Where(In(Column("user"), Tuple(LiteralExpressions([]string{"a", "b"}, true)))).
+		GroupBy(Column("user")).
+		Having(GreaterThan(avgScore, Arg(60))).
+		Build())
+	tests := []struct {
+		name        string
+		placeholder Placeholder
+		want        string
+	}{
+		{
+			name:        "question",
+			placeholder: PlaceholderQuestion,
+			want:        "SELECT user, avg(if((kind = ?), score, ?)) AS avg_score FROM (\nSELECT * FROM scores WHERE (ts > ?)\n) WHERE (user IN (?, ?)) GROUP BY user HAVING (avg_score > ?)",
+		},
+		{
+			name:        "dollar",
+			placeholder: PlaceholderDollar,
+			want:        "SELECT user, avg(if((kind = $1), score, $2)) AS avg_score FROM (\nSELECT * FROM scores WHERE (ts > $3)\n) WHERE (user IN ($4, $5)) GROUP BY user HAVING (avg_score > $6)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := BuildArgs(q, tt.placeholder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.want {
+				t.Errorf("BuildArgs() query = %q, want %q", query, tt.want)
+			}
+			wantArgs := []any{"exam", 0, time.Unix(100, 0), "a", "b", 60}
+			if !reflect.DeepEqual(args, wantArgs) {
+				t.Errorf("BuildArgs() args = %v, want %v", args, wantArgs)
+			}
+		})
+	}
+	// inline rendering is not affected
+	if v := q.String(); v != "SELECT user, avg(if((kind = 'exam'), score, 0)) AS avg_score FROM (\nSELECT * FROM scores WHERE (ts > 100)\n) WHERE (user IN ('a', 'b')) GROUP BY user HAVING (avg_score > 60)" {
+		t.Fatalf("%q", v)
+	}
+}
+
+func TestArg_Expression(t *testing.T) {
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{nil, "NULL"},
+		{"it's", `'it\'s'`},
+		{1.5, "1.5"},
+		{time.Unix(1, 0), "1"},
+	}
+	for _, tt := range tests {
+		if got := Arg(tt.v).Expression(); got != tt.want {
+			t.Errorf("Arg(%v).Expression() = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRaw(t *testing.T) {
+	e := And(Raw("a = ? AND b != '?' AND `c?` > ?", "x", 2), Equal(Column("d"), Arg(3)))
+	if v := e.Expression(); v != "(a = 'x' AND b != '?' AND `c?` > 2 AND (d = 3))" {
+		t.Fatal(v)
+	}
+	sql, args := ExpressionArgs(e, PlaceholderDollar)
+	if sql != "(a = $1 AND b != '?' AND `c?` > $2 AND (d = $3))" {
+		t.Fatal(sql)
+	}
+	if !reflect.DeepEqual(args, []any{"x", 2, 3}) {
+		t.Fatal(args)
+	}
+}
+
+func TestRaw_PlaceholderMismatch(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic, got nothing")
+		}
+	}()
+	Raw("a = ?")
+}
+
+func TestBuildArgs_Policy(t *testing.T) {
+	old := DefaultPolicy
+	DefaultPolicy = NewPolicy()
+	defer func() { DefaultPolicy = old }()
+	RegisterPolicy(Table("t"), Equal(Column("tenant_id"), LiteralExpressionQuoted("t1")))
+
+	b := Select(Column("a")).From(Table("t")).Where(Equal(Column("b"), Arg(5)))
+	query, args, err := BuildArgs(must(b.Build()), PlaceholderQuestion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT a FROM t WHERE ((b = ?) AND (tenant_id = ?))" {
+		t.Errorf("BuildArgs() query = %q", query)
+	}
+	if wantArgs := []any{5, "t1"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("BuildArgs() args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestBuildArgs_Pretty(t *testing.T) {
+	level := Case().When(GreaterThan(Column("n"), Arg(100)), Arg("high")).Else(Arg("low"))
+	q := must(Select(As(level, Column("level"))).From(Table("t")).PrettyPrint().Build())
+	query, args, err := BuildArgs(q, PlaceholderDollar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT\n\tCASE\n\t\tWHEN (n > $1) THEN $2\n\t\tELSE $3\n\tEND AS level\nFROM\n\tt"
+	if query != want {
+		t.Errorf("BuildArgs() query = %q, want %q", query, want)
+	}
+	if wantArgs := []any{100, "high", "low"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("BuildArgs() args = %v, want %v", args, wantArgs)
+	}
+}
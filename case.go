@@ -0,0 +1,185 @@
+package click
+
+import (
+	"strings"
+)
+
+// CaseExpression is a conditional expression, rendered as `CASE WHEN ... THEN ... ELSE ... END`,
+// or as `multiIf(...)` after calling MultiIf.
+// Its methods return modified copies, so a partially built CaseExpression can be shared.
+// See https://clickhouse.com/docs/sql-reference/operators#conditional-expression
+type CaseExpression struct {
+	operand Expression
+	conds   []Expression
+	results []Expression
+	els     Expression
+	multiIf bool
+}
+
+// Case starts a searched CASE expression, `CASE WHEN cond THEN result ... END`.
+func Case() CaseExpression {
+	return CaseExpression{}
+}
+
+// CaseOf starts a simple CASE expression, `CASE operand WHEN value THEN result ... END`.
+func CaseOf(operand Expression) CaseExpression {
+	if operand == nil {
+		panic("empty operand in CASE expression")
+	}
+	return CaseExpression{operand: operand}
+}
+
+// When adds a branch. For simple CASE, cond is the value compared to the operand.
+func (c CaseExpression) When(cond, result Expression) CaseExpression {
+	if cond == nil || result == nil {
+		panic("empty WHEN branch in CASE expression")
+	}
+	c.conds = appendCopy(c.conds, cond)
+	c.results = appendCopy(c.results, result)
+	return c
+}
+
+// Else sets the result when no branch matches. Without Else, the result is NULL.
+func (c CaseExpression) Else(result Expression) CaseExpression {
+	c.els = result
+	return c
+}
+
+// MultiIf renders the expression as `multiIf(cond1, result1, cond2, result2, ..., else)`.
+// For simple CASE, conditions are rendered as `(operand = value)`.
+// See https://clickhouse.com/docs/sql-reference/functions/conditional-functions#multiif
+func (c CaseExpression) MultiIf() CaseExpression {
+	c.multiIf = true
+	return c
+}
+
+func (c CaseExpression) Expression() string {
+	return c.render(false, "", "")
+}
+
+// render writes the expression in a single line, or in multiple lines in pretty style,
+// where continuation lines begin with linePrefix, and branches are indented further with indent.
+func (c CaseExpression) render(pretty bool, linePrefix, indent string) string {
+	if len(c.conds) == 0 {
+		panic("CASE expression must have at least one WHEN branch")
+	}
+	part := func(e Expression) string {
+		if pretty {
+			if s, ok := multiline(e, linePrefix+indent, indent); ok {
+				return s
+			}
+		}
+		return e.Expression()
+	}
+	var sb strings.Builder
+	branch := func(first bool) {
+		switch {
+		case pretty:
+			sb.WriteByte('\n')
+			sb.WriteString(linePrefix)
+			sb.WriteString(indent)
+		case !first || !c.multiIf:
+			sb.WriteByte(' ')
+		}
+	}
+	if c.multiIf {
+		sb.WriteString("multiIf(")
+		for i := range c.conds {
+			branch(i == 0)
+			cond := c.conds[i]
+			if c.operand != nil {
+				cond = Equal(c.operand, cond)
+			}
+			sb.WriteString(part(cond))
+			sb.WriteString(", ")
+			sb.WriteString(part(c.results[i]))
+			sb.WriteByte(',')
+		}
+		branch(false)
+		if c.els != nil {
+			sb.WriteString(part(c.els))
+		} else {
+			sb.WriteString("NULL")
+		}
+		if pretty {
+			sb.WriteByte('\n')
+			sb.WriteString(linePrefix)
+		}
+		sb.WriteByte(')')
+		return sb.String()
+	}
+	sb.WriteString("CASE")
+	if c.operand != nil {
+		sb.WriteByte(' ')
+		sb.WriteString(part(c.operand))
+	}
+	for i := range c.conds {
+		branch(false)
+		sb.WriteString("WHEN ")
+		sb.WriteString(part(c.conds[i]))
+		sb.WriteString(" THEN ")
+		sb.WriteString(part(c.results[i]))
+	}
+	if c.els != nil {
+		branch(false)
+		sb.WriteString("ELSE ")
+		sb.WriteString(part(c.els))
+	}
+	if pretty {
+		sb.WriteByte('\n')
+		sb.WriteString(linePrefix)
+	} else {
+		sb.WriteByte(' ')
+	}
+	sb.WriteString("END")
+	return sb.String()
+}
+
+// children returns the operand, conditions and results interleaved, and the else result, skipping absent ones.
+func (c CaseExpression) children() []Expression {
+	var ret []Expression
+	if c.operand != nil {
+		ret = append(ret, c.operand)
+	}
+	for i := range c.conds {
+		ret = append(ret, c.conds[i], c.results[i])
+	}
+	if c.els != nil {
+		ret = append(ret, c.els)
+	}
+	return ret
+}
+
+func (c CaseExpression) withChildren(ch []Expression) CaseExpression {
+	if c.operand != nil {
+		c.operand, ch = ch[0], ch[1:]
+	}
+	c.conds = make([]Expression, len(c.conds))
+	c.results = make([]Expression, len(c.results))
+	for i := range c.conds {
+		c.conds[i], c.results[i], ch = ch[0], ch[1], ch[2:]
+	}
+	if c.els != nil {
+		c.els = ch[0]
+	}
+	return c
+}
+
+// multiline renders expressions spanning multiple lines in pretty style, like CASE.
+// Continuation lines begin with linePrefix, and nested lines are indented further with indent.
+// It returns false if the expression is always rendered in a single line.
+func multiline(e Expression, linePrefix, indent string) (string, bool) {
+	switch e := e.(type) {
+	case CaseExpression:
+		return e.render(true, linePrefix, indent), true
+	case asExpression:
+		if s, ok := multiline(e.Left, linePrefix, indent); ok {
+			return s + " AS " + e.Right.Expression(), true
+		}
+	case orderByExpression:
+		if s, ok := multiline(e.expression, linePrefix, indent); ok {
+			return s + e.directionSuffix(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,106 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestCaseExpression(t *testing.T) {
+	score := Column("score")
+	buckets := Case().
+		When(LessThan(score, LiteralExpression(60)), LiteralExpressionQuoted("low")).
+		When(LessThan(score, LiteralExpression(90)), LiteralExpressionQuoted("mid"))
+	tests := []struct {
+		name string
+		expr Expression
+		want string
+	}{
+		{
+			name: "searched",
+			expr: buckets.Else(LiteralExpressionQuoted("high")),
+			want: "CASE WHEN (score < 60) THEN 'low' WHEN (score < 90) THEN 'mid' ELSE 'high' END",
+		},
+		{
+			name: "without else",
+			expr: buckets,
+			want: "CASE WHEN (score < 60) THEN 'low' WHEN (score < 90) THEN 'mid' END",
+		},
+		{
+			name: "simple",
+			expr: CaseOf(Column("code")).When(LiteralExpression(1), LiteralExpressionQuoted("a")).Else(LiteralExpressionQuoted("b")),
+			want: "CASE code WHEN 1 THEN 'a' ELSE 'b' END",
+		},
+		{
+			name: "multiIf",
+			expr: buckets.Else(LiteralExpressionQuoted("high")).MultiIf(),
+			want: "multiIf((score < 60), 'low', (score < 90), 'mid', 'high')",
+		},
+		{
+			name: "simple multiIf without else",
+			expr: CaseOf(Column("code")).When(LiteralExpression(1), LiteralExpressionQuoted("a")).MultiIf(),
+			want: "multiIf((code = 1), 'a', NULL)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expr.Expression(); got != tt.want {
+				t.Errorf("Expression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaseExpression_Empty(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic, got nothing")
+		}
+	}()
+	Case().Else(LiteralExpression(1)).Expression()
+}
+
+func TestCaseExpression_Select(t *testing.T) {
+	bucket := Case().
+		When(LessThan(Column("score"), LiteralExpression(60)), LiteralExpressionQuoted("low")).
+		Else(LiteralExpressionQuoted("high"))
+	s := Select(As(bucket, Alias("bucket")), Count()).From(Table("tbl")).GroupBy(bucket).OrderBy(Desc(bucket))
+	if v := must(s.BuildString()); v != "SELECT CASE WHEN (score < 60) THEN 'low' ELSE 'high' END AS bucket, count() FROM tbl "+
+		"GROUP BY CASE WHEN (score < 60) THEN 'low' ELSE 'high' END ORDER BY CASE WHEN (score < 60) THEN 'low' ELSE 'high' END DESC" {
+		t.Fatal(v)
+	}
+	s = Select(As(bucket, Alias("bucket")), As(bucket.MultiIf(), Alias("m"))).From(Table("tbl")).OrderBy(Desc(bucket))
+	want := "SELECT\n" +
+		"\tCASE\n" +
+		"\t\tWHEN (score < 60) THEN 'low'\n" +
+		"\t\tELSE 'high'\n" +
+		"\tEND AS bucket,\n" +
+		"\tmultiIf(\n" +
+		"\t\t(score < 60), 'low',\n" +
+		"\t\t'high'\n" +
+		"\t) AS m\n" +
+		"FROM\n" +
+		"\ttbl\n" +
+		"ORDER BY\n" +
+		"\tCASE\n" +
+		"\t\tWHEN (score < 60) THEN 'low'\n" +
+		"\t\tELSE 'high'\n" +
+		"\tEND DESC"
+	if v := must(s.PrettyPrint().BuildString()); v != want {
+		t.Fatal(v)
+	}
+}
+
+func TestCaseExpression_Rewrite(t *testing.T) {
+	c := CaseOf(Column("a")).When(LiteralExpression(1), Column("b")).Else(Column("c"))
+	got := must(Rewrite(c, func(e Expression) (Expression, error) {
+		if col, ok := e.(Column); ok {
+			return Column("t." + col), nil
+		}
+		return e, nil
+	}))
+	if v := got.Expression(); v != "CASE t.a WHEN 1 THEN t.b ELSE t.c END" {
+		t.Fatal(v)
+	}
+	if v := c.Expression(); v != "CASE a WHEN 1 THEN b ELSE c END" {
+		t.Fatalf("original expression is modified: %s", v)
+	}
+}
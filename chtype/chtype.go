@@ -0,0 +1,480 @@
+// Package chtype parses ClickHouse data type names, like `Nullable(DateTime64(3, 'UTC'))`.
+// See https://clickhouse.com/docs/sql-reference/data-types
+package chtype
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Type is a parsed ClickHouse data type.
+type Type struct {
+	// Name is the type name without parameters, e.g. `DateTime64` or `Array`.
+	Name string
+	// Params are non-type parameters in their SQL form, e.g. `3` and `'UTC'` in `DateTime64(3, 'UTC')`.
+	// For SimpleAggregateFunction, it contains the function name.
+	Params []string
+	// Elems are nested types, e.g. the element type of Array, key and value types of Map, or elements of Tuple.
+	Elems []Type
+	// FieldNames are element names of named Tuple and Nested. It is nil for other types.
+	FieldNames []string
+}
+
+// types whose all parameters are types
+var containerTypes = map[string]bool{
+	"Nullable":       true,
+	"LowCardinality": true,
+	"Array":          true,
+	"Map":            true,
+	"Tuple":          true,
+	"Nested":         true,
+	"Variant":        true,
+}
+
+// Parse parses a ClickHouse data type name.
+func Parse(s string) (Type, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Type{}, errors.New("empty type")
+	}
+	open := strings.IndexByte(s, '(')
+	if open < 0 {
+		if !isTypeName(s) {
+			return Type{}, fmt.Errorf("invalid type name: %q", s)
+		}
+		return Type{Name: s}, nil
+	}
+	if !strings.HasSuffix(s, ")") {
+		return Type{}, fmt.Errorf("unbalanced parentheses in type: %q", s)
+	}
+	t := Type{Name: strings.TrimSpace(s[:open])}
+	if !isTypeName(t.Name) {
+		return Type{}, fmt.Errorf("invalid type name: %q", t.Name)
+	}
+	args, err := SplitArgs(s[open+1 : len(s)-1])
+	if err != nil {
+		return Type{}, fmt.Errorf("parse type %q: %w", s, err)
+	}
+	switch {
+	case t.Name == "Tuple" || t.Name == "Nested":
+		for _, arg := range args {
+			name, typ, err := parseField(arg)
+			if err != nil {
+				return Type{}, fmt.Errorf("parse type %q: %w", s, err)
+			}
+			if name != "" {
+				t.FieldNames = append(t.FieldNames, name)
+			} else if t.FieldNames != nil {
+				return Type{}, fmt.Errorf("parse type %q: mixed named and unnamed elements", s)
+			}
+			t.Elems = append(t.Elems, typ)
+		}
+		if t.FieldNames != nil && len(t.FieldNames) != len(t.Elems) {
+			return Type{}, fmt.Errorf("parse type %q: mixed named and unnamed elements", s)
+		}
+	case containerTypes[t.Name]:
+		for _, arg := range args {
+			typ, err := Parse(arg)
+			if err != nil {
+				return Type{}, fmt.Errorf("parse type %q: %w", s, err)
+			}
+			t.Elems = append(t.Elems, typ)
+		}
+	case t.Name == "SimpleAggregateFunction":
+		if len(args) < 2 {
+			return Type{}, fmt.Errorf("parse type %q: expected function name and type", s)
+		}
+		t.Params = args[:1]
+		for _, arg := range args[1:] {
+			typ, err := Parse(arg)
+			if err != nil {
+				return Type{}, fmt.Errorf("parse type %q: %w", s, err)
+			}
+			t.Elems = append(t.Elems, typ)
+		}
+	default:
+		t.Params = args
+	}
+	if err := t.validate(); err != nil {
+		return Type{}, fmt.Errorf("parse type %q: %w", s, err)
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics on error. It is intended for static type names.
+func MustParse(s string) Type {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (t Type) validate() error {
+	expected := -1
+	switch t.Name {
+	case "Nullable", "LowCardinality", "Array":
+		expected = 1
+	case "Map":
+		expected = 2
+	}
+	if expected >= 0 && len(t.Elems) != expected {
+		return fmt.Errorf("%s expects %d type arguments, got %d", t.Name, expected, len(t.Elems))
+	}
+	return nil
+}
+
+// parseField parses a Tuple element, which is either `Type` or `name Type`.
+func parseField(s string) (name string, typ Type, err error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "`") {
+		end := strings.IndexByte(s[1:], '`')
+		if end < 0 {
+			return "", Type{}, fmt.Errorf("unterminated quoted name: %q", s)
+		}
+		typ, err = Parse(s[end+2:])
+		return s[1 : end+1], typ, err
+	}
+	sp := strings.IndexAny(s, " \t")
+	if open := strings.IndexByte(s, '('); sp < 0 || (open >= 0 && open < sp) {
+		typ, err = Parse(s)
+		return "", typ, err
+	}
+	typ, err = Parse(s[sp+1:])
+	return s[:sp], typ, err
+}
+
+func isTypeName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SplitArgs splits comma separated arguments, ignoring commas in nested parentheses and quoted strings.
+// Arguments are trimmed.
+func SplitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			switch c {
+			case '\\':
+				i++
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth < 0 {
+				return nil, errors.New("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quoted string")
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced parentheses")
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(args) > 0 {
+		args = append(args, last)
+	}
+	return args, nil
+}
+
+func (t Type) String() string {
+	if len(t.Params) == 0 && len(t.Elems) == 0 {
+		return t.Name
+	}
+	var sb strings.Builder
+	sb.WriteString(t.Name)
+	sb.WriteByte('(')
+	for i, p := range t.Params {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p)
+	}
+	for i, e := range t.Elems {
+		if i > 0 || len(t.Params) > 0 {
+			sb.WriteString(", ")
+		}
+		if t.FieldNames != nil {
+			sb.WriteString(t.FieldNames[i])
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(e.String())
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// Elem returns the only nested type of Nullable, LowCardinality, Array and SimpleAggregateFunction.
+func (t Type) Elem() Type {
+	if len(t.Elems) == 0 {
+		return Type{}
+	}
+	return t.Elems[len(t.Elems)-1]
+}
+
+// Base strips LowCardinality, Nullable and SimpleAggregateFunction wrappers,
+// returning the type of stored values.
+func (t Type) Base() Type {
+	for {
+		switch t.Name {
+		case "LowCardinality", "Nullable", "SimpleAggregateFunction":
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// IsNullable reports whether the type accepts NULL, including LowCardinality(Nullable(T)).
+func (t Type) IsNullable() bool {
+	for {
+		switch t.Name {
+		case "Nullable":
+			return true
+		case "LowCardinality", "SimpleAggregateFunction":
+			t = t.Elem()
+		default:
+			return false
+		}
+	}
+}
+
+// IntBits returns bit width of integer types, e.g. 32 for Int32 and UInt32, and 0 for other types.
+func (t Type) IntBits() int {
+	name := strings.TrimPrefix(t.Name, "U")
+	if !strings.HasPrefix(name, "Int") {
+		return 0
+	}
+	bits, err := strconv.Atoi(name[3:])
+	if err != nil {
+		return 0
+	}
+	switch bits {
+	case 8, 16, 32, 64, 128, 256:
+		return bits
+	default:
+		return 0
+	}
+}
+
+func (t Type) IsInteger() bool {
+	return t.IntBits() > 0
+}
+
+func (t Type) IsUnsigned() bool {
+	return t.IsInteger() && strings.HasPrefix(t.Name, "U")
+}
+
+func (t Type) IsFloat() bool {
+	return t.Name == "Float32" || t.Name == "Float64" || t.Name == "BFloat16"
+}
+
+func (t Type) IsDecimal() bool {
+	return strings.HasPrefix(t.Name, "Decimal")
+}
+
+// IsNumeric reports whether the type is integer, floating point or decimal.
+func (t Type) IsNumeric() bool {
+	return t.IsInteger() || t.IsFloat() || t.IsDecimal()
+}
+
+// IsString reports whether the type is String or FixedString.
+func (t Type) IsString() bool {
+	return t.Name == "String" || t.Name == "FixedString"
+}
+
+// IsTemporal reports whether the type is a date or date-time type.
+func (t Type) IsTemporal() bool {
+	switch t.Name {
+	case "Date", "Date32", "DateTime", "DateTime32", "DateTime64":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsEnum reports whether the type is Enum8 or Enum16.
+func (t Type) IsEnum() bool {
+	return t.Name == "Enum8" || t.Name == "Enum16" || t.Name == "Enum"
+}
+
+// DecimalPrecisionScale returns precision and scale of decimal types.
+func (t Type) DecimalPrecisionScale() (precision, scale int, err error) {
+	switch t.Name {
+	case "Decimal":
+		if len(t.Params) < 1 || len(t.Params) > 2 {
+			return 0, 0, fmt.Errorf("invalid decimal type: %s", t)
+		}
+		if precision, err = strconv.Atoi(t.Params[0]); err != nil {
+			return 0, 0, fmt.Errorf("invalid decimal precision: %s", t)
+		}
+		if len(t.Params) == 2 {
+			if scale, err = strconv.Atoi(t.Params[1]); err != nil {
+				return 0, 0, fmt.Errorf("invalid decimal scale: %s", t)
+			}
+		}
+	case "Decimal32", "Decimal64", "Decimal128", "Decimal256":
+		if len(t.Params) != 1 {
+			return 0, 0, fmt.Errorf("invalid decimal type: %s", t)
+		}
+		if scale, err = strconv.Atoi(t.Params[0]); err != nil {
+			return 0, 0, fmt.Errorf("invalid decimal scale: %s", t)
+		}
+		precision = map[string]int{"Decimal32": 9, "Decimal64": 18, "Decimal128": 38, "Decimal256": 76}[t.Name]
+	default:
+		return 0, 0, fmt.Errorf("not a decimal type: %s", t)
+	}
+	if precision < 1 || precision > 76 || scale < 0 || scale > precision {
+		return 0, 0, fmt.Errorf("invalid decimal type: %s", t)
+	}
+	return precision, scale, nil
+}
+
+// DateTimePrecision returns sub-second precision of DateTime64, and 0 for other types.
+func (t Type) DateTimePrecision() int {
+	if t.Name != "DateTime64" || len(t.Params) == 0 {
+		return 0
+	}
+	p, _ := strconv.Atoi(t.Params[0])
+	return p
+}
+
+// TimeZone returns the explicit time zone of DateTime and DateTime64, or empty string if absent.
+func (t Type) TimeZone() string {
+	var p string
+	switch {
+	case (t.Name == "DateTime" || t.Name == "DateTime32") && len(t.Params) == 1:
+		p = t.Params[0]
+	case t.Name == "DateTime64" && len(t.Params) == 2:
+		p = t.Params[1]
+	default:
+		return ""
+	}
+	s, err := Unquote(p)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// EnumValues returns name-value pairs of Enum8 and Enum16, e.g. `'a' = 1`.
+func (t Type) EnumValues() (map[string]int, error) {
+	if !t.IsEnum() {
+		return nil, fmt.Errorf("not an enum type: %s", t)
+	}
+	ret := make(map[string]int, len(t.Params))
+	for i, p := range t.Params {
+		eq := strings.LastIndexByte(p, '=')
+		if eq < 0 {
+			// values are implicitly numbered from 1 if omitted
+			name, err := Unquote(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum element %q: %w", p, err)
+			}
+			ret[name] = i + 1
+			continue
+		}
+		name, err := Unquote(strings.TrimSpace(p[:eq]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum element %q: %w", p, err)
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(p[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid enum element %q: %w", p, err)
+		}
+		ret[name] = v
+	}
+	return ret, nil
+}
+
+// FixedStringLength returns N of FixedString(N).
+func (t Type) FixedStringLength() (int, error) {
+	if t.Name != "FixedString" || len(t.Params) != 1 {
+		return 0, fmt.Errorf("not a FixedString type: %s", t)
+	}
+	n, err := strconv.Atoi(t.Params[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid FixedString length: %s", t)
+	}
+	return n, nil
+}
+
+// Unquote unquotes a single-quoted SQL string literal with backslash escapes.
+func Unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", fmt.Errorf("not a quoted string: %s", s)
+	}
+	s = s[1 : len(s)-1]
+	if !strings.ContainsAny(s, `\'`) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			sb.WriteByte(unescapeByte(s[i]))
+		case c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			// SQL standard escape of single quote
+			i++
+			sb.WriteByte('\'')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
+
+// unescapeByte returns the byte escaped with backslash, e.g. 'n' for newline.
+func unescapeByte(c byte) byte {
+	switch c {
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case '0':
+		return 0
+	default:
+		return c
+	}
+}
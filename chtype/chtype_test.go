@@ -0,0 +1,116 @@
+package chtype
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Type
+	}{
+		{
+			name: "simple",
+			in:   "UInt64",
+			want: Type{Name: "UInt64"},
+		},
+		{
+			name: "parameterized",
+			in:   "DateTime64(3, 'Asia/Shanghai')",
+			want: Type{Name: "DateTime64", Params: []string{"3", "'Asia/Shanghai'"}},
+		},
+		{
+			name: "nested",
+			in:   "Array(Nullable(String))",
+			want: Type{Name: "Array", Elems: []Type{{Name: "Nullable", Elems: []Type{{Name: "String"}}}}},
+		},
+		{
+			name: "map",
+			in:   "Map(LowCardinality(String), UInt8)",
+			want: Type{Name: "Map", Elems: []Type{{Name: "LowCardinality", Elems: []Type{{Name: "String"}}}, {Name: "UInt8"}}},
+		},
+		{
+			name: "unnamed tuple",
+			in:   "Tuple(String, Decimal(18, 4))",
+			want: Type{Name: "Tuple", Elems: []Type{{Name: "String"}, {Name: "Decimal", Params: []string{"18", "4"}}}},
+		},
+		{
+			name: "named tuple",
+			in:   "Tuple(a UInt8, `b c` DateTime('UTC'))",
+			want: Type{
+				Name:       "Tuple",
+				Elems:      []Type{{Name: "UInt8"}, {Name: "DateTime", Params: []string{"'UTC'"}}},
+				FieldNames: []string{"a", "b c"},
+			},
+		},
+		{
+			name: "enum",
+			in:   "Enum8('a, b' = 1, 'c' = 2)",
+			want: Type{Name: "Enum8", Params: []string{"'a, b' = 1", "'c' = 2"}},
+		},
+		{
+			name: "simple aggregate function",
+			in:   "SimpleAggregateFunction(sum, UInt64)",
+			want: Type{Name: "SimpleAggregateFunction", Params: []string{"sum"}, Elems: []Type{{Name: "UInt64"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, in := range []string{"", "Array(String", "Array(String, UInt8)", "Nullable()", "Map(String)", "a b", "Enum8('a = 1)"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q): expected error, got nothing", in)
+		}
+	}
+}
+
+func TestType_String(t *testing.T) {
+	for _, in := range []string{
+		"UInt8",
+		"Array(Nullable(String))",
+		"Map(String, Array(DateTime64(3, 'UTC')))",
+		"Tuple(a UInt8, b String)",
+		"SimpleAggregateFunction(sum, UInt64)",
+		"Enum8('a' = 1, 'b' = 2)",
+	} {
+		if got := MustParse(in).String(); got != in {
+			t.Errorf("String() = %v, want %v", got, in)
+		}
+	}
+}
+
+func TestType_Properties(t *testing.T) {
+	typ := MustParse("LowCardinality(Nullable(String))")
+	if !typ.IsNullable() || typ.Base().Name != "String" || !typ.Base().IsString() {
+		t.Fatal(typ)
+	}
+	if MustParse("UInt32").IntBits() != 32 || !MustParse("UInt32").IsUnsigned() || MustParse("Int256").IsUnsigned() {
+		t.Fatal("unexpected integer properties")
+	}
+	if MustParse("Interval").IsInteger() {
+		t.Fatal("Interval is not an integer")
+	}
+	if p, s, err := MustParse("Decimal64(4)").DecimalPrecisionScale(); err != nil || p != 18 || s != 4 {
+		t.Fatal(p, s, err)
+	}
+	if tz := MustParse("DateTime64(6, 'Europe/Berlin')").TimeZone(); tz != "Europe/Berlin" {
+		t.Fatal(tz)
+	}
+	values, err := MustParse(`Enum16('it\'s' = -1, 'b' = 2)`).EnumValues()
+	if err != nil || !reflect.DeepEqual(values, map[string]int{"it's": -1, "b": 2}) {
+		t.Fatal(values, err)
+	}
+}
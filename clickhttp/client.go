@@ -0,0 +1,261 @@
+// Package clickhttp executes queries built with click over the ClickHouse HTTP interface.
+// See https://clickhouse.com/docs/interfaces/http
+package clickhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/keuin/click"
+)
+
+// Client executes queries over the ClickHouse HTTP interface.
+// It is safe for concurrent use.
+type Client struct {
+	endpoint   *url.URL
+	httpClient *http.Client
+	user       string
+	password   string
+	database   string
+	settings   map[string]string
+}
+
+type Option func(c *Client)
+
+// WithHTTPClient sets the underlying HTTP client. http.DefaultClient is used by default.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+func WithCredentials(user, password string) Option {
+	return func(c *Client) {
+		c.user = user
+		c.password = password
+	}
+}
+
+// WithDatabase sets the default database of all queries.
+func WithDatabase(database string) Option {
+	return func(c *Client) {
+		c.database = database
+	}
+}
+
+// WithSettings sets ClickHouse settings applied to all queries.
+// Settings of a single query, set with QuerySetting, take precedence.
+func WithSettings(settings map[string]string) Option {
+	return func(c *Client) {
+		for k, v := range settings {
+			c.settings[k] = v
+		}
+	}
+}
+
+// New creates a client. Endpoint is the base URL of ClickHouse HTTP interface, e.g. `http://localhost:8123`.
+func New(endpoint string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported endpoint scheme: %q", u.Scheme)
+	}
+	c := &Client{
+		endpoint:   u,
+		httpClient: http.DefaultClient,
+		settings:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+type queryOptions struct {
+	queryID  string
+	format   click.Format
+	settings map[string]string
+	params   map[string]string
+}
+
+type QueryOption func(o *queryOptions)
+
+// QueryID sets query_id of the query, which can be used to find or cancel the query in system tables.
+func QueryID(id string) QueryOption {
+	return func(o *queryOptions) {
+		o.queryID = id
+	}
+}
+
+// QueryFormat sets the output format of the query, unless the query has its own FORMAT clause.
+func QueryFormat(f click.Format) QueryOption {
+	return func(o *queryOptions) {
+		o.format = f
+	}
+}
+
+// QuerySetting sets a ClickHouse setting of the query.
+func QuerySetting(name, value string) QueryOption {
+	return func(o *queryOptions) {
+		o.settings[name] = value
+	}
+}
+
+// QueryParam passes value of query parameter `{name:Type}` in the query.
+// See click.QueryParameter and https://clickhouse.com/docs/interfaces/cli#cli-queries-with-parameters
+func QueryParam(name, value string) QueryOption {
+	return func(o *queryOptions) {
+		o.params[name] = value
+	}
+}
+
+// Rows is the streamed result of a query, in the format reported by ClickHouse.
+// Caller must close it after reading.
+type Rows struct {
+	body io.ReadCloser
+	// QueryID is query_id of the query, generated by the server if not set.
+	QueryID string
+	// Format is the output format of the result.
+	Format click.Format
+}
+
+func (r *Rows) Read(p []byte) (int, error) {
+	return r.body.Read(p)
+}
+
+func (r *Rows) Close() error {
+	return r.body.Close()
+}
+
+// Query executes a SELECT query and streams its result.
+func (c *Client) Query(ctx context.Context, q click.SelectQuery, opts ...QueryOption) (*Rows, error) {
+	if q == nil {
+		return nil, errors.New("nil query")
+	}
+	return c.QueryString(ctx, q.String(), opts...)
+}
+
+// QueryString executes a raw SQL query and streams its result.
+func (c *Client) QueryString(ctx context.Context, query string, opts ...QueryOption) (*Rows, error) {
+	o := queryOptions{
+		settings: make(map[string]string),
+		params:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	resp, err := c.do(ctx, query, nil, &o)
+	if err != nil {
+		return nil, err
+	}
+	format := click.Format(resp.Header.Get("X-ClickHouse-Format"))
+	if format == "" {
+		format = o.format
+	}
+	return &Rows{
+		body:    resp.Body,
+		QueryID: resp.Header.Get("X-ClickHouse-Query-Id"),
+		Format:  format,
+	}, nil
+}
+
+// Exec executes a query and discards its result. It is typically used for DDL and INSERT queries.
+func (c *Client) Exec(ctx context.Context, query string, opts ...QueryOption) error {
+	rows, err := c.QueryString(ctx, query, opts...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	_, err = io.Copy(io.Discard, rows)
+	return err
+}
+
+// Insert executes an INSERT query whose data is read from data, in the format of the query.
+// The data is streamed as request body, e.g. rows written by rowformat.RowBinaryEncoder.
+func (c *Client) Insert(ctx context.Context, insert *click.InsertBuilder, data io.Reader, opts ...QueryOption) error {
+	if insert == nil {
+		return errors.New("nil query")
+	}
+	query, err := insert.BuildString()
+	if err != nil {
+		return err
+	}
+	o := queryOptions{
+		settings: make(map[string]string),
+		params:   make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	resp, err := c.do(ctx, query, data, &o)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// do sends the query. If data is not nil, the query is sent in URL and data is sent as request body.
+// Exceptions written at the end of the response body are returned as *Exception when reading the body.
+func (c *Client) do(ctx context.Context, query string, data io.Reader, o *queryOptions) (*http.Response, error) {
+	u := *c.endpoint
+	params := u.Query()
+	if c.database != "" {
+		params.Set("database", c.database)
+	}
+	for k, v := range c.settings {
+		params.Set(k, v)
+	}
+	for k, v := range o.settings {
+		params.Set(k, v)
+	}
+	if o.queryID != "" {
+		params.Set("query_id", o.queryID)
+	}
+	if o.format != "" {
+		params.Set("default_format", string(o.format))
+	}
+	for k, v := range o.params {
+		params.Set("param_"+k, v)
+	}
+	body := data
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		params.Set("query", query)
+	}
+	u.RawQuery = params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if data == nil {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	if c.user != "" {
+		req.Header.Set("X-ClickHouse-User", c.user)
+	}
+	if c.password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, parseException(resp)
+	}
+	resp.Body = &exceptionReader{body: resp.Body}
+	return resp, nil
+}
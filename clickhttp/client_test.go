@@ -0,0 +1,203 @@
+package clickhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/keuin/click"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, WithCredentials("user", "secret"), WithDatabase("db"), WithSettings(map[string]string{
+		"max_threads": "4",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient_Query(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		q := r.URL.Query()
+		switch {
+		case r.Method != http.MethodPost:
+			t.Errorf("method: %s", r.Method)
+		case string(body) != "SELECT count() FROM tbl WHERE (id = {id:UInt64})":
+			t.Errorf("body: %s", body)
+		case r.Header.Get("X-ClickHouse-User") != "user" || r.Header.Get("X-ClickHouse-Key") != "secret":
+			t.Errorf("credentials: %v", r.Header)
+		case q.Get("database") != "db" || q.Get("max_threads") != "8" || q.Get("readonly") != "1":
+			t.Errorf("settings: %v", q)
+		case q.Get("query_id") != "qid" || q.Get("default_format") != "JSONEachRow" || q.Get("param_id") != "42":
+			t.Errorf("query options: %v", q)
+		}
+		w.Header().Set("X-ClickHouse-Query-Id", q.Get("query_id"))
+		w.Header().Set("X-ClickHouse-Format", q.Get("default_format"))
+		_, _ = w.Write([]byte(`{"count()":"1"}` + "\n"))
+	})
+	q, err := click.Select(click.Count()).
+		From(click.Table("tbl")).
+		Where(click.Equal(click.Column("id"), click.QueryParameter("id", "UInt64"))).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := c.Query(context.Background(), q,
+		QueryID("qid"),
+		QueryFormat(click.FormatJSONEachRow),
+		QuerySetting("max_threads", "8"),
+		QuerySetting("readonly", "1"),
+		QueryParam("id", "42"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	if rows.QueryID != "qid" || rows.Format != click.FormatJSONEachRow {
+		t.Fatalf("unexpected rows metadata: %+v", rows)
+	}
+	data, err := io.ReadAll(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"count()":"1"}`+"\n" {
+		t.Fatal(string(data))
+	}
+}
+
+func TestClient_Exception(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-ClickHouse-Exception-Code", "62")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("Code: 62. DB::Exception: Syntax error: failed at position 1 ('SELEC'): SELEC 1. (SYNTAX_ERROR) (version 23.8.1.1)\n"))
+	})
+	err := c.Exec(context.Background(), "SELEC 1")
+	var e *Exception
+	if !errors.As(err, &e) {
+		t.Fatalf("expected *Exception, got %v", err)
+	}
+	if e.StatusCode != http.StatusBadRequest || e.Code != 62 || e.Name != "SYNTAX_ERROR" ||
+		e.Message != "Syntax error: failed at position 1 ('SELEC'): SELEC 1." {
+		t.Fatalf("unexpected exception: %+v", e)
+	}
+}
+
+func Test_newException(t *testing.T) {
+	tests := []struct {
+		name       string
+		codeHeader string
+		body       string
+		want       Exception
+	}{
+		{
+			name: "without error name",
+			body: "Code: 60. DB::Exception: Table db.t doesn't exist. (version 21.3.1.1)",
+			want: Exception{StatusCode: 404, Code: 60, Message: "Table db.t doesn't exist."},
+		},
+		{
+			name:       "not a ClickHouse exception",
+			codeHeader: "516",
+			body:       "Authentication failed",
+			want:       Exception{StatusCode: 404, Code: 516, Message: "Authentication failed"},
+		},
+		{
+			name: "plain error",
+			body: "Not Found\n",
+			want: Exception{StatusCode: 404, Message: "Not Found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newException(404, tt.codeHeader, tt.body); *got != tt.want {
+				t.Errorf("newException() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidEndpoint(t *testing.T) {
+	if _, err := New("tcp://localhost:9000"); err == nil {
+		t.Fatal("expected error, got nothing")
+	}
+}
+
+func TestClient_Insert(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if q := r.URL.Query().Get("query"); q != "INSERT INTO tbl (a) FORMAT RowBinary" {
+			t.Errorf("query: %s", q)
+		}
+		if string(body) != "\x01\x02" {
+			t.Errorf("body: %q", body)
+		}
+	})
+	err := c.Insert(context.Background(), click.InsertInto("tbl", "a").Format(click.FormatRowBinary), strings.NewReader("\x01\x02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_StreamException(t *testing.T) {
+	rows := strings.Repeat("1\tCode: 1. DB::Exception: not an exception\n", 4096)
+	tests := []struct {
+		name string
+		body string
+		want string
+		code int
+	}{
+		{
+			name: "no exception",
+			body: rows,
+			want: rows,
+		},
+		{
+			name: "exception",
+			body: rows + "Code: 241. DB::Exception: Memory limit (total) exceeded. (MEMORY_LIMIT_EXCEEDED) (version 23.8.1.1)\n",
+			code: 241,
+		},
+		{
+			name: "exception with tag",
+			body: rows + "\r\n__exception__\r\nxyz\r\nCode: 241. DB::Exception: Memory limit (total) exceeded. (MEMORY_LIMIT_EXCEEDED) (version 24.11.1.1)\r\n111 xyz\r\n__exception__\r\n",
+			code: 241,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, tt.body)
+			})
+			rows, err := c.QueryString(context.Background(), "SELECT 1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rows.Close()
+			data, err := io.ReadAll(rows)
+			if tt.code == 0 {
+				if err != nil || string(data) != tt.want {
+					t.Fatalf("ReadAll() = %d bytes, %v", len(data), err)
+				}
+				return
+			}
+			var e *Exception
+			if !errors.As(err, &e) {
+				t.Fatalf("expected *Exception, got %v", err)
+			}
+			if e.StatusCode != http.StatusOK || e.Code != tt.code || e.Name != "MEMORY_LIMIT_EXCEEDED" || e.Message != "Memory limit (total) exceeded." {
+				t.Fatalf("unexpected exception: %+v", e)
+			}
+			if strings.Contains(string(data), "Code: 241") {
+				t.Fatal("exception is returned as data")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package clickhttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxExceptionBodySize limits how much of an error response is read.
+const maxExceptionBodySize = 64 * 1024
+
+// Exception is an error reported by ClickHouse server.
+type Exception struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Code is ClickHouse error code, e.g. 62. It is zero if the response is not a ClickHouse exception.
+	Code int
+	// Name is ClickHouse error name, e.g. SYNTAX_ERROR. It may be empty in responses of old servers.
+	Name string
+	// Message is the error message, without error code, error name and server version.
+	Message string
+}
+
+func (e *Exception) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("clickhouse: HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	if e.Name == "" {
+		return fmt.Sprintf("clickhouse: code %d: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("clickhouse: code %d (%s): %s", e.Code, e.Name, e.Message)
+}
+
+// exceptionPattern matches exception bodies like
+// `Code: 62. DB::Exception: Syntax error: failed at position 1. (SYNTAX_ERROR) (version 23.8.1.1)`.
+var exceptionPattern = regexp.MustCompile(`(?s)^Code: (\d+)\. (?:DB::Exception: )?(.*?)(?: \(([A-Z][A-Z0-9_]*)\))?(?: \(version [^)]*\))?$`)
+
+func parseException(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExceptionBodySize))
+	if err != nil {
+		return fmt.Errorf("read error response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	return newException(resp.StatusCode, resp.Header.Get("X-ClickHouse-Exception-Code"), string(body))
+}
+
+func newException(statusCode int, codeHeader, body string) *Exception {
+	body = strings.TrimSpace(body)
+	e := &Exception{
+		StatusCode: statusCode,
+		Message:    body,
+	}
+	if m := exceptionPattern.FindStringSubmatch(body); m != nil {
+		e.Code, _ = strconv.Atoi(m[1])
+		e.Message = m[2]
+		e.Name = m[3]
+	}
+	if code, err := strconv.Atoi(codeHeader); err == nil && e.Code == 0 {
+		e.Code = code
+	}
+	return e
+}
+
+// exceptionReader detects exceptions written by ClickHouse at the end of a streaming response,
+// which has already been sent with HTTP 200, e.g. `Code: 241. DB::Exception: Memory limit exceeded`.
+// The last maxExceptionBodySize bytes of the stream are held back until EOF, and replaced with *Exception if they
+// end with an exception.
+type exceptionReader struct {
+	body io.ReadCloser
+	buf  []byte // bytes read but not returned yet
+	err  error  // error of the last body read, returned after buf
+}
+
+func (r *exceptionReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		held := maxExceptionBodySize
+		if r.err != nil {
+			held = 0
+		}
+		if n := len(r.buf) - held; n > 0 {
+			if n > len(p) {
+				n = len(p)
+			}
+			copy(p, r.buf[:n])
+			r.buf = r.buf[n:]
+			return n, nil
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+		if cap(r.buf)-len(r.buf) < 4096 {
+			buf := make([]byte, len(r.buf), 2*maxExceptionBodySize)
+			copy(buf, r.buf)
+			r.buf = buf
+		}
+		n, err := r.body.Read(r.buf[len(r.buf):cap(r.buf)])
+		r.buf = r.buf[:len(r.buf)+n]
+		if err == io.EOF {
+			if e := streamException(r.buf); e != nil {
+				r.buf, err = nil, e
+			}
+		}
+		r.err = err
+	}
+}
+
+// streamException parses the exception at the end of a streaming response, in forms of
+// `...\nCode: 241. DB::Exception: ...` or, since ClickHouse 24.11,
+// `...\r\n__exception__\r\n<tag>\r\nCode: 241. DB::Exception: ...\r\n<size> <tag>\r\n__exception__\r\n`.
+// It returns nil if the stream does not end with an exception.
+func streamException(tail []byte) *Exception {
+	s := strings.TrimRight(string(tail), "\r\n")
+	if strings.HasSuffix(s, "__exception__") {
+		s = strings.TrimRight(strings.TrimSuffix(s, "__exception__"), "\r\n")
+		// drop the line of message size and tag
+		i := strings.LastIndexByte(s, '\n')
+		if i < 0 {
+			return nil
+		}
+		s = s[:i]
+	}
+	// the exception starts at a line, and is the last line of the stream
+	i := strings.LastIndex(s, "Code: ")
+	for i > 0 && s[i-1] != '\n' {
+		i = strings.LastIndex(s[:i], "Code: ")
+	}
+	if i < 0 || !strings.Contains(s[i:], "DB::Exception") {
+		return nil
+	}
+	e := newException(http.StatusOK, "", s[i:])
+	if e.Code == 0 {
+		return nil
+	}
+	return e
+}
+
+func (r *exceptionReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,102 @@
+// Package clicksql runs queries built with click through database/sql drivers, like clickhouse-go.
+package clicksql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/internal/fields"
+)
+
+// Queryer is implemented by *sql.DB, *sql.Tx and *sql.Conn.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Build renders the query with `?` placeholders, which are supported by clickhouse-go.
+// See click.BuildArgs for values passed as arguments.
+func Build(q click.SelectQuery) (string, []any, error) {
+	return click.BuildArgs(q, click.PlaceholderQuestion)
+}
+
+// Query executes the query with values passed as arguments, using `?` placeholders.
+func Query(ctx context.Context, db Queryer, q click.SelectQuery) (*sql.Rows, error) {
+	query, args, err := Build(q)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// Select executes the query and scans all rows into dst, which is a pointer to slice of structs or struct pointers.
+// See ScanStruct for how columns are mapped to fields.
+func Select(ctx context.Context, db Queryer, dst any, q click.SelectQuery) error {
+	rows, err := Query(ctx, db, q)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return ScanAll(rows, dst)
+}
+
+// ScanAll scans all remaining rows into dst, which is a pointer to slice of structs or struct pointers.
+// Scanned rows are appended to the slice. It does not close rows.
+func ScanAll(rows *sql.Rows, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("unsupported scan destination %T, expected pointer to slice", dst)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Pointer
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported scan destination %T, expected slice of structs", dst)
+	}
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		if err := ScanStruct(rows, elem.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return rows.Err()
+}
+
+// ScanStruct scans the current row into dst, which is a pointer to struct.
+// Columns are mapped to fields by `ch` tag, or field name if the tag is absent, case-insensitively.
+// Fields of embedded structs are included. Columns without corresponding fields are discarded.
+// Tag `ch:"-"` skips the field.
+func ScanStruct(rows *sql.Rows, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported scan destination %T, expected pointer to struct", dst)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	fieldMap := fields.Of(rv.Elem().Type())
+	targets := make([]any, len(columns))
+	for i, c := range columns {
+		index, ok := fieldMap.LookupFold(c)
+		if !ok {
+			targets[i] = new(any)
+			continue
+		}
+		targets[i] = fields.ByIndex(rv.Elem(), index).Addr().Interface()
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return fmt.Errorf("scan %s: %w", rv.Elem().Type(), err)
+	}
+	return nil
+}
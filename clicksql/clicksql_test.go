@@ -0,0 +1,186 @@
+package clicksql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/keuin/click"
+)
+
+// fakeDriver records executed queries, and returns predefined results.
+type fakeDriver struct {
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.Value
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{d: c.d, query: query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.queries = append(s.d.queries, s.query)
+	s.d.args = append(s.d.args, args)
+	return &fakeRows{columns: s.d.columns, rows: s.d.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var (
+	fake = &fakeDriver{}
+	once sync.Once
+)
+
+func openFake(t *testing.T, columns []string, rows [][]driver.Value) *sql.DB {
+	t.Helper()
+	once.Do(func() {
+		sql.Register("clicksql-fake", fake)
+	})
+	fake.mu.Lock()
+	fake.queries, fake.args = nil, nil
+	fake.columns, fake.rows = columns, rows
+	fake.mu.Unlock()
+	db, err := sql.Open("clicksql-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+type Base struct {
+	ID int64 `ch:"id"`
+}
+
+type user struct {
+	Base
+	Name    string
+	Score   float64 `ch:"avg_score"`
+	Ignored string  `ch:"-"`
+}
+
+func TestSelect(t *testing.T) {
+	db := openFake(t, []string{"id", "name", "avg_score", "unknown"}, [][]driver.Value{
+		{int64(1), "alice", 90.5, "x"},
+		{int64(2), "bob", 60.0, "y"},
+	})
+	q, err := click.Select(click.Column("id"), click.Column("name"), click.As(click.Avg(click.Column("score")), click.Alias("avg_score"))).
+		From(click.Table("scores")).
+		Where(click.Equal(click.Column("class"), click.Arg("A"))).
+		GroupBy(click.Column("id"), click.Column("name")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var users []user
+	if err := Select(context.Background(), db, &users, q); err != nil {
+		t.Fatal(err)
+	}
+	want := []user{
+		{Base: Base{ID: 1}, Name: "alice", Score: 90.5},
+		{Base: Base{ID: 2}, Name: "bob", Score: 60},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Fatalf("got %+v, want %+v", users, want)
+	}
+	if len(fake.queries) != 1 || fake.queries[0] != "SELECT id, name, avg(score) AS avg_score FROM scores WHERE (class = ?) GROUP BY id, name" {
+		t.Fatal(fake.queries)
+	}
+	if !reflect.DeepEqual(fake.args[0], []driver.Value{"A"}) {
+		t.Fatal(fake.args)
+	}
+}
+
+func TestScanAll_Pointers(t *testing.T) {
+	db := openFake(t, []string{"id"}, [][]driver.Value{{int64(1)}})
+	rows, err := db.QueryContext(context.Background(), "SELECT id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var users []*user
+	if err := ScanAll(rows, &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != 1 {
+		t.Fatalf("%+v", users)
+	}
+}
+
+func TestScanAll_InvalidDestination(t *testing.T) {
+	db := openFake(t, []string{"id"}, nil)
+	rows, err := db.QueryContext(context.Background(), "SELECT id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var users []user
+	for _, dst := range []any{users, &[]int{}, nil} {
+		if err := ScanAll(rows, dst); err == nil {
+			t.Errorf("ScanAll(%T): expected error, got nothing", dst)
+		}
+	}
+}
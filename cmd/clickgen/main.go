@@ -0,0 +1,69 @@
+// Command clickgen generates Go code with typed Table and Column constants and row structs,
+// from a dump of system.columns or DESCRIBE TABLE, so that it works offline.
+//
+// Usage:
+//
+//	clickhouse-client -q "SELECT database, table, name, type, comment FROM system.columns
+//	    WHERE database = 'db' FORMAT JSONEachRow" > columns.json
+//	clickgen -in columns.json -package models -out models/tables.go
+//
+// For a DESCRIBE TABLE dump, set the table name with -table.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/schema"
+)
+
+func main() {
+	in := flag.String("in", "-", "dump of system.columns or DESCRIBE TABLE, in JSONEachRow, JSON or TabSeparatedWithNames format, `-` for stdin")
+	out := flag.String("out", "-", "output Go file, `-` for stdout")
+	pkg := flag.String("package", "", "package name of generated code (required)")
+	table := flag.String("table", "", "table name of a DESCRIBE TABLE dump, like `db.events`")
+	flag.Parse()
+	if *pkg == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*in, *out, *pkg, click.Table(*table)); err != nil {
+		fmt.Fprintln(os.Stderr, "clickgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string, table click.Table) error {
+	var r io.Reader = os.Stdin
+	if in != "-" {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var s *schema.Schema
+	var err error
+	if table != "" {
+		s, err = schema.LoadDescribe(table, r)
+	} else {
+		s, err = schema.Load(r)
+	}
+	if err != nil {
+		return fmt.Errorf("load %s: %w", in, err)
+	}
+	var buf bytes.Buffer
+	if err := schema.Generate(&buf, s, schema.GenerateOptions{Package: pkg}); err != nil {
+		return err
+	}
+	if out == "-" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(out, buf.Bytes(), 0o644)
+}
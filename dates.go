@@ -0,0 +1,166 @@
+package click
+
+import (
+	"strings"
+	"time"
+)
+
+// IntervalUnit is the unit of INTERVAL.
+// See https://clickhouse.com/docs/sql-reference/data-types/special-data-types/interval
+type IntervalUnit string
+
+const (
+	UnitNanosecond  IntervalUnit = "NANOSECOND"
+	UnitMicrosecond IntervalUnit = "MICROSECOND"
+	UnitMillisecond IntervalUnit = "MILLISECOND"
+	UnitSecond      IntervalUnit = "SECOND"
+	UnitMinute      IntervalUnit = "MINUTE"
+	UnitHour        IntervalUnit = "HOUR"
+	UnitDay         IntervalUnit = "DAY"
+	UnitWeek        IntervalUnit = "WEEK"
+	UnitMonth       IntervalUnit = "MONTH"
+	UnitQuarter     IntervalUnit = "QUARTER"
+	UnitYear        IntervalUnit = "YEAR"
+)
+
+// intervalExpr is `INTERVAL value unit`.
+type intervalExpr struct {
+	value Expression
+	unit  IntervalUnit
+}
+
+func (i intervalExpr) Expression() string {
+	return "INTERVAL " + i.value.Expression() + " " + string(i.unit)
+}
+
+// Interval creates `INTERVAL n unit`, e.g. Interval(7, UnitDay) is `INTERVAL 7 DAY`.
+func Interval(n int64, unit IntervalUnit) Expression {
+	return IntervalOf(LiteralExpression(n), unit)
+}
+
+// IntervalOf creates an interval whose length is an expression, like a query parameter.
+func IntervalOf(v Expression, unit IntervalUnit) Expression {
+	if v == nil {
+		panic("empty interval value")
+	}
+	if unit == "" {
+		panic("empty interval unit")
+	}
+	return intervalExpr{value: v, unit: unit}
+}
+
+// DurationInterval converts a duration to an interval of the largest exact unit, from NANOSECOND to HOUR.
+// Days are never used, since INTERVAL 1 DAY is a calendar day, which is not always 24 hours.
+func DurationInterval(d time.Duration) Expression {
+	for _, u := range []struct {
+		d    time.Duration
+		unit IntervalUnit
+	}{
+		{time.Hour, UnitHour},
+		{time.Minute, UnitMinute},
+		{time.Second, UnitSecond},
+		{time.Millisecond, UnitMillisecond},
+		{time.Microsecond, UnitMicrosecond},
+	} {
+		if d%u.d == 0 {
+			return Interval(int64(d/u.d), u.unit)
+		}
+	}
+	return Interval(int64(d), UnitNanosecond)
+}
+
+// InspectInterval returns the value and the unit of an interval.
+func InspectInterval(e Expression) (v Expression, unit IntervalUnit, ok bool) {
+	i, ok := e.(intervalExpr)
+	if !ok {
+		return nil, "", false
+	}
+	return i.value, i.unit, true
+}
+
+// Plus is the arithmetic operator `(l + r)`, also adding intervals to dates.
+func Plus(l, r Expression) Expression {
+	return BinaryExpression{
+		Operator:     "+",
+		LeftOperand:  l,
+		RightOperand: r,
+	}
+}
+
+// Minus is the arithmetic operator `(l - r)`, also subtracting intervals from dates.
+func Minus(l, r Expression) Expression {
+	return BinaryExpression{
+		Operator:     "-",
+		LeftOperand:  l,
+		RightOperand: r,
+	}
+}
+
+// Ago is `(now() - interval)`, e.g. Ago(Interval(7, UnitDay)).
+func Ago(interval Expression) Expression {
+	return Minus(Now(), interval)
+}
+
+// DateTime converts a timestamp to a DateTime literal in UTC, like `toDateTime('2006-01-02 15:04:05', 'UTC')`.
+// Fractional seconds are truncated, use DateTime64 to keep them.
+func DateTime(t time.Time) Expression {
+	return Fn("toDateTime", quoted(t.UTC().Format("2006-01-02 15:04:05")), quoted("UTC"))
+}
+
+// DateTime64 converts a timestamp to a DateTime64 literal in UTC with nanosecond precision,
+// like `toDateTime64('2006-01-02 15:04:05.000000000', 9, 'UTC')`.
+func DateTime64(t time.Time) Expression {
+	return Fn("toDateTime64", quoted(t.UTC().Format("2006-01-02 15:04:05.000000000")), LiteralExpression(9), quoted("UTC"))
+}
+
+// date and time functions, see https://clickhouse.com/docs/sql-reference/functions/date-time-functions
+
+func Now() Expression       { return Fn("now") }
+func Today() Expression     { return Fn("today") }
+func Yesterday() Expression { return Fn("yesterday") }
+
+// NowIn is now() in the time zone.
+func NowIn(timezone string) Expression { return Fn("now", quoted(timezone)) }
+
+// Now64 is the current time as DateTime64 with sub-second precision, from 0 to 9.
+func Now64(precision int) Expression { return Fn("now64", LiteralExpression(precision)) }
+
+func ToStartOfMinute(t Expression) Expression  { return Fn("toStartOfMinute", t) }
+func ToStartOfHour(t Expression) Expression    { return Fn("toStartOfHour", t) }
+func ToStartOfDay(t Expression) Expression     { return Fn("toStartOfDay", t) }
+func ToMonday(t Expression) Expression         { return Fn("toMonday", t) }
+func ToStartOfMonth(t Expression) Expression   { return Fn("toStartOfMonth", t) }
+func ToStartOfQuarter(t Expression) Expression { return Fn("toStartOfQuarter", t) }
+func ToStartOfYear(t Expression) Expression    { return Fn("toStartOfYear", t) }
+func ToDate(t Expression) Expression           { return Fn("toDate", t) }
+
+// ToStartOfWeek rounds down to the nearest Sunday or Monday, depending on mode.
+// See https://clickhouse.com/docs/sql-reference/functions/date-time-functions#toweek
+func ToStartOfWeek(t Expression, mode int) Expression {
+	return Fn("toStartOfWeek", t, LiteralExpression(mode))
+}
+
+// ToStartOfInterval rounds down to the beginning of the interval, e.g. ToStartOfInterval(ts, Interval(15, UnitMinute)).
+func ToStartOfInterval(t Expression, interval Expression) Expression {
+	return Fn("toStartOfInterval", t, interval)
+}
+
+// DateDiff is the count of unit boundaries crossed between start and end, e.g. `dateDiff('day', start, end)`.
+func DateDiff(unit IntervalUnit, start, end Expression) Expression {
+	return Fn("dateDiff", quoted(unitName(unit)), start, end)
+}
+
+// DateTrunc truncates the time to the unit, e.g. `dateTrunc('month', t)`.
+func DateTrunc(unit IntervalUnit, t Expression) Expression {
+	return Fn("dateTrunc", quoted(unitName(unit)), t)
+}
+
+// ToTimeZone converts the time zone of DateTime or DateTime64, keeping the timestamp.
+func ToTimeZone(t Expression, timezone string) Expression {
+	return Fn("toTimeZone", t, quoted(timezone))
+}
+
+// unitName is the unit name in lower case, as required by dateDiff and dateTrunc.
+func unitName(unit IntervalUnit) string {
+	return strings.ToLower(string(unit))
+}
@@ -0,0 +1,96 @@
+package click
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateExpressions(t *testing.T) {
+	ts := Column("ts")
+	tests := []struct {
+		name string
+		expr Expression
+		want string
+	}{
+		{
+			name: "time window",
+			expr: GreaterOrEqualThan(ts, Minus(Now(), Interval(7, UnitDay))),
+			want: "(ts >= (now() - INTERVAL 7 DAY))",
+		},
+		{
+			name: "ago",
+			expr: Ago(Interval(1, UnitQuarter)),
+			want: "(now() - INTERVAL 1 QUARTER)",
+		},
+		{
+			name: "plus",
+			expr: Plus(Today(), Interval(-1, UnitWeek)),
+			want: "(today() + INTERVAL -1 WEEK)",
+		},
+		{
+			name: "interval of parameter",
+			expr: IntervalOf(QueryParameter("days", "UInt32"), UnitDay),
+			want: "INTERVAL {days:UInt32} DAY",
+		},
+		{
+			name: "toStartOfInterval",
+			expr: ToStartOfInterval(ts, DurationInterval(15*time.Minute)),
+			want: "toStartOfInterval(ts, INTERVAL 15 MINUTE)",
+		},
+		{
+			name: "toStartOfWeek",
+			expr: ToStartOfWeek(ts, 1),
+			want: "toStartOfWeek(ts, 1)",
+		},
+		{
+			name: "dateDiff",
+			expr: DateDiff(UnitDay, Column("start"), Column("end")),
+			want: "dateDiff('day', start, end)",
+		},
+		{
+			name: "dateTrunc",
+			expr: DateTrunc(UnitMonth, ts),
+			want: "dateTrunc('month', ts)",
+		},
+		{
+			name: "toTimeZone",
+			expr: ToTimeZone(ts, "Asia/Shanghai"),
+			want: "toTimeZone(ts, 'Asia/Shanghai')",
+		},
+		{
+			name: "datetime literal",
+			expr: DateTime(time.Date(2024, 1, 2, 11, 4, 5, 6, time.FixedZone("", 8*3600))),
+			want: "toDateTime('2024-01-02 03:04:05', 'UTC')",
+		},
+		{
+			name: "datetime64 literal",
+			expr: DateTime64(time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)),
+			want: "toDateTime64('2024-01-02 03:04:05.000000006', 9, 'UTC')",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expr.Expression(); got != tt.want {
+				t.Errorf("Expression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationInterval(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "INTERVAL 0 HOUR"},
+		{48 * time.Hour, "INTERVAL 48 HOUR"},
+		{90 * time.Second, "INTERVAL 90 SECOND"},
+		{-1500 * time.Millisecond, "INTERVAL -1500 MILLISECOND"},
+		{time.Nanosecond, "INTERVAL 1 NANOSECOND"},
+	}
+	for _, tt := range tests {
+		if got := DurationInterval(tt.d).Expression(); got != tt.want {
+			t.Errorf("DurationInterval(%s) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package click
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/keuin/click/internal/ident"
+)
+
+// DictionaryAttribute is an attribute declaration of a dictionary, like `name Type DEFAULT value`.
+// Key columns are attributes as well, see CreateDictionaryBuilder.PrimaryKey.
+// See https://clickhouse.com/docs/sql-reference/dictionaries#dictionary-key-and-fields
+type DictionaryAttribute struct {
+	Name Column
+	Type string
+	// Default is the value of keys absent in the source, the default value of Type if nil.
+	Default Expression
+	// Expression computes the attribute in the source, e.g. a column with a different name.
+	Expression   Expression
+	Hierarchical bool
+	Injective    bool
+}
+
+func (a DictionaryAttribute) definition() (string, error) {
+	if a.Name == "" {
+		return "", errors.New("empty attribute name")
+	}
+	if a.Type == "" {
+		return "", fmt.Errorf("attribute %s: empty type", a.Name)
+	}
+	var sb strings.Builder
+	sb.WriteString(string(a.Name))
+	sb.WriteByte(' ')
+	sb.WriteString(a.Type)
+	if a.Default != nil {
+		sb.WriteString(" DEFAULT ")
+		sb.WriteString(a.Default.Expression())
+	}
+	if a.Expression != nil {
+		sb.WriteString(" EXPRESSION ")
+		sb.WriteString(a.Expression.Expression())
+	}
+	if a.Hierarchical {
+		sb.WriteString(" HIERARCHICAL")
+	}
+	if a.Injective {
+		sb.WriteString(" INJECTIVE")
+	}
+	return sb.String(), nil
+}
+
+// DictionaryParam is a `NAME value` parameter in SOURCE and LAYOUT clauses.
+// Values must be strings, numbers or booleans, as in SETTINGS clause.
+type DictionaryParam struct {
+	Name  string
+	Value any
+}
+
+// DictParam is a shortcut of DictionaryParam, e.g. `DictParam("PORT", 9000)`.
+func DictParam(name string, value any) DictionaryParam {
+	return DictionaryParam{Name: name, Value: value}
+}
+
+// dictionaryFunc renders `NAME(P1 v1 P2 v2)`, the form of sources and layouts.
+type dictionaryFunc struct {
+	name   string
+	params []DictionaryParam
+}
+
+func (f dictionaryFunc) render() (string, error) {
+	if f.name == "" {
+		return "", errors.New("empty name")
+	}
+	var sb strings.Builder
+	sb.WriteString(f.name)
+	sb.WriteByte('(')
+	for i, p := range f.params {
+		if p.Name == "" {
+			return "", fmt.Errorf("%s: empty parameter name", f.name)
+		}
+		v, err := settingValue(p.Value)
+		if err != nil {
+			return "", fmt.Errorf("%s: parameter %s: %w", f.name, p.Name, err)
+		}
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(p.Name)
+		sb.WriteByte(' ')
+		sb.WriteString(v)
+	}
+	sb.WriteByte(')')
+	return sb.String(), nil
+}
+
+// DictionarySource is the SOURCE clause of a dictionary.
+// See https://clickhouse.com/docs/sql-reference/dictionaries#dictionary-sources
+type DictionarySource struct {
+	dictionaryFunc
+}
+
+// CustomSource creates a source of any type, e.g. `CustomSource("REDIS", DictParam("HOST", "localhost"), DictParam("PORT", 6379))`.
+func CustomSource(typ string, params ...DictionaryParam) DictionarySource {
+	return DictionarySource{dictionaryFunc{name: typ, params: append([]DictionaryParam(nil), params...)}}
+}
+
+// SourceClickHouse loads the dictionary from a ClickHouse table, on the local server unless HOST is set in params.
+func SourceClickHouse(table Table, params ...DictionaryParam) DictionarySource {
+	db, name := ident.SplitTable(string(table))
+	var ps []DictionaryParam
+	if db != "" {
+		ps = append(ps, DictParam("DB", db))
+	}
+	ps = append(ps, DictParam("TABLE", name))
+	return CustomSource("CLICKHOUSE", append(ps, params...)...)
+}
+
+// SourceMySQL loads the dictionary from a MySQL table, params usually include HOST, PORT, USER, PASSWORD and DB.
+func SourceMySQL(table string, params ...DictionaryParam) DictionarySource {
+	return CustomSource("MYSQL", append([]DictionaryParam{DictParam("TABLE", table)}, params...)...)
+}
+
+// SourcePostgreSQL loads the dictionary from a PostgreSQL table, params usually include HOST, PORT, USER, PASSWORD and DB.
+func SourcePostgreSQL(table string, params ...DictionaryParam) DictionarySource {
+	return CustomSource("POSTGRESQL", append([]DictionaryParam{DictParam("TABLE", table)}, params...)...)
+}
+
+// SourceHTTP loads the dictionary from a URL, in the format like CSVWithNames.
+func SourceHTTP(url string, format Format, params ...DictionaryParam) DictionarySource {
+	return CustomSource("HTTP", append([]DictionaryParam{DictParam("URL", url), DictParam("FORMAT", string(format))}, params...)...)
+}
+
+// DictionaryLayout is the LAYOUT clause of a dictionary, which decides how it is stored in memory.
+// See https://clickhouse.com/docs/sql-reference/dictionaries#ways-to-store-dictionaries-in-memory
+type DictionaryLayout struct {
+	dictionaryFunc
+}
+
+// CustomLayout creates a layout of any type, e.g. `CustomLayout("HASHED", DictParam("SHARDS", 16))`.
+func CustomLayout(typ string, params ...DictionaryParam) DictionaryLayout {
+	return DictionaryLayout{dictionaryFunc{name: typ, params: append([]DictionaryParam(nil), params...)}}
+}
+
+func LayoutFlat() DictionaryLayout             { return CustomLayout("FLAT") }
+func LayoutHashed() DictionaryLayout           { return CustomLayout("HASHED") }
+func LayoutSparseHashed() DictionaryLayout     { return CustomLayout("SPARSE_HASHED") }
+func LayoutHashedArray() DictionaryLayout      { return CustomLayout("HASHED_ARRAY") }
+func LayoutComplexKeyHashed() DictionaryLayout { return CustomLayout("COMPLEX_KEY_HASHED") }
+func LayoutDirect() DictionaryLayout           { return CustomLayout("DIRECT") }
+func LayoutIPTrie() DictionaryLayout           { return CustomLayout("IP_TRIE") }
+
+// LayoutRangeHashed stores values of keys in ranges, which requires CreateDictionaryBuilder.Range.
+func LayoutRangeHashed() DictionaryLayout { return CustomLayout("RANGE_HASHED") }
+
+// LayoutComplexKeyRangeHashed is LayoutRangeHashed with composite keys.
+func LayoutComplexKeyRangeHashed() DictionaryLayout { return CustomLayout("COMPLEX_KEY_RANGE_HASHED") }
+
+// LayoutCache stores at most sizeInCells recently used keys, loading absent keys from the source on demand.
+func LayoutCache(sizeInCells int) DictionaryLayout {
+	return CustomLayout("CACHE", DictParam("SIZE_IN_CELLS", sizeInCells))
+}
+
+func (l DictionaryLayout) isRange() bool {
+	return strings.HasSuffix(strings.ToUpper(l.name), "RANGE_HASHED")
+}
+
+// CreateDictionary creates a CREATE DICTIONARY query.
+// PRIMARY KEY, SOURCE, LAYOUT and LIFETIME clauses are required.
+// See https://clickhouse.com/docs/sql-reference/statements/create/dictionary
+func CreateDictionary(name Table) *CreateDictionaryBuilder {
+	return &CreateDictionaryBuilder{
+		name: name,
+	}
+}
+
+// CreateDictionaryBuilder implements builder pattern for constructing CREATE DICTIONARY SQLs.
+type CreateDictionaryBuilder struct {
+	name        Table
+	cluster     string
+	ifNotExists bool
+	orReplace   bool
+	attributes  []DictionaryAttribute
+	primaryKey  []Column
+	source      *DictionarySource
+	layout      *DictionaryLayout
+	lifetime    [2]int
+	hasLifetime bool
+	rangeMin    Column
+	rangeMax    Column
+	comment     string
+	style       RenderStyle
+	styleSet    bool
+}
+
+// clone returns a copy of the builder, which can be modified without affecting the original one.
+func (b *CreateDictionaryBuilder) clone() *CreateDictionaryBuilder {
+	c := *b
+	c.attributes = append([]DictionaryAttribute(nil), b.attributes...)
+	c.primaryKey = append([]Column(nil), b.primaryKey...)
+	return &c
+}
+
+func (b *CreateDictionaryBuilder) OnCluster(cluster string) *CreateDictionaryBuilder {
+	b.cluster = cluster
+	return b
+}
+
+func (b *CreateDictionaryBuilder) IfNotExists() *CreateDictionaryBuilder {
+	b.ifNotExists = true
+	return b
+}
+
+func (b *CreateDictionaryBuilder) OrReplace() *CreateDictionaryBuilder {
+	b.orReplace = true
+	return b
+}
+
+// Attribute adds attributes, including key columns, in order.
+func (b *CreateDictionaryBuilder) Attribute(attributes ...DictionaryAttribute) *CreateDictionaryBuilder {
+	b.attributes = append(append([]DictionaryAttribute(nil), b.attributes...), attributes...)
+	return b
+}
+
+// PrimaryKey sets key columns. Layouts with a single key, like FLAT and HASHED, require exactly one UInt64 key,
+// while COMPLEX_KEY_* layouts accept composite keys.
+func (b *CreateDictionaryBuilder) PrimaryKey(columns ...Column) *CreateDictionaryBuilder {
+	b.primaryKey = append([]Column(nil), columns...)
+	return b
+}
+
+func (b *CreateDictionaryBuilder) Source(source DictionarySource) *CreateDictionaryBuilder {
+	b.source = &source
+	return b
+}
+
+func (b *CreateDictionaryBuilder) Layout(layout DictionaryLayout) *CreateDictionaryBuilder {
+	b.layout = &layout
+	return b
+}
+
+// Lifetime sets the reload interval in seconds, randomly chosen between minSeconds and maxSeconds to spread the load.
+// Lifetime(0, 0) disables reloading.
+func (b *CreateDictionaryBuilder) Lifetime(minSeconds, maxSeconds int) *CreateDictionaryBuilder {
+	b.lifetime = [2]int{minSeconds, maxSeconds}
+	b.hasLifetime = true
+	return b
+}
+
+// Range sets columns of range bounds, required by RANGE_HASHED layouts.
+func (b *CreateDictionaryBuilder) Range(start, end Column) *CreateDictionaryBuilder {
+	b.rangeMin = start
+	b.rangeMax = end
+	return b
+}
+
+func (b *CreateDictionaryBuilder) Comment(comment string) *CreateDictionaryBuilder {
+	b.comment = comment
+	return b
+}
+
+// PrettyPrint renders every attribute and clause in its own line.
+func (b *CreateDictionaryBuilder) PrettyPrint(v ...bool) *CreateDictionaryBuilder {
+	if len(v) == 0 || v[0] {
+		b.style = prettyStyle
+	} else {
+		b.style = defaultStyle
+	}
+	b.styleSet = true
+	return b
+}
+
+func (b *CreateDictionaryBuilder) BuildString() (string, error) {
+	style := defaultStyle
+	if b.styleSet {
+		style = b.style
+	}
+	if err := b.validate(); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if b.orReplace {
+		sb.WriteString("OR REPLACE ")
+	}
+	sb.WriteString("DICTIONARY ")
+	if b.ifNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(string(b.name))
+	if b.cluster != "" {
+		sb.WriteString(" ON CLUSTER ")
+		sb.WriteString(b.cluster)
+	}
+	sb.WriteString(" (")
+	for i, a := range b.attributes {
+		s, err := a.definition()
+		if err != nil {
+			return "", err
+		}
+		if i > 0 {
+			sb.WriteByte(',')
+			if style.Indent == "" {
+				sb.WriteByte(' ')
+			}
+		}
+		if style.Indent != "" {
+			sb.WriteByte('\n')
+			sb.WriteString(style.Indent)
+		}
+		sb.WriteString(s)
+	}
+	if style.Indent != "" {
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte(')')
+	clause := func(s string) {
+		if style.Indent == "" {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(s)
+	}
+	keys := make([]string, len(b.primaryKey))
+	for i, c := range b.primaryKey {
+		keys[i] = string(c)
+	}
+	clause("PRIMARY KEY " + strings.Join(keys, ", "))
+	source, err := b.source.render()
+	if err != nil {
+		return "", fmt.Errorf("SOURCE: %w", err)
+	}
+	clause("SOURCE(" + source + ")")
+	layout, err := b.layout.render()
+	if err != nil {
+		return "", fmt.Errorf("LAYOUT: %w", err)
+	}
+	clause("LAYOUT(" + layout + ")")
+	if b.lifetime[0] == b.lifetime[1] {
+		clause("LIFETIME(" + strconv.Itoa(b.lifetime[0]) + ")")
+	} else {
+		clause("LIFETIME(MIN " + strconv.Itoa(b.lifetime[0]) + " MAX " + strconv.Itoa(b.lifetime[1]) + ")")
+	}
+	if b.rangeMin != "" {
+		clause("RANGE(MIN " + string(b.rangeMin) + " MAX " + string(b.rangeMax) + ")")
+	}
+	if b.comment != "" {
+		clause("COMMENT " + quoted(b.comment).Expression())
+	}
+	return sb.String(), nil
+}
+
+func (b *CreateDictionaryBuilder) validate() error {
+	if b.name == "" {
+		return errors.New("no dictionary name")
+	}
+	if b.orReplace && b.ifNotExists {
+		return errors.New("OR REPLACE and IF NOT EXISTS are exclusive")
+	}
+	if len(b.attributes) == 0 {
+		return errors.New("no attributes")
+	}
+	if len(b.primaryKey) == 0 {
+		return errors.New("no PRIMARY KEY")
+	}
+	declared := make(map[Column]bool, len(b.attributes))
+	for _, a := range b.attributes {
+		declared[a.Name] = true
+	}
+	for _, c := range append(append([]Column(nil), b.primaryKey...), b.rangeMin, b.rangeMax) {
+		if c != "" && !declared[c] {
+			return fmt.Errorf("column %s is not declared as an attribute", c)
+		}
+	}
+	if b.source == nil {
+		return errors.New("no SOURCE")
+	}
+	if b.layout == nil {
+		return errors.New("no LAYOUT")
+	}
+	if !b.hasLifetime {
+		return errors.New("no LIFETIME")
+	}
+	if b.lifetime[0] < 0 || b.lifetime[0] > b.lifetime[1] {
+		return fmt.Errorf("invalid LIFETIME: MIN %d MAX %d", b.lifetime[0], b.lifetime[1])
+	}
+	if (b.rangeMin == "") != (b.rangeMax == "") {
+		return errors.New("RANGE requires both MIN and MAX")
+	}
+	if b.layout.isRange() != (b.rangeMin != "") {
+		return fmt.Errorf("RANGE is required by and only supported by RANGE_HASHED layouts, got %s", b.layout.name)
+	}
+	return nil
+}
+
+// dictionary functions, see https://clickhouse.com/docs/sql-reference/functions/ext-dict-functions
+// Composite keys are passed as Tuple.
+
+// DictGet returns the attribute of the key, or the default value of the attribute if the key is absent,
+// e.g. `dictGet('users', 'name', user_id)`.
+func DictGet(dict Table, attribute Column, key Expression) Expression {
+	return Fn("dictGet", quoted(string(dict)), quoted(string(attribute)), key)
+}
+
+// DictGetOrDefault returns the attribute of the key, or def if the key is absent.
+func DictGetOrDefault(dict Table, attribute Column, key, def Expression) Expression {
+	return Fn("dictGetOrDefault", quoted(string(dict)), quoted(string(attribute)), key, def)
+}
+
+// DictGetOrNull returns the attribute of the key, or NULL if the key is absent.
+func DictGetOrNull(dict Table, attribute Column, key Expression) Expression {
+	return Fn("dictGetOrNull", quoted(string(dict)), quoted(string(attribute)), key)
+}
+
+// DictHas checks whether the key is present in the dictionary.
+func DictHas(dict Table, key Expression) Expression {
+	return Fn("dictHas", quoted(string(dict)), key)
+}
@@ -0,0 +1,136 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestCreateDictionary(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *CreateDictionaryBuilder
+		want string
+	}{
+		{
+			name: "hashed",
+			b: CreateDictionary("db.users_dict").IfNotExists().OnCluster("main").
+				Attribute(
+					DictionaryAttribute{Name: "id", Type: "UInt64"},
+					DictionaryAttribute{Name: "name", Type: "String", Default: LiteralExpressionQuoted("unknown")},
+					DictionaryAttribute{Name: "parent_id", Type: "UInt64", Hierarchical: true},
+				).
+				PrimaryKey("id").
+				Source(SourceClickHouse("db.users", DictParam("USER", "default"))).
+				Layout(LayoutHashed()).
+				Lifetime(300, 360).
+				Comment("user names"),
+			want: "CREATE DICTIONARY IF NOT EXISTS db.users_dict ON CLUSTER main " +
+				"(id UInt64, name String DEFAULT 'unknown', parent_id UInt64 HIERARCHICAL) PRIMARY KEY id " +
+				"SOURCE(CLICKHOUSE(DB 'db' TABLE 'users' USER 'default')) LAYOUT(HASHED()) LIFETIME(MIN 300 MAX 360) COMMENT 'user names'",
+		},
+		{
+			name: "complex key cache",
+			b: CreateDictionary("d").OrReplace().
+				Attribute(
+					DictionaryAttribute{Name: "a", Type: "String"},
+					DictionaryAttribute{Name: "b", Type: "UInt32"},
+					DictionaryAttribute{Name: "v", Type: "Float64", Expression: Fn("toFloat64", Column("raw"))},
+				).
+				PrimaryKey("a", "b").
+				Source(SourceMySQL("t", DictParam("HOST", "mysql"), DictParam("PORT", 3306))).
+				Layout(CustomLayout("COMPLEX_KEY_CACHE", DictParam("SIZE_IN_CELLS", 1000))).
+				Lifetime(0, 0),
+			want: "CREATE OR REPLACE DICTIONARY d (a String, b UInt32, v Float64 EXPRESSION toFloat64(raw)) PRIMARY KEY a, b " +
+				"SOURCE(MYSQL(TABLE 't' HOST 'mysql' PORT 3306)) LAYOUT(COMPLEX_KEY_CACHE(SIZE_IN_CELLS 1000)) LIFETIME(0)",
+		},
+		{
+			name: "range hashed",
+			b: CreateDictionary("prices").
+				Attribute(
+					DictionaryAttribute{Name: "id", Type: "UInt64"},
+					DictionaryAttribute{Name: "start", Type: "Date"},
+					DictionaryAttribute{Name: "end", Type: "Date"},
+					DictionaryAttribute{Name: "price", Type: "Decimal(10, 2)"},
+				).
+				PrimaryKey("id").
+				Source(SourceHTTP("https://example.com/prices.csv", FormatCSVWithNames)).
+				Layout(LayoutRangeHashed()).
+				Range("start", "end").
+				Lifetime(3600, 3600),
+			want: "CREATE DICTIONARY prices (id UInt64, start Date, end Date, price Decimal(10, 2)) PRIMARY KEY id " +
+				"SOURCE(HTTP(URL 'https://example.com/prices.csv' FORMAT 'CSVWithNames')) LAYOUT(RANGE_HASHED()) LIFETIME(3600) RANGE(MIN start MAX end)",
+		},
+		{
+			name: "pretty",
+			b: CreateDictionary("d").
+				Attribute(DictionaryAttribute{Name: "id", Type: "UInt64"}, DictionaryAttribute{Name: "v", Type: "String"}).
+				PrimaryKey("id").
+				Source(SourceClickHouse("t")).
+				Layout(LayoutFlat()).
+				Lifetime(60, 120).
+				PrettyPrint(),
+			want: "CREATE DICTIONARY d (\n\tid UInt64,\n\tv String\n)\nPRIMARY KEY id\nSOURCE(CLICKHOUSE(TABLE 't'))\nLAYOUT(FLAT())\nLIFETIME(MIN 60 MAX 120)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.b.BuildString()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateDictionary_Invalid(t *testing.T) {
+	valid := func() *CreateDictionaryBuilder {
+		return CreateDictionary("d").
+			Attribute(DictionaryAttribute{Name: "id", Type: "UInt64"}, DictionaryAttribute{Name: "v", Type: "String"}).
+			PrimaryKey("id").
+			Source(SourceClickHouse("t")).
+			Layout(LayoutFlat()).
+			Lifetime(0, 0)
+	}
+	if _, err := valid().BuildString(); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]*CreateDictionaryBuilder{
+		"no attributes":      CreateDictionary("d").PrimaryKey("id").Source(SourceClickHouse("t")).Layout(LayoutFlat()).Lifetime(0, 0),
+		"no source":          CreateDictionary("d").Attribute(DictionaryAttribute{Name: "id", Type: "UInt64"}).PrimaryKey("id").Layout(LayoutFlat()).Lifetime(0, 0),
+		"no lifetime":        CreateDictionary("d").Attribute(DictionaryAttribute{Name: "id", Type: "UInt64"}).PrimaryKey("id").Source(SourceClickHouse("t")).Layout(LayoutFlat()),
+		"undeclared key":     valid().PrimaryKey("x"),
+		"invalid lifetime":   valid().Lifetime(10, 5),
+		"range without hash": valid().Range("id", "v"),
+		"hash without range": valid().Layout(LayoutRangeHashed()),
+		"empty type":         valid().Attribute(DictionaryAttribute{Name: "x"}),
+		"invalid param":      valid().Source(SourceClickHouse("t", DictParam("PORT", []int{1}))),
+		"replace and ifne":   valid().OrReplace().IfNotExists(),
+	}
+	for name, b := range tests {
+		if s, err := b.BuildString(); err == nil {
+			t.Errorf("%s: expected error, got %v", name, s)
+		}
+	}
+}
+
+func TestDictGet(t *testing.T) {
+	q, err := Select(
+		Column("id"),
+		As(DictGet("users_dict", "name", Column("user_id")), Column("name")),
+		DictGetOrDefault("geo", "country", Tuple{Column("lat"), Column("lon")}, LiteralExpressionQuoted("")),
+		DictGetOrNull("users_dict", "email", Column("user_id")),
+	).
+		From(Table("events")).
+		Where(DictHas("users_dict", Column("user_id"))).
+		BuildString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT id, dictGet('users_dict', 'name', user_id) AS name, dictGetOrDefault('geo', 'country', (lat, lon), ''), " +
+		"dictGetOrNull('users_dict', 'email', user_id) FROM events WHERE dictHas('users_dict', user_id)"
+	if q != want {
+		t.Errorf("got:\n%v\nwant:\n%v", q, want)
+	}
+}
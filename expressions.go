@@ -4,11 +4,19 @@ import (
 	"strings"
 )
 
+// And concatenates sub-expressions with AND. Empty And is `true`.
 func And(sub ...Expression) Expression {
+	if len(sub) == 0 {
+		return True()
+	}
 	return Concatenate(OpAnd, sub...)
 }
 
+// Or concatenates sub-expressions with OR. Empty Or is `false`.
 func Or(sub ...Expression) Expression {
+	if len(sub) == 0 {
+		return False()
+	}
 	return Concatenate(OpOr, sub...)
 }
 
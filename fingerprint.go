@@ -0,0 +1,220 @@
+package click
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// normLiteral is a literal stripped during normalization.
+type normLiteral struct {
+	val any
+}
+
+func (normLiteral) Expression() string {
+	return "?"
+}
+
+// normLiteralList is a tuple of literals stripped during normalization, so lists of any length share the same shape.
+type normLiteralList struct {
+	vals []any
+}
+
+func (normLiteralList) Expression() string {
+	return "(?..)"
+}
+
+// Normalize returns the canonical shape of the query, and literals stripped from it.
+//
+// In the shape, bound values and literals (except unquoted strings, which are SQL expressions like column names)
+// are replaced with `?`, tuples of literals are replaced with `(?..)`, operands of AND and OR are flattened
+// and sorted by their shapes and then literals, and whitespaces are collapsed. Pretty-printing does not affect the result.
+// LIMIT, OFFSET and SAMPLE values are kept in the shape.
+// The stripped literals are returned in the order of their placeholders in the shape,
+// stripped tuples are returned as []any.
+//
+// The query is normalized as written, without DefaultPolicy, DefaultGuardrail or type checking.
+func (s *SelectBuilder) Normalize() (string, []any, error) {
+	normalized := s.rewrite(normalizeExpression)
+	c := &argCollector{placeholder: PlaceholderQuestion}
+	sql, err := normalized.rewrite(func(e Expression) Expression {
+		switch e := e.(type) {
+		case normLiteral:
+			return c.marker(e.val, "")
+		case normLiteralList:
+			return c.marker(e.vals, e.Expression())
+		}
+		return e
+	}).buildString(defaultStyle)
+	if err != nil {
+		return "", nil, err
+	}
+	sql, args := c.resolve(sql)
+	return collapseWhitespaces(sql), args, nil
+}
+
+// Fingerprint returns a stable hash of the query shape, see Normalize.
+// Queries differing only in literals share the same fingerprint, which is similar to normalizedQueryHash
+// in ClickHouse, and suitable to group query metrics.
+func (s *SelectBuilder) Fingerprint() (uint64, error) {
+	shape, _, err := s.Normalize()
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write([]byte(shape))
+	return h.Sum64(), nil
+}
+
+// SemanticFingerprint returns a stable hash of the query shape and stripped literals, see Normalize.
+// Unlike Fingerprint, the query is hashed as sent by Build, with DefaultPolicy and DefaultGuardrail applied,
+// so queries of different tenants differ when their predicates are injected by the policy.
+// Equivalent queries share the same fingerprint, which is suitable as cache key of query results.
+// Values passed with the query out of band, like QueryParameter values, are not known to the builder
+// and must be added to the cache key by the caller.
+func (s *SelectBuilder) SemanticFingerprint() (uint64, error) {
+	q, err := s.prepare()
+	if err != nil {
+		return 0, err
+	}
+	shape, args, err := q.Normalize()
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write([]byte(shape))
+	for _, v := range args {
+		// type is included so that 1 and '1' differ
+		fmt.Fprintf(h, "\x00%T:%v", v, v)
+	}
+	return h.Sum64(), nil
+}
+
+// normalizeExpression is applied bottom-up, so sub-expressions are already normalized.
+func normalizeExpression(e Expression) Expression {
+	switch e := e.(type) {
+	case argExpr:
+		return normLiteral{val: e.val}
+	case literalValue:
+		v, quoted := e.literalValue()
+		if quoted || v == nil || reflect.TypeOf(v).Kind() != reflect.String {
+			return normLiteral{val: literalValueOf(e)}
+		}
+	case Tuple:
+		vals := make([]any, len(e))
+		for i := range e {
+			lit, ok := e[i].(normLiteral)
+			if !ok {
+				return e
+			}
+			vals[i] = lit.val
+		}
+		return normLiteralList{vals: vals}
+	case concatenatedExpression:
+		if e.Op != OpAnd && e.Op != OpOr {
+			return e
+		}
+		var operands []Expression
+		for _, sub := range e.Expr {
+			if c, ok := sub.(concatenatedExpression); ok && c.Op == e.Op {
+				operands = append(operands, c.Expr...)
+			} else {
+				operands = append(operands, sub)
+			}
+		}
+		shapes, literals := make([]string, len(operands)), make([]string, len(operands))
+		for i := range operands {
+			shapes[i] = operands[i].Expression()
+			literals[i] = literalKey(operands[i])
+		}
+		sort.Stable(byRendered{operands, shapes, literals})
+		return concatenatedExpression{Op: e.Op, Expr: operands}
+	}
+	return e
+}
+
+// literalValueOf returns the value of literal, converting timestamps to Unix seconds as rendered.
+func literalValueOf(e literalValue) any {
+	v, _ := e.literalValue()
+	if ts, ok := v.(interface{ Unix() int64 }); ok {
+		return ts.Unix()
+	}
+	return v
+}
+
+// literalKey renders the normalized expression with stripped literals, so that operands of the same shape
+// are sorted by their literals, e.g. `a = 1 AND a = 2` and `a = 2 AND a = 1` are normalized to the same order.
+func literalKey(e Expression) string {
+	return rewriteExpression(e, func(e Expression) Expression {
+		switch e := e.(type) {
+		case normLiteral:
+			return alias(fmt.Sprintf("%T:%v", e.val, e.val))
+		case normLiteralList:
+			return alias(fmt.Sprintf("%T:%v", e.vals, e.vals))
+		}
+		return e
+	}).Expression()
+}
+
+// byRendered sorts expressions by their shapes, and then literals.
+type byRendered struct {
+	exprs    []Expression
+	shapes   []string
+	literals []string
+}
+
+func (b byRendered) Len() int { return len(b.exprs) }
+func (b byRendered) Less(i, j int) bool {
+	if b.shapes[i] != b.shapes[j] {
+		return b.shapes[i] < b.shapes[j]
+	}
+	return b.literals[i] < b.literals[j]
+}
+func (b byRendered) Swap(i, j int) {
+	b.exprs[i], b.exprs[j] = b.exprs[j], b.exprs[i]
+	b.shapes[i], b.shapes[j] = b.shapes[j], b.shapes[i]
+	b.literals[i], b.literals[j] = b.literals[j], b.literals[i]
+}
+
+// collapseWhitespaces replaces whitespace sequences outside of quotes with a single space,
+// and removes spaces adjacent to parentheses.
+func collapseWhitespaces(sql string) string {
+	var (
+		sb           strings.Builder
+		quote, last  byte
+		pendingSpace bool
+	)
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if quote != 0 {
+			sb.WriteByte(c)
+			switch c {
+			case '\\':
+				if i+1 < len(sql) {
+					i++
+					sb.WriteByte(sql[i])
+				}
+			case quote:
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			pendingSpace = true
+			continue
+		}
+		if pendingSpace && last != 0 && last != '(' && c != ')' {
+			sb.WriteByte(' ')
+		}
+		pendingSpace = false
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+		}
+		sb.WriteByte(c)
+		last = c
+	}
+	return sb.String()
+}
@@ -0,0 +1,108 @@
+package click
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectBuilder_Normalize(t *testing.T) {
+	s := Select(Column("a"), As(Count(), Alias("cnt"))).
+		From(Select(LiteralExpression("*")).From(Table("tbl")).Where(GreaterThan(Column("ts"), LiteralExpression(time.Unix(100, 0))))).
+		Where(And(
+			In(Column("b"), Tuple(LiteralExpressions([]int{1, 2, 3}, false))),
+			Or(Equal(Column("c"), Arg("x")), And(Equal(Column("d"), LiteralExpressionQuoted("y")), LiteralExpression("e"))),
+		)).
+		GroupBy(Column("a")).
+		Having(GreaterThan(Alias("cnt"), LiteralExpression(10))).
+		Limit(5).
+		PrettyPrint()
+	shape, args, err := s.Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shape != "SELECT a, count() AS cnt FROM (SELECT * FROM tbl WHERE (ts > ?)) WHERE ((((d = ?) AND e) OR (c = ?)) AND (b IN (?..))) GROUP BY a HAVING (cnt > ?) LIMIT 5" {
+		t.Fatal(shape)
+	}
+	if !reflect.DeepEqual(args, []any{int64(100), "y", "x", []any{1, 2, 3}, 10}) {
+		t.Fatal(args)
+	}
+}
+
+func TestSelectBuilder_Fingerprint(t *testing.T) {
+	base := func(values []string, user string) *SelectBuilder {
+		return Select(Count()).From(Table("tbl")).Where(And(
+			In(Column("kind"), Tuple(LiteralExpressions(values, true))),
+			Equal(Column("user"), Arg(user)),
+		))
+	}
+	reordered := Select(Count()).From(Table("tbl")).Where(And(
+		Equal(Column("user"), LiteralExpressionQuoted("u1")),
+		In(Column("kind"), Tuple(LiteralExpressions([]string{"a", "b"}, true))),
+	)).PrettyPrint()
+	f1 := must(base([]string{"a", "b"}, "u1").Fingerprint())
+	f2 := must(base([]string{"c"}, "u2").Fingerprint())
+	f3 := must(reordered.Fingerprint())
+	if f1 != f2 || f1 != f3 {
+		t.Fatal("queries of the same shape have different fingerprints", f1, f2, f3)
+	}
+	if f4 := must(base([]string{"a"}, "u1").OrderBy(Column("user")).Fingerprint()); f4 == f1 {
+		t.Fatal("queries of different shapes have the same fingerprint")
+	}
+	s1 := must(base([]string{"a", "b"}, "u1").SemanticFingerprint())
+	s2 := must(base([]string{"a", "b"}, "u2").SemanticFingerprint())
+	s3 := must(reordered.SemanticFingerprint())
+	if s1 == s2 {
+		t.Fatal("queries with different literals have the same semantic fingerprint")
+	}
+	if s1 != s3 {
+		t.Fatal("equivalent queries have different semantic fingerprints")
+	}
+}
+
+func TestSelectBuilder_SemanticFingerprint_SameShape(t *testing.T) {
+	q := func(x, y int) *SelectBuilder {
+		return Select(Column("a")).From(Table("t")).Where(And(Equal(Column("a"), Arg(x)), Equal(Column("a"), Arg(y))))
+	}
+	if must(q(1, 2).SemanticFingerprint()) != must(q(2, 1).SemanticFingerprint()) {
+		t.Fatal("operands of the same shape are not sorted by literals")
+	}
+	if must(q(1, 2).SemanticFingerprint()) == must(q(1, 3).SemanticFingerprint()) {
+		t.Fatal("queries with different literals have the same semantic fingerprint")
+	}
+}
+
+func TestSelectBuilder_Normalize_Policy(t *testing.T) {
+	old := DefaultPolicy
+	DefaultPolicy = NewPolicy()
+	defer func() { DefaultPolicy = old }()
+	RegisterPolicy(Table("t"), Equal(Column("tenant_id"), Arg("t1")))
+
+	shape, args, err := Select(Column("a")).From(Table("t")).Where(Equal(Column("b"), Arg(5))).Normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shape != "SELECT a FROM t WHERE (b = ?)" || !reflect.DeepEqual(args, []any{5}) {
+		t.Fatalf("Normalize() = %q, %v", shape, args)
+	}
+
+	// the predicate injected by the policy makes cache keys of tenants differ
+	q := Select(Column("a")).From(Table("t"))
+	before := must(q.SemanticFingerprint())
+	DefaultPolicy = NewPolicy()
+	RegisterPolicy(Table("t"), Equal(Column("tenant_id"), Arg("t2")))
+	if after := must(q.SemanticFingerprint()); after == before {
+		t.Fatal("SemanticFingerprint() ignores DefaultPolicy")
+	}
+	DefaultPolicy = NewPolicy()
+	RegisterPolicy(Table("t"), Equal(Column("tenant_id"), QueryParameter("tenant", "UInt64")))
+	if _, err := Select(Column("*")).From(TableFunction("view", LiteralExpression("SELECT * FROM t"))).SemanticFingerprint(); err == nil {
+		t.Fatal("SemanticFingerprint() of a query rejected by the policy should fail")
+	}
+}
+
+func Test_collapseWhitespaces(t *testing.T) {
+	if v := collapseWhitespaces("SELECT\n\t( a ,  'b  c' )\nFROM\n(\n\tt\n)"); v != "SELECT (a , 'b  c') FROM (t)" {
+		t.Fatal(v)
+	}
+}
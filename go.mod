@@ -1,3 +1,10 @@
 module github.com/keuin/click
 
 go 1.18
+
+require github.com/huandu/go-sqlbuilder v1.43.0
+
+require (
+	github.com/huandu/go-clone v1.7.3 // indirect
+	github.com/huandu/xstrings v1.4.0 // indirect
+)
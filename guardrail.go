@@ -0,0 +1,247 @@
+package click
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits are cost limits on queries reading a table, see Guardrail.
+type Limits struct {
+	// MaxLimit is the maximum LIMIT of the outermost query. Queries without LIMIT get `LIMIT MaxLimit`,
+	// and queries with a greater LIMIT are rejected. Zero means no limit.
+	MaxLimit int
+	// TimeColumn is a partition key column like `ts` or `event_date`. Queries reading the table must have a lower bound
+	// on it in WHERE or PREWHERE clause, like `ts >= now() - INTERVAL 1 DAY` or `toDate(ts) = today()`,
+	// so that partitions are pruned. A bound in OR counts only if every operand of OR is bounded.
+	TimeColumn Column
+	// SampleAboveRows makes SAMPLE clause mandatory for queries reading the table, if it has more rows,
+	// or its number of rows is unknown, see Guardrail.SetRows. Zero means SAMPLE is optional.
+	SampleAboveRows uint64
+	// MaxExecutionTime is set as `SETTINGS max_execution_time` of the outermost query, unless the query sets a smaller value.
+	// It is rounded down to seconds, so zero or less than a second means no timeout.
+	MaxExecutionTime time.Duration
+	// DenyStar rejects queries reading the table with `*`, `t.*` or `COLUMNS('regexp')` in SELECT clause.
+	DenyStar bool
+}
+
+// Guardrail is a set of cost limits on tables, so that ad-hoc queries do not scan whole tables.
+// Limits of a table are checked on every SELECT reading the table directly, including nested queries in FROM clause,
+// while LIMIT and SETTINGS are injected into the outermost query, with the strictest limits of all tables read.
+// Queries reading a guarded table with table functions like remote() and merge() are rejected.
+//
+// A Guardrail is safe for concurrent use.
+type Guardrail struct {
+	mu     sync.RWMutex
+	limits map[Table]Limits
+	rows   map[Table]uint64
+}
+
+// NewGuardrail creates an empty guardrail.
+func NewGuardrail() *Guardrail {
+	return &Guardrail{}
+}
+
+// DefaultGuardrail is applied by SelectBuilder.Build and SelectBuilder.BuildString, after DefaultPolicy.
+var DefaultGuardrail = NewGuardrail()
+
+// RegisterGuardrail sets limits on the table in DefaultGuardrail.
+func RegisterGuardrail(table Table, limits Limits) {
+	DefaultGuardrail.Limit(table, limits)
+}
+
+// Limit sets limits on the table, replacing previous ones. Table names without database match the table in any database.
+func (g *Guardrail) Limit(table Table, limits Limits) *Guardrail {
+	if table == "" {
+		panic("empty table in guardrail")
+	}
+	if limits.MaxLimit < 0 || limits.MaxExecutionTime < 0 {
+		panic("negative limit in guardrail")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.limits == nil {
+		g.limits = make(map[Table]Limits)
+	}
+	g.limits[table] = limits
+	return g
+}
+
+// SetRows sets the number of rows of the table for Limits.SampleAboveRows, e.g. `total_rows` in system.tables.
+func (g *Guardrail) SetRows(table Table, rows uint64) *Guardrail {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.rows == nil {
+		g.rows = make(map[Table]uint64)
+	}
+	g.rows[table] = rows
+	return g
+}
+
+func (g *Guardrail) empty() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.limits) == 0
+}
+
+// Apply checks the query, returning a copy of it with LIMIT and SETTINGS injected. The query itself is not modified.
+func (g *Guardrail) Apply(q *SelectBuilder) (*SelectBuilder, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	s := q.Clone()
+	var read []Limits
+	if err := g.check(s, &read); err != nil {
+		return nil, err
+	}
+	maxLimit, timeout := 0, time.Duration(0)
+	for _, l := range read {
+		if l.MaxLimit > 0 && (maxLimit == 0 || l.MaxLimit < maxLimit) {
+			maxLimit = l.MaxLimit
+		}
+		if l.MaxExecutionTime >= time.Second && (timeout == 0 || l.MaxExecutionTime < timeout) {
+			timeout = l.MaxExecutionTime
+		}
+	}
+	if maxLimit > 0 {
+		if !s.hasLimit {
+			s.limit, s.hasLimit = maxLimit, true
+		} else if s.limit > maxLimit {
+			return nil, fmt.Errorf("guardrail: LIMIT %d exceeds the maximum %d", s.limit, maxLimit)
+		}
+	}
+	if timeout > 0 {
+		seconds := int64(timeout / time.Second)
+		v, ok := s.settings.get(SettingMaxExecutionTime)
+		// a timeout of 0 is no timeout
+		if n, err := strconv.ParseFloat(fmt.Sprint(v), 64); !ok || err != nil || n <= 0 || n > float64(seconds) {
+			s.settings = s.settings.set(SettingMaxExecutionTime, seconds)
+		}
+	}
+	return s, nil
+}
+
+// check checks limits of tables read by s and its nested queries, appending limits of tables to read.
+func (g *Guardrail) check(s *SelectBuilder, read *[]Limits) error {
+	var err error
+	walkFrom(s.from, func(f FromExpression) {
+		if err != nil {
+			return
+		}
+		switch f := f.(type) {
+		case Table:
+			for guarded, l := range g.limits {
+				if !sameTable(guarded, f) {
+					continue
+				}
+				*read = append(*read, l)
+				if err = g.checkTable(s, f, l); err != nil {
+					return
+				}
+			}
+		case tableFunction:
+			tables := make([]Table, 0, len(g.limits))
+			for t := range g.limits {
+				tables = append(tables, t)
+			}
+			err = checkTableFunction(f, tables, "guardrail")
+		case tableAlias, fileLikeFunction:
+		default:
+			if sub, ok := InspectSubquery(f); ok {
+				err = g.check(sub, read)
+			} else {
+				err = fmt.Errorf("guardrail: unknown FROM source %T may read guarded tables", f)
+			}
+		}
+	})
+	return err
+}
+
+// checkTable checks limits of table t on query s reading it directly.
+func (g *Guardrail) checkTable(s *SelectBuilder, t Table, l Limits) error {
+	if l.DenyStar {
+		for _, e := range s.selects {
+			if isStarSelect(e) {
+				return fmt.Errorf("guardrail: table %s: SELECT %s reads all columns", t, e.Expression())
+			}
+		}
+	}
+	if l.TimeColumn != "" && !lowerBounded(s.where, l.TimeColumn) && !lowerBounded(s.prewhere, l.TimeColumn) {
+		return fmt.Errorf("guardrail: table %s: WHERE must have a lower bound on %s", t, l.TimeColumn)
+	}
+	if l.SampleAboveRows > 0 && s.sample <= 0 {
+		rows, ok := g.rowsOf(t)
+		if !ok {
+			return fmt.Errorf("guardrail: table %s: SAMPLE is required, since the number of rows is unknown", t)
+		}
+		if rows > l.SampleAboveRows {
+			return fmt.Errorf("guardrail: table %s: SAMPLE is required for tables of more than %d rows", t, l.SampleAboveRows)
+		}
+	}
+	return nil
+}
+
+func (g *Guardrail) rowsOf(t Table) (uint64, bool) {
+	for v, rows := range g.rows {
+		if sameTable(v, t) {
+			return rows, true
+		}
+	}
+	return 0, false
+}
+
+// isStarSelect reports whether the SELECT expression reads all columns, like `*`, `t.*` and `COLUMNS('regexp')`.
+func isStarSelect(e Expression) bool {
+	if expr, _, ok := InspectAs(e); ok {
+		e = expr
+	}
+	if name, _, ok := InspectFunction(e); ok {
+		return strings.EqualFold(name, "COLUMNS")
+	}
+	switch KindOf(e) {
+	case KindColumn, KindAlias, KindRaw:
+		return isStar(strings.TrimSpace(e.Expression()))
+	default:
+		return false
+	}
+}
+
+// lowerBounded reports whether the predicate has a lower bound on the column, like `col >= x` or `toDate(col) = x`,
+// where x refers to no columns.
+func lowerBounded(e Expression, col Column) bool {
+	switch e := e.(type) {
+	case concatenatedExpression:
+		for _, v := range e.Expr {
+			bounded := lowerBounded(v, col)
+			if e.Op == OpAnd && bounded {
+				return true
+			}
+			if e.Op == OpOr && !bounded {
+				return false
+			}
+		}
+		return e.Op == OpOr
+	case BinaryExpression:
+		switch e.Operator {
+		case ">", ">=", "IN":
+			return boundsColumn(e.LeftOperand, e.RightOperand, col)
+		case "<", "<=":
+			return boundsColumn(e.RightOperand, e.LeftOperand, col)
+		case "=", "==":
+			return boundsColumn(e.LeftOperand, e.RightOperand, col) || boundsColumn(e.RightOperand, e.LeftOperand, col)
+		}
+	}
+	return false
+}
+
+// boundsColumn reports whether e is an expression of only the column, like `ts` or `toDate(ts)`,
+// and bound refers to no columns.
+func boundsColumn(e, bound Expression, col Column) bool {
+	cols := ReferencedColumns(e)
+	if len(cols) != 1 || len(ReferencedColumns(bound)) > 0 {
+		return false
+	}
+	name, want := unquoteIdent(string(cols[0])), unquoteIdent(string(col))
+	return name == want || strings.HasSuffix(name, "."+want)
+}
@@ -0,0 +1,205 @@
+package click
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testGuardrail() *Guardrail {
+	return NewGuardrail().
+		Limit(Table("db.events"), Limits{
+			MaxLimit:         1000,
+			TimeColumn:       "ts",
+			SampleAboveRows:  1e9,
+			MaxExecutionTime: 30 * time.Second,
+			DenyStar:         true,
+		}).
+		Limit(Table("users"), Limits{MaxLimit: 100, MaxExecutionTime: 10 * time.Second}).
+		SetRows(Table("db.events"), 1e6)
+}
+
+func TestGuardrail_Apply(t *testing.T) {
+	lastDay := GreaterOrEqualThan(Column("ts"), Ago(Interval(1, UnitDay)))
+	tests := []struct {
+		name  string
+		query *SelectBuilder
+		want  string
+	}{
+		{
+			name:  "inject",
+			query: Select(Count()).From(Table("events")).Where(lastDay),
+			want:  "SELECT count() FROM events WHERE (ts >= (now() - INTERVAL 1 DAY)) LIMIT 1000 SETTINGS max_execution_time = 30",
+		},
+		{
+			name:  "smaller limits",
+			query: Select(Count()).From(Table("db.events")).Where(lastDay).Limit(10).Setting(SettingMaxExecutionTime, 5),
+			want:  "SELECT count() FROM db.events WHERE (ts >= (now() - INTERVAL 1 DAY)) LIMIT 10 SETTINGS max_execution_time = 5",
+		},
+		{
+			name:  "greater timeout",
+			query: Select(Count()).From(Table("db.events")).Where(lastDay).Setting(SettingMaxExecutionTime, 0),
+			want:  "SELECT count() FROM db.events WHERE (ts >= (now() - INTERVAL 1 DAY)) LIMIT 1000 SETTINGS max_execution_time = 30",
+		},
+		{
+			name: "strictest of nested queries",
+			query: Select(Column("name")).From(TableAlias(Select(Column("name")).From(Table("users")), "u")).
+				Where(In(Column("id"), Tuple{LiteralExpression(1)})),
+			want: "SELECT name FROM (\nSELECT name FROM users\n) AS u WHERE (id IN (1)) LIMIT 100 SETTINGS max_execution_time = 10",
+		},
+		{
+			name:  "unguarded",
+			query: Select(Column("*")).From(Table("other")),
+			want:  "SELECT * FROM other",
+		},
+		{
+			name: "time range",
+			query: Select(Column("id"), As(Count(), Column("n"))).From(Table("events").As("e")).
+				Prewhere(Or(
+					And(LessThan(DateTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)), Column("e.ts")), Column("x")),
+					Equal(ToDate(Column("ts")), Today()),
+				)).
+				GroupBy(Column("id")),
+			want: "SELECT id, count() AS n FROM events AS e PREWHERE " +
+				"(((toDateTime('2024-01-01 00:00:00', 'UTC') < e.ts) AND x) OR (toDate(ts) = today())) GROUP BY id LIMIT 1000 " +
+				"SETTINGS max_execution_time = 30",
+		},
+	}
+	g := testGuardrail()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := must(tt.query.BuildString())
+			got := must(must(g.Apply(tt.query)).BuildString())
+			if got != tt.want {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+			if v := must(tt.query.BuildString()); v != before {
+				t.Errorf("original query is modified: %s", v)
+			}
+		})
+	}
+}
+
+func TestGuardrail_Reject(t *testing.T) {
+	lastDay := GreaterOrEqualThan(Column("ts"), Ago(Interval(1, UnitDay)))
+	tests := []struct {
+		name  string
+		query *SelectBuilder
+		want  string
+	}{
+		{
+			name:  "LIMIT",
+			query: Select(Count()).From(Table("events")).Where(lastDay).Limit(5000),
+			want:  "LIMIT 5000 exceeds the maximum 1000",
+		},
+		{
+			name:  "no WHERE",
+			query: Select(Count()).From(Table("events")),
+			want:  "WHERE must have a lower bound on ts",
+		},
+		{
+			name:  "upper bound only",
+			query: Select(Count()).From(Table("events")).Where(LessThan(Column("ts"), Now())),
+			want:  "WHERE must have a lower bound on ts",
+		},
+		{
+			name:  "bound by column",
+			query: Select(Count()).From(Table("events")).Where(GreaterThan(Column("ts"), Column("created"))),
+			want:  "WHERE must have a lower bound on ts",
+		},
+		{
+			name:  "partially bounded OR",
+			query: Select(Count()).From(Table("events")).Where(Or(lastDay, Column("x"))),
+			want:  "WHERE must have a lower bound on ts",
+		},
+		{
+			name:  "star",
+			query: Select(Column("e.*")).From(Table("events").As("e")).Where(lastDay),
+			want:  "SELECT e.* reads all columns",
+		},
+		{
+			name:  "COLUMNS",
+			query: Select(Fn("COLUMNS", LiteralExpressionQuoted("^a"))).From(Table("events")).Where(lastDay),
+			want:  "SELECT COLUMNS('^a') reads all columns",
+		},
+		{
+			name:  "nested query",
+			query: Select(Count()).From(Select(Column("*")).From(Table("events")).Where(lastDay)),
+			want:  "SELECT * reads all columns",
+		},
+		{
+			name:  "table function",
+			query: Select(Count()).From(Remote("host:9000", "db", "events")),
+			want:  "guardrail: table db.events is protected, remote() bypasses the guardrail",
+		},
+		{
+			name:  "remote with identifier",
+			query: Select(Count()).From(TableFunction("remote", LiteralExpressionQuoted("host:9000"), LiteralExpression("db.events"))),
+			want:  "guardrail: table db.events is protected, remote() bypasses the guardrail",
+		},
+		{
+			name:  "merge in current database",
+			query: Select(Count()).From(TableFunction("merge", LiteralExpressionQuoted("^ev"))),
+			want:  "guardrail: table db.events is protected, merge() bypasses the guardrail",
+		},
+		{
+			name:  "view",
+			query: Select(Count()).From(TableFunction("view", LiteralExpression("SELECT * FROM events"))),
+			want:  "guardrail: table function view() may read protected tables and can not be checked",
+		},
+	}
+	g := testGuardrail()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := g.Apply(tt.query)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Apply() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardrail_Sample(t *testing.T) {
+	g := NewGuardrail().Limit(Table("events"), Limits{SampleAboveRows: 1000})
+	q := Select(Count()).From(Table("events"))
+	if _, err := g.Apply(q); err == nil || !strings.Contains(err.Error(), "number of rows is unknown") {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	g.SetRows(Table("db.events"), 1000)
+	if _, err := g.Apply(q); err != nil {
+		t.Fatal(err)
+	}
+	g.SetRows(Table("db.events"), 1001)
+	if _, err := g.Apply(q); err == nil || !strings.Contains(err.Error(), "SAMPLE is required for tables of more than 1000 rows") {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if _, err := g.Apply(q.Sample(0.1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultGuardrail_Build(t *testing.T) {
+	old := DefaultGuardrail
+	DefaultGuardrail = NewGuardrail()
+	defer func() { DefaultGuardrail = old }()
+	RegisterGuardrail(Table("events"), Limits{MaxLimit: 10, DenyStar: true})
+
+	q := Select(Column("a")).From(Table("events"))
+	if v := must(q.BuildString()); v != "SELECT a FROM events LIMIT 10" {
+		t.Fatal(v)
+	}
+	if v := must(q.Build()).String(); v != "SELECT a FROM events LIMIT 10" {
+		t.Fatal(v)
+	}
+	if _, err := Select(Column("*")).From(Table("events")).Build(); err == nil {
+		t.Fatal("expected error on SELECT *")
+	}
+	simple := SimpleQuery{Select: []Expression{Column("a")}, From: "events", Limit: 100}
+	if _, err := simple.Build(); err == nil || !strings.Contains(err.Error(), "LIMIT 100 exceeds the maximum 10") {
+		t.Fatalf("SimpleQuery.Build() error = %v", err)
+	}
+	simple.Limit = 0
+	if v := must(simple.BuildString()); v != "SELECT a FROM events LIMIT 10" {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,54 @@
+package click
+
+import (
+	"errors"
+	"strings"
+)
+
+// InsertInto creates an INSERT query whose data is sent separately in the given format,
+// e.g. `INSERT INTO t (a, b) FORMAT RowBinary`. Columns are optional, all columns are inserted if absent.
+// See https://clickhouse.com/docs/sql-reference/statements/insert-into
+func InsertInto(table Table, columns ...Column) *InsertBuilder {
+	b := &InsertBuilder{
+		table: table,
+	}
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// InsertBuilder implements builder pattern for constructing INSERT SQLs with data in a specific format.
+type InsertBuilder struct {
+	table   Table
+	columns []Column
+	format  Format
+}
+
+func (b *InsertBuilder) Format(f Format) *InsertBuilder {
+	b.format = f
+	return b
+}
+
+func (b *InsertBuilder) BuildString() (string, error) {
+	if b.table == "" {
+		return "", errors.New("no table")
+	}
+	if b.format == "" {
+		return "", errors.New("no format")
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(string(b.table))
+	if len(b.columns) > 0 {
+		sb.WriteString(" (")
+		for i, c := range b.columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(c.Expression())
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteString(" FORMAT ")
+	sb.WriteString(string(b.format))
+	return sb.String(), nil
+}
@@ -0,0 +1,34 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestInsertInto(t *testing.T) {
+	tests := []struct {
+		name string
+		b    *InsertBuilder
+		want string
+	}{
+		{
+			name: "all columns",
+			b:    InsertInto("tbl").Format(FormatRowBinary),
+			want: "INSERT INTO tbl FORMAT RowBinary",
+		},
+		{
+			name: "some columns",
+			b:    InsertInto("tbl", "a", "b").Format(FormatRowBinaryWithNamesAndTypes),
+			want: "INSERT INTO tbl (a, b) FORMAT RowBinaryWithNamesAndTypes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := must(tt.b.BuildString()); got != tt.want {
+				t.Errorf("BuildString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if _, err := InsertInto("tbl").BuildString(); err == nil {
+		t.Fatal("expected error on absent format")
+	}
+}
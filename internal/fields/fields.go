@@ -0,0 +1,105 @@
+// Package fields maps column names to fields of structs, following `ch` struct tags.
+package fields
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Map maps column names to indexes of struct fields, including fields of embedded structs.
+// A field is named by its `ch` tag if present, or the field name otherwise. Fields tagged `ch:"-"` are skipped.
+type Map struct {
+	exact map[string][]int
+	// fold is lower-cased names of fields without tags
+	fold map[string][]int
+	// lower is lower-cased names of all fields
+	lower map[string][]int
+}
+
+// Lookup returns the field of the column, matching names exactly,
+// or case-insensitively for fields without tags.
+func (m *Map) Lookup(name string) ([]int, bool) {
+	if index, ok := m.exact[name]; ok {
+		return index, true
+	}
+	index, ok := m.fold[strings.ToLower(name)]
+	return index, ok
+}
+
+// LookupFold returns the field of the column, matching names case-insensitively.
+func (m *Map) LookupFold(name string) ([]int, bool) {
+	index, ok := m.lower[strings.ToLower(name)]
+	return index, ok
+}
+
+// field index cache of struct types, map[reflect.Type]*Map
+var cache sync.Map
+
+// Of returns the Map of struct type typ.
+func Of(typ reflect.Type) *Map {
+	if m, ok := cache.Load(typ); ok {
+		return m.(*Map)
+	}
+	m := &Map{
+		exact: make(map[string][]int),
+		fold:  make(map[string][]int),
+		lower: make(map[string][]int),
+	}
+	var walk func(typ reflect.Type, prefix []int)
+	walk = func(typ reflect.Type, prefix []int) {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			tag, hasTag := f.Tag.Lookup("ch")
+			if tag == "-" {
+				continue
+			}
+			index := append(append([]int{}, prefix...), i)
+			if f.Anonymous && !hasTag {
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, index)
+					continue
+				}
+			}
+			if !f.IsExported() {
+				continue
+			}
+			name := f.Name
+			if hasTag {
+				name = strings.Split(tag, ",")[0]
+			}
+			// outer fields take precedence over embedded ones
+			if _, ok := m.exact[name]; !ok {
+				m.exact[name] = index
+			}
+			lower := strings.ToLower(name)
+			if _, ok := m.fold[lower]; !ok && !hasTag {
+				m.fold[lower] = index
+			}
+			if _, ok := m.lower[lower]; !ok {
+				m.lower[lower] = index
+			}
+		}
+	}
+	walk(typ, nil)
+	actual, _ := cache.LoadOrStore(typ, m)
+	return actual.(*Map)
+}
+
+// ByIndex is reflect.Value.FieldByIndex, allocating nil embedded struct pointers.
+func ByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
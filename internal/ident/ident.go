@@ -0,0 +1,14 @@
+// Package ident handles identifiers in SQL, shared by packages of the module.
+package ident
+
+import "strings"
+
+// SplitTable splits a table name like `db.events` into the database and the table name, ignoring backquotes.
+// The database is empty for names without database.
+func SplitTable(table string) (database, name string) {
+	s := strings.ReplaceAll(table, "`", "")
+	if i := strings.LastIndexByte(s, '.'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return "", s
+}
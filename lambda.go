@@ -0,0 +1,149 @@
+package click
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LambdaParameter is a parameter of a lambda function, referenced in the lambda body.
+// It is a different type from Column, so rewriting columns never touches lambda parameters.
+type LambdaParameter string
+
+func (p LambdaParameter) Expression() string {
+	return string(p)
+}
+
+// LambdaExpression is a lambda function, like `x -> (x > 0)`, used as argument of higher-order functions.
+// See https://clickhouse.com/docs/sql-reference/functions/overview#higher-order-functions
+type LambdaExpression struct {
+	params []LambdaParameter
+	body   Expression
+}
+
+// Lambda creates a lambda function with parameters and body.
+// Parameters must be non-empty and distinct.
+func Lambda(params []LambdaParameter, body Expression) LambdaExpression {
+	if len(params) == 0 {
+		panic("lambda must have at least one parameter")
+	}
+	if body == nil {
+		panic("empty lambda body")
+	}
+	seen := make(map[LambdaParameter]bool, len(params))
+	for _, p := range params {
+		if p == "" {
+			panic("empty lambda parameter")
+		}
+		if seen[p] {
+			panic(fmt.Sprintf("duplicated lambda parameter %s", p))
+		}
+		seen[p] = true
+	}
+	return LambdaExpression{
+		params: append([]LambdaParameter(nil), params...),
+		body:   body,
+	}
+}
+
+func (l LambdaExpression) Expression() string {
+	var sb strings.Builder
+	if len(l.params) == 1 {
+		sb.WriteString(string(l.params[0]))
+	} else {
+		sb.WriteByte('(')
+		for i, p := range l.params {
+			if i != 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(string(p))
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteString(" -> ")
+	sb.WriteString(l.body.Expression())
+	return sb.String()
+}
+
+// InspectLambda returns parameters and body of a lambda function.
+func InspectLambda(e Expression) (params []LambdaParameter, body Expression, ok bool) {
+	l, ok := e.(LambdaExpression)
+	if !ok {
+		return nil, nil, false
+	}
+	return append([]LambdaParameter(nil), l.params...), l.body, true
+}
+
+// higherOrder calls a higher-order function, whose lambda takes one parameter per array.
+func higherOrder(name string, f LambdaExpression, arrays []Expression) Expression {
+	if f.body == nil {
+		panic(name + ": empty lambda")
+	}
+	if len(arrays) == 0 {
+		panic(name + ": no arrays")
+	}
+	if len(f.params) != len(arrays) {
+		panic(fmt.Sprintf("%s: lambda takes %d parameters, but %d arrays are given", name, len(f.params), len(arrays)))
+	}
+	args := make([]Expression, 0, len(arrays)+1)
+	args = append(args, f)
+	return Fn(name, append(args, arrays...)...)
+}
+
+// ArrayMap applies f on elements of arrays, e.g. `arrayMap((x, y) -> (x + y), arr1, arr2)`.
+// The lambda takes one parameter per array.
+func ArrayMap(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayMap", f, arrays)
+}
+
+// ArrayFilter returns elements of the first array for which f returns non-zero.
+// The lambda takes one parameter per array.
+func ArrayFilter(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayFilter", f, arrays)
+}
+
+// ArrayExists returns 1 if f returns non-zero for at least one element.
+// The lambda takes one parameter per array.
+func ArrayExists(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayExists", f, arrays)
+}
+
+// ArrayAll returns 1 if f returns non-zero for all elements.
+// The lambda takes one parameter per array.
+func ArrayAll(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayAll", f, arrays)
+}
+
+// ArrayCount returns the number of elements for which f returns non-zero.
+// The lambda takes one parameter per array.
+func ArrayCount(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayCount", f, arrays)
+}
+
+// ArrayFirst returns the first element of the first array for which f returns non-zero.
+// The lambda takes one parameter per array.
+func ArrayFirst(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayFirst", f, arrays)
+}
+
+// ArraySort sorts the array in ascending order.
+// See https://clickhouse.com/docs/sql-reference/functions/array-functions#arraysort
+func ArraySort(array Expression) Expression {
+	return Fn("arraySort", array)
+}
+
+// ArraySortBy sorts the first array in ascending order of f applied on elements.
+// The lambda takes one parameter per array.
+func ArraySortBy(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arraySort", f, arrays)
+}
+
+// ArrayReverseSort sorts the array in descending order.
+func ArrayReverseSort(array Expression) Expression {
+	return Fn("arrayReverseSort", array)
+}
+
+// ArrayReverseSortBy sorts the first array in descending order of f applied on elements.
+// The lambda takes one parameter per array.
+func ArrayReverseSortBy(f LambdaExpression, arrays ...Expression) Expression {
+	return higherOrder("arrayReverseSort", f, arrays)
+}
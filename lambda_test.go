@@ -0,0 +1,90 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestHigherOrderFunctions(t *testing.T) {
+	x, y := LambdaParameter("x"), LambdaParameter("y")
+	positive := Lambda([]LambdaParameter{x}, GreaterThan(x, LiteralExpression(0)))
+	tests := []struct {
+		name string
+		expr Expression
+		want string
+	}{
+		{
+			name: "arrayFilter",
+			expr: ArrayFilter(positive, Column("arr")),
+			want: "arrayFilter(x -> (x > 0), arr)",
+		},
+		{
+			name: "arrayMap with two arrays",
+			expr: ArrayMap(Lambda([]LambdaParameter{x, y}, Fn("plus", x, y)), Column("a"), Column("b")),
+			want: "arrayMap((x, y) -> plus(x, y), a, b)",
+		},
+		{
+			name: "arrayExists",
+			expr: ArrayExists(positive, Column("arr")),
+			want: "arrayExists(x -> (x > 0), arr)",
+		},
+		{
+			name: "arraySort",
+			expr: ArraySort(Column("arr")),
+			want: "arraySort(arr)",
+		},
+		{
+			name: "arraySort by lambda",
+			expr: ArraySortBy(Lambda([]LambdaParameter{x}, Fn("negate", x)), Column("arr")),
+			want: "arraySort(x -> negate(x), arr)",
+		},
+		{
+			name: "nested lambda",
+			expr: ArrayMap(Lambda([]LambdaParameter{x}, ArrayFilter(Lambda([]LambdaParameter{y}, GreaterThan(y, x)), Column("b"))), Column("a")),
+			want: "arrayMap(x -> arrayFilter(y -> (y > x), b), a)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.expr.Expression(); got != tt.want {
+				t.Errorf("Expression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHigherOrderFunctions_ParameterCount(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic, got nothing")
+		}
+	}()
+	x := LambdaParameter("x")
+	ArrayMap(Lambda([]LambdaParameter{x}, x), Column("a"), Column("b"))
+}
+
+func TestLambda_DuplicatedParameter(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic, got nothing")
+		}
+	}()
+	x := LambdaParameter("x")
+	Lambda([]LambdaParameter{x, x}, x)
+}
+
+func TestLambda_RewriteColumns(t *testing.T) {
+	x := LambdaParameter("x")
+	e := ArrayFilter(Lambda([]LambdaParameter{x}, GreaterThan(x, Column("threshold"))), Column("arr"))
+	if cols := ReferencedColumns(e); len(cols) != 2 || cols[0] != "threshold" || cols[1] != "arr" {
+		t.Fatal(cols)
+	}
+	got := must(Rewrite(e, func(e Expression) (Expression, error) {
+		if c, ok := e.(Column); ok {
+			return Column("t." + c), nil
+		}
+		return e, nil
+	}))
+	if v := got.Expression(); v != "arrayFilter(x -> (x > t.threshold), t.arr)" {
+		t.Fatal(v)
+	}
+}
@@ -82,3 +82,10 @@ var clickhouseStringEscapeReplacer = strings.NewReplacer(
 	`'`, `\'`,
 	`\`, `\\`,
 )
+
+// QueryParameter is a placeholder of server-side query parameter, rendering as `{name:Type}`.
+// The value is passed separately when executing the query, e.g. with `param_name` in HTTP interface.
+// See https://clickhouse.com/docs/interfaces/cli#cli-queries-with-parameters
+func QueryParameter(name, typ string) Expression {
+	return alias("{" + name + ":" + typ + "}")
+}
@@ -13,3 +13,9 @@ func TestLiteralExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestQueryParameter(t *testing.T) {
+	if v := QueryParameter("id", "UInt64").Expression(); v != "{id:UInt64}" {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,171 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/clickhttp"
+	"github.com/keuin/click/rowformat"
+)
+
+// Executor executes migration statements and manages the bookkeeping table.
+type Executor interface {
+	// Exec executes a DDL statement.
+	Exec(ctx context.Context, query string) error
+	// Records returns the latest record of each version in the bookkeeping table, in ascending order of versions.
+	Records(ctx context.Context, table string) ([]Record, error)
+	// AddRecord adds a record to the bookkeeping table.
+	AddRecord(ctx context.Context, table string, r Record) error
+}
+
+// HTTPExecutor executes migrations with a ClickHouse HTTP client.
+type HTTPExecutor struct {
+	Client *clickhttp.Client
+}
+
+func (e HTTPExecutor) Exec(ctx context.Context, query string) error {
+	return e.Client.Exec(ctx, query)
+}
+
+type recordRow struct {
+	Version     uint64    `ch:"version"`
+	Description string    `ch:"last_description"`
+	Checksum    string    `ch:"last_checksum"`
+	Applied     uint8     `ch:"last_applied"`
+	Time        time.Time `ch:"last_ts"`
+}
+
+func (e HTTPExecutor) Records(ctx context.Context, table string) ([]Record, error) {
+	ts := click.Column("ts")
+	// aliases differ from column names, since ClickHouse aliases are visible in the whole query
+	latest := func(c click.Column) click.SelectExpression {
+		return click.As(click.Fn("argMax", c, ts), "last_"+c)
+	}
+	q, err := click.Select(
+		click.Column("version"),
+		latest("description"),
+		latest("checksum"),
+		latest("applied"),
+		click.As(click.Fn("max", ts), click.Column("last_ts")),
+	).
+		From(click.Table(table)).
+		GroupBy(click.Column("version")).
+		OrderBy(click.Column("version")).
+		Format(click.FormatTabSeparatedWithNamesAndTypes).
+		Build()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := e.Client.Query(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	d, err := rowformat.NewDecoder(rows, click.FormatTabSeparatedWithNamesAndTypes)
+	if err != nil {
+		return nil, err
+	}
+	var ret []Record
+	for d.Next() {
+		var row recordRow
+		if err := d.Scan(&row); err != nil {
+			return nil, err
+		}
+		ret = append(ret, Record{
+			Version:     row.Version,
+			Description: row.Description,
+			Checksum:    row.Checksum,
+			Applied:     row.Applied != 0,
+			Time:        row.Time,
+		})
+	}
+	return ret, d.Err()
+}
+
+func (e HTTPExecutor) AddRecord(ctx context.Context, table string, r Record) error {
+	applied := 0
+	if r.Applied {
+		applied = 1
+	}
+	row, err := json.Marshal(map[string]any{
+		"version":     r.Version,
+		"description": r.Description,
+		"checksum":    r.Checksum,
+		"applied":     applied,
+		"ts":          r.Time.UTC().Format("2006-01-02 15:04:05.000000"),
+	})
+	if err != nil {
+		return err
+	}
+	insert := click.InsertInto(click.Table(table), "version", "description", "checksum", "applied", "ts").
+		Format(click.FormatJSONEachRow)
+	return e.Client.Insert(ctx, insert, bytes.NewReader(append(row, '\n')))
+}
+
+// MemoryExecutor is an in-memory Executor for tests. It records executed statements without executing them.
+// It is safe for concurrent use.
+type MemoryExecutor struct {
+	mu      sync.Mutex
+	records map[string][]Record
+	// Statements are executed statements, including creation of the bookkeeping table.
+	Statements []string
+	// Fail is called before executing each statement, returning non-nil error fails the statement.
+	Fail func(query string) error
+}
+
+func (e *MemoryExecutor) Exec(ctx context.Context, query string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.Fail != nil {
+		if err := e.Fail(query); err != nil {
+			return err
+		}
+	}
+	e.Statements = append(e.Statements, query)
+	return nil
+}
+
+func (e *MemoryExecutor) Records(ctx context.Context, table string) ([]Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	latest := make(map[uint64]Record)
+	for _, r := range e.records[table] {
+		latest[r.Version] = r
+	}
+	ret := make([]Record, 0, len(latest))
+	for _, r := range latest {
+		ret = append(ret, r)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Version < ret[j].Version
+	})
+	return ret, nil
+}
+
+func (e *MemoryExecutor) AddRecord(ctx context.Context, table string, r Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if table == "" {
+		return errors.New("empty table")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.records == nil {
+		e.records = make(map[string][]Record)
+	}
+	e.records[table] = append(e.records[table], r)
+	return nil
+}
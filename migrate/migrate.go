@@ -0,0 +1,407 @@
+// Package migrate applies versioned schema migrations built with click DDL builders,
+// keeping records of applied migrations in a bookkeeping table.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keuin/click"
+)
+
+var (
+	// ErrChecksumMismatch means statements of an applied migration are modified after it was applied.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrUnknownVersion means a migration is applied, but not defined any more.
+	ErrUnknownVersion = errors.New("unknown applied migration")
+	// ErrIrreversible means a migration without Down statements is being rolled back.
+	ErrIrreversible = errors.New("irreversible migration")
+)
+
+// SQL is a raw SQL statement, for statements without a builder.
+type SQL string
+
+func (s SQL) BuildString() (string, error) {
+	if strings.TrimSpace(string(s)) == "" {
+		return "", errors.New("empty SQL")
+	}
+	return string(s), nil
+}
+
+// Migration is a versioned schema change. Versions must be positive and unique, e.g. 1, 2, 3 or 20240102150405.
+// Up statements are checksummed, modifying them after the migration is applied is detected as drift.
+//
+// A migration is recorded only after all of its statements succeed, so a migration failing partway is run again
+// from its first statement. Statements should be idempotent, e.g. with IfNotExists of CREATE builders
+// and Idempotent of AlterTableBuilder, or a migration should have a single statement.
+type Migration struct {
+	Version     uint64
+	Description string
+	Up          []click.Statement
+	// Down statements roll back the migration. Migrations without Down statements are irreversible.
+	Down []click.Statement
+}
+
+// Checksum is the hex SHA-256 of Up statements as written, see click.RawString.
+// It does not depend on click.DefaultPolicy or click.DefaultGuardrail, which are applied on execution.
+func (m Migration) Checksum() (string, error) {
+	up, err := m.rawUp(nil)
+	if err != nil {
+		return "", err
+	}
+	return checksum(up), nil
+}
+
+// rawUp renders Up statements as written. If rendered is not nil, it is Up statements rendered for execution,
+// and reused for statements other than *click.SelectBuilder, which are rendered the same way.
+func (m Migration) rawUp(rendered []string) ([]string, error) {
+	if rendered == nil {
+		return m.render("up", m.Up, click.RawString)
+	}
+	ret := append([]string(nil), rendered...)
+	for i, s := range m.Up {
+		if _, ok := s.(*click.SelectBuilder); !ok {
+			continue
+		}
+		sql, err := click.RawString(s)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d: up statement %d: %w", m.Version, i, err)
+		}
+		ret[i] = sql
+	}
+	return ret, nil
+}
+
+// renderUp renders Up statements for execution.
+func (m Migration) renderUp() ([]string, error) {
+	return m.render("up", m.Up, click.Statement.BuildString)
+}
+
+// renderDown renders Down statements for execution.
+func (m Migration) renderDown() ([]string, error) {
+	return m.render("down", m.Down, click.Statement.BuildString)
+}
+
+func (m Migration) render(direction string, statements []click.Statement, build func(click.Statement) (string, error)) ([]string, error) {
+	ret := make([]string, len(statements))
+	for i, s := range statements {
+		if s == nil {
+			return nil, fmt.Errorf("migration %d: %s statement %d: nil statement", m.Version, direction, i)
+		}
+		sql, err := build(s)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d: %s statement %d: %w", m.Version, direction, i, err)
+		}
+		ret[i] = sql
+	}
+	return ret, nil
+}
+
+func checksum(statements []string) string {
+	h := sha256.New()
+	for _, sql := range statements {
+		h.Write([]byte(sql))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Record is a row in the bookkeeping table. Applying and rolling back a migration both add a record,
+// the latest record of a version tells whether it is applied.
+type Record struct {
+	Version     uint64
+	Description string
+	Checksum    string
+	Applied     bool
+	Time        time.Time
+}
+
+// Status is the state of a migration.
+type Status struct {
+	Version     uint64
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+	// Drifted means the migration is applied with different Up statements.
+	Drifted bool
+}
+
+// Runner applies migrations through an Executor.
+type Runner struct {
+	exec       Executor
+	migrations []Migration
+	table      string
+	cluster    string
+	engine     string
+	dryRun     io.Writer
+	now        func() time.Time
+}
+
+type Option func(r *Runner)
+
+// WithTable sets the bookkeeping table, `schema_migrations` by default.
+func WithTable(table string) Option {
+	return func(r *Runner) {
+		r.table = table
+	}
+}
+
+// WithCluster creates the bookkeeping table ON CLUSTER. Migration statements should be built with the same cluster,
+// e.g. `click.AlterTable(t).OnCluster(r.Cluster())`. It is usually combined with WithEngine, using a replicated engine.
+func WithCluster(cluster string) Option {
+	return func(r *Runner) {
+		r.cluster = cluster
+	}
+}
+
+// WithEngine sets the engine of the bookkeeping table, `MergeTree` by default.
+func WithEngine(engine string) Option {
+	return func(r *Runner) {
+		r.engine = engine
+	}
+}
+
+// WithDryRun prints statements to w in pretty format, instead of executing them.
+// Records are read but never written in dry-run mode.
+func WithDryRun(w io.Writer) Option {
+	return func(r *Runner) {
+		r.dryRun = w
+	}
+}
+
+// New creates a runner. Migrations may be in any order, they are applied in ascending order of versions.
+func New(exec Executor, migrations []Migration, opts ...Option) (*Runner, error) {
+	if exec == nil {
+		return nil, errors.New("nil executor")
+	}
+	r := &Runner{
+		exec:       exec,
+		migrations: append([]Migration(nil), migrations...),
+		table:      "schema_migrations",
+		engine:     "MergeTree",
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.table == "" {
+		return nil, errors.New("empty bookkeeping table")
+	}
+	sort.Slice(r.migrations, func(i, j int) bool {
+		return r.migrations[i].Version < r.migrations[j].Version
+	})
+	for i, m := range r.migrations {
+		if m.Version == 0 {
+			return nil, errors.New("migration version must be positive")
+		}
+		if i > 0 && r.migrations[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicated migration version %d", m.Version)
+		}
+		if len(m.Up) == 0 {
+			return nil, fmt.Errorf("migration %d: no up statements", m.Version)
+		}
+	}
+	return r, nil
+}
+
+// Cluster returns the cluster set by WithCluster.
+func (r *Runner) Cluster() string {
+	return r.cluster
+}
+
+// Status returns states of all defined migrations, and fails on migrations applied but not defined.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Status, len(r.migrations))
+	for i, m := range r.migrations {
+		ret[i] = Status{Version: m.Version, Description: m.Description}
+		rec, ok := applied[m.Version]
+		if !ok {
+			continue
+		}
+		checksum, err := m.Checksum()
+		if err != nil {
+			return nil, err
+		}
+		ret[i].Applied = true
+		ret[i].AppliedAt = rec.Time
+		ret[i].Drifted = rec.Checksum != checksum
+	}
+	return ret, nil
+}
+
+// Up applies all pending migrations.
+func (r *Runner) Up(ctx context.Context) error {
+	return r.UpTo(ctx, ^uint64(0))
+}
+
+// UpTo applies pending migrations whose versions are not greater than version.
+// Before applying anything, it fails with ErrChecksumMismatch if any applied migration drifted.
+func (r *Runner) UpTo(ctx context.Context, version uint64) error {
+	status, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range status {
+		if s.Drifted {
+			return fmt.Errorf("migration %d: %w", s.Version, ErrChecksumMismatch)
+		}
+	}
+	for i, m := range r.migrations {
+		if m.Version > version {
+			break
+		}
+		if status[i].Applied {
+			continue
+		}
+		if err := r.apply(ctx, m, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down rolls back the latest applied migration, if any.
+func (r *Runner) Down(ctx context.Context) error {
+	status, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(status) - 1; i >= 0; i-- {
+		if status[i].Applied {
+			return r.apply(ctx, r.migrations[i], false)
+		}
+	}
+	return nil
+}
+
+// DownTo rolls back applied migrations whose versions are greater than version, latest first.
+func (r *Runner) DownTo(ctx context.Context, version uint64) error {
+	status, err := r.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(status) - 1; i >= 0; i-- {
+		if status[i].Version <= version {
+			break
+		}
+		if status[i].Applied {
+			if err := r.apply(ctx, r.migrations[i], false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applied creates the bookkeeping table and reads records of applied migrations.
+func (r *Runner) applied(ctx context.Context) (map[uint64]Record, error) {
+	if err := r.run(ctx, SQL(r.createTable()), r.createTable()); err != nil {
+		return nil, fmt.Errorf("create bookkeeping table: %w", err)
+	}
+	records, err := r.exec.Records(ctx, r.table)
+	if err != nil {
+		if r.dryRun != nil {
+			fmt.Fprintf(r.dryRun, "-- bookkeeping table is not readable, assuming no migrations are applied: %v\n\n", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read bookkeeping table: %w", err)
+	}
+	defined := make(map[uint64]bool, len(r.migrations))
+	for _, m := range r.migrations {
+		defined[m.Version] = true
+	}
+	ret := make(map[uint64]Record)
+	for _, rec := range records {
+		if !rec.Applied {
+			continue
+		}
+		if !defined[rec.Version] {
+			return nil, fmt.Errorf("migration %d: %w", rec.Version, ErrUnknownVersion)
+		}
+		ret[rec.Version] = rec
+	}
+	return ret, nil
+}
+
+func (r *Runner) createTable() string {
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE IF NOT EXISTS ")
+	sb.WriteString(r.table)
+	if r.cluster != "" {
+		sb.WriteString(" ON CLUSTER ")
+		sb.WriteString(r.cluster)
+	}
+	sb.WriteString(" (version UInt64, description String, checksum String, applied UInt8, ts DateTime64(6, 'UTC')) ENGINE = ")
+	sb.WriteString(r.engine)
+	sb.WriteString(" ORDER BY (version, ts)")
+	return sb.String()
+}
+
+// run executes the statement rendered as sql, or prints it in dry-run mode.
+func (r *Runner) run(ctx context.Context, s click.Statement, sql string) error {
+	if r.dryRun == nil {
+		return r.exec.Exec(ctx, sql)
+	}
+	sql, err := click.PrettyString(s)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.dryRun, "%s;\n\n", strings.TrimRight(sql, "\n"))
+	return err
+}
+
+// apply executes Up or Down statements of the migration, and adds a record.
+func (r *Runner) apply(ctx context.Context, m Migration, up bool) error {
+	// Up statements are rendered once, for both the checksum and execution
+	upSQL, err := m.renderUp()
+	if err != nil {
+		return err
+	}
+	raw, err := m.rawUp(upSQL)
+	if err != nil {
+		return err
+	}
+	direction, statements, rendered := "up", m.Up, upSQL
+	if !up {
+		direction, statements = "down", m.Down
+		if len(statements) == 0 {
+			return fmt.Errorf("migration %d: %w", m.Version, ErrIrreversible)
+		}
+		if rendered, err = m.renderDown(); err != nil {
+			return err
+		}
+	}
+	if r.dryRun != nil {
+		fmt.Fprintf(r.dryRun, "-- %d %s (%s)\n", m.Version, m.Description, direction)
+	}
+	for i, s := range statements {
+		if err := r.run(ctx, s, rendered[i]); err != nil {
+			return fmt.Errorf("migration %d: %s statement %d: %w", m.Version, direction, i, err)
+		}
+	}
+	rec := Record{
+		Version:     m.Version,
+		Description: m.Description,
+		Checksum:    checksum(raw),
+		Applied:     up,
+		Time:        r.now().UTC(),
+	}
+	if r.dryRun != nil {
+		return nil
+	}
+	if err := r.exec.AddRecord(ctx, r.table, rec); err != nil {
+		return fmt.Errorf("migration %d: add record: %w", m.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/clickhttp"
+)
+
+func testMigrations() []Migration {
+	return []Migration{
+		{
+			Version:     2,
+			Description: "add email",
+			Up: []click.Statement{
+				click.AlterTable("users").AddColumn(click.ColumnDefinition{Name: "email", Type: "String"}),
+			},
+			Down: []click.Statement{
+				click.AlterTable("users").DropColumn("email"),
+			},
+		},
+		{
+			Version:     1,
+			Description: "create users",
+			Up: []click.Statement{
+				SQL("CREATE TABLE users (id UInt64) ENGINE = MergeTree ORDER BY id"),
+			},
+			Down: []click.Statement{
+				SQL("DROP TABLE users"),
+			},
+		},
+	}
+}
+
+// executed returns statements except creation of the bookkeeping table.
+func executed(e *MemoryExecutor) []string {
+	var ret []string
+	for _, s := range e.Statements {
+		if !strings.HasPrefix(s, "CREATE TABLE IF NOT EXISTS schema_migrations") {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+func assertStatements(t *testing.T, e *MemoryExecutor, want ...string) {
+	t.Helper()
+	got := executed(e)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("statements:\n%v\nexpected:\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	e.Statements = nil
+}
+
+func TestRunner_UpDown(t *testing.T) {
+	ctx := context.Background()
+	e := &MemoryExecutor{}
+	r, err := New(e, testMigrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpTo(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "CREATE TABLE users (id UInt64) ENGINE = MergeTree ORDER BY id")
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "ALTER TABLE users ADD COLUMN email String")
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e)
+
+	status, err := r.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || !status[0].Applied || !status[1].Applied || status[0].Version != 1 || status[1].Drifted {
+		t.Fatalf("status: %+v", status)
+	}
+
+	if err := r.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "ALTER TABLE users DROP COLUMN email")
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "ALTER TABLE users ADD COLUMN email String")
+	if err := r.DownTo(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "ALTER TABLE users DROP COLUMN email", "DROP TABLE users")
+	if err := r.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e)
+}
+
+func TestRunner_Drift(t *testing.T) {
+	ctx := context.Background()
+	e := &MemoryExecutor{}
+	r, err := New(e, testMigrations()[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := testMigrations()
+	modified[1].Up = []click.Statement{SQL("CREATE TABLE users (id UInt32) ENGINE = MergeTree ORDER BY id")}
+	r, err = New(e, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Statements = nil
+	if err := r.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	assertStatements(t, e)
+	status, err := r.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Drifted || status[1].Applied {
+		t.Fatalf("status: %+v", status)
+	}
+
+	r, err = New(e, testMigrations()[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected unknown version, got %v", err)
+	}
+}
+
+func TestRunner_Failure(t *testing.T) {
+	ctx := context.Background()
+	e := &MemoryExecutor{
+		Fail: func(query string) error {
+			if strings.HasPrefix(query, "ALTER") {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}
+	ms := testMigrations()
+	ms = append(ms, Migration{Version: 3, Description: "irreversible", Up: []click.Statement{SQL("SELECT 1")}})
+	r, err := New(e, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); err == nil || !strings.Contains(err.Error(), "migration 2: up statement 0: boom") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status, err := r.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Applied || status[1].Applied || status[2].Applied {
+		t.Fatalf("status: %+v", status)
+	}
+
+	e.Fail = nil
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Down(ctx); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("expected irreversible, got %v", err)
+	}
+}
+
+// countingStatement renders the number of times it is rendered.
+type countingStatement struct {
+	n int
+}
+
+func (s *countingStatement) BuildString() (string, error) {
+	s.n++
+	return fmt.Sprintf("SELECT %d", s.n), nil
+}
+
+func TestRunner_RenderOnce(t *testing.T) {
+	ctx := context.Background()
+	e := &MemoryExecutor{}
+	s := &countingStatement{}
+	m := Migration{Version: 1, Up: []click.Statement{s}}
+	r, err := New(e, []Migration{m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "SELECT 1")
+	if s.n != 1 {
+		t.Fatalf("statement is rendered %d times", s.n)
+	}
+	records, err := e.Records(ctx, "schema_migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Checksum != checksum([]string{"SELECT 1"}) {
+		t.Fatalf("records: %+v", records)
+	}
+}
+
+func TestMigration_Checksum_Policy(t *testing.T) {
+	m := Migration{Version: 1, Up: []click.Statement{click.Select(click.Column("a")).From(click.Table("events"))}}
+	before, err := m.Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := click.DefaultPolicy
+	click.DefaultPolicy = click.NewPolicy()
+	defer func() { click.DefaultPolicy = old }()
+	click.RegisterPolicy(click.Table("events"), click.Equal(click.Column("tenant_id"), click.LiteralExpression(1)))
+
+	after, err := m.Checksum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after != before {
+		t.Fatal("checksum depends on DefaultPolicy")
+	}
+	ctx := context.Background()
+	e := &MemoryExecutor{}
+	r, err := New(e, []Migration{m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertStatements(t, e, "SELECT a FROM events WHERE (tenant_id = 1)")
+	records, err := e.Records(ctx, "schema_migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Checksum != before {
+		t.Fatalf("records: %+v", records)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	up := []click.Statement{SQL("SELECT 1")}
+	for name, ms := range map[string][]Migration{
+		"zero version":  {{Version: 0, Up: up}},
+		"duplicated":    {{Version: 1, Up: up}, {Version: 1, Up: up}},
+		"no statements": {{Version: 1}},
+	} {
+		if _, err := New(&MemoryExecutor{}, ms); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRunner_DryRun(t *testing.T) {
+	var out bytes.Buffer
+	e := &MemoryExecutor{}
+	r, err := New(e, testMigrations(), WithDryRun(&out), WithTable("db.migrations"), WithCluster("main"),
+		WithEngine("ReplicatedMergeTree"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Statements) != 0 {
+		t.Fatalf("statements are executed in dry-run mode: %v", e.Statements)
+	}
+	expected := "CREATE TABLE IF NOT EXISTS db.migrations ON CLUSTER main (version UInt64, description String, checksum String, applied UInt8, ts DateTime64(6, 'UTC')) ENGINE = ReplicatedMergeTree ORDER BY (version, ts);\n\n" +
+		"-- 1 create users (up)\n" +
+		"CREATE TABLE users (id UInt64) ENGINE = MergeTree ORDER BY id;\n\n" +
+		"-- 2 add email (up)\n" +
+		"ALTER TABLE users\n\tADD COLUMN email String;\n\n"
+	if out.String() != expected {
+		t.Fatalf("dry-run output:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+	records, _ := e.Records(context.Background(), "db.migrations")
+	if len(records) != 0 {
+		t.Fatalf("records are written in dry-run mode: %v", records)
+	}
+}
+
+func TestHTTPExecutor(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if q := r.URL.Query().Get("query"); q != "" {
+			queries = append(queries, q+"\n"+string(body))
+			return
+		}
+		queries = append(queries, string(body))
+		if strings.HasPrefix(string(body), "SELECT") {
+			_, _ = w.Write([]byte("version\tlast_description\tlast_checksum\tlast_applied\tlast_ts\n" +
+				"UInt64\tString\tString\tUInt8\tDateTime64(6, 'UTC')\n" +
+				"1\tcreate users\tabc\t1\t2024-01-02 03:04:05.123456\n"))
+		}
+	}))
+	defer srv.Close()
+	c, err := clickhttp.New(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := HTTPExecutor{Client: c}
+	ctx := context.Background()
+
+	records, err := e.Records(ctx, "schema_migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Record{
+		Version:     1,
+		Description: "create users",
+		Checksum:    "abc",
+		Applied:     true,
+		Time:        time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC),
+	}
+	if len(records) != 1 || records[0].Version != want.Version || records[0].Description != want.Description ||
+		records[0].Checksum != want.Checksum || !records[0].Applied || !records[0].Time.Equal(want.Time) {
+		t.Fatalf("records: %+v", records)
+	}
+
+	want.Applied = false
+	if err := e.AddRecord(ctx, "schema_migrations", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Exec(ctx, "DROP TABLE users"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"SELECT version, argMax(description, ts) AS last_description, argMax(checksum, ts) AS last_checksum, argMax(applied, ts) AS last_applied, max(ts) AS last_ts FROM schema_migrations GROUP BY version ORDER BY version FORMAT TabSeparatedWithNamesAndTypes",
+		"INSERT INTO schema_migrations (version, description, checksum, applied, ts) FORMAT JSONEachRow\n" +
+			`{"applied":0,"checksum":"abc","description":"create users","ts":"2024-01-02 03:04:05.123456","version":1}` + "\n",
+		"DROP TABLE users",
+	}
+	if strings.Join(queries, "\n---\n") != strings.Join(expected, "\n---\n") {
+		t.Fatalf("queries:\n%s", strings.Join(queries, "\n---\n"))
+	}
+}
@@ -0,0 +1,194 @@
+package click
+
+import (
+	"errors"
+	"strings"
+)
+
+// DeleteFrom creates a lightweight DELETE query, which marks rows as deleted instead of rewriting data parts.
+// WHERE clause is required, unless AllowEmptyWhere is called.
+// See https://clickhouse.com/docs/sql-reference/statements/delete
+func DeleteFrom(table Table) *DeleteBuilder {
+	return &DeleteBuilder{
+		mutation: mutation{table: table},
+	}
+}
+
+// DeleteBuilder implements builder pattern for constructing lightweight DELETE SQLs.
+type DeleteBuilder struct {
+	mutation
+}
+
+func (b *DeleteBuilder) OnCluster(cluster string) *DeleteBuilder {
+	b.cluster = cluster
+	return b
+}
+
+// InPartition limits the deletion to the partition.
+func (b *DeleteBuilder) InPartition(partition Expression) *DeleteBuilder {
+	b.partition = partition
+	return b
+}
+
+// Where sets the predicate of deleted rows. It shares Expression types with SelectBuilder.Where.
+func (b *DeleteBuilder) Where(where Expression) *DeleteBuilder {
+	b.where = where
+	return b
+}
+
+// AndWhere appends a predicate to WHERE clause with AND. If WHERE clause is absent, it is equivalent to Where.
+func (b *DeleteBuilder) AndWhere(where Expression) *DeleteBuilder {
+	b.where = andExpressions(b.where, where)
+	return b
+}
+
+// AllowEmptyWhere allows deleting all rows, when WHERE clause is absent or always true.
+func (b *DeleteBuilder) AllowEmptyWhere() *DeleteBuilder {
+	b.allowAll = true
+	return b
+}
+
+// Setting adds a setting to SETTINGS clause, e.g. `Setting(SettingLightweightDeletesSync, 2)`.
+func (b *DeleteBuilder) Setting(name string, value any) *DeleteBuilder {
+	b.settings = b.settings.set(name, value)
+	return b
+}
+
+func (b *DeleteBuilder) BuildString() (string, error) {
+	where, err := b.checkWhere()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("DELETE FROM ")
+	sb.WriteString(string(b.table))
+	b.writeCluster(&sb)
+	if b.partition != nil {
+		sb.WriteString(" IN PARTITION ")
+		sb.WriteString(b.partition.Expression())
+	}
+	sb.WriteString(" WHERE ")
+	sb.WriteString(where.Expression())
+	return b.finish(&sb)
+}
+
+// AlterUpdate creates an `ALTER TABLE ... UPDATE` mutation query.
+// WHERE clause is required, unless AllowEmptyWhere is called.
+// See https://clickhouse.com/docs/sql-reference/statements/alter/update
+func AlterUpdate(table Table) *AlterUpdateBuilder {
+	return &AlterUpdateBuilder{
+		mutation: mutation{table: table},
+	}
+}
+
+// AlterUpdateBuilder implements builder pattern for constructing `ALTER TABLE ... UPDATE` SQLs.
+type AlterUpdateBuilder struct {
+	mutation
+	set []Assignment
+}
+
+func (b *AlterUpdateBuilder) OnCluster(cluster string) *AlterUpdateBuilder {
+	b.cluster = cluster
+	return b
+}
+
+// InPartition limits the mutation to the partition.
+func (b *AlterUpdateBuilder) InPartition(partition Expression) *AlterUpdateBuilder {
+	b.partition = partition
+	return b
+}
+
+// Set assigns a new value to the column. Values may refer to other columns, e.g. `Set("b", Column("a"))`.
+func (b *AlterUpdateBuilder) Set(column Column, value Expression) *AlterUpdateBuilder {
+	b.set = append(append([]Assignment(nil), b.set...), Set(column, value))
+	return b
+}
+
+// Where sets the predicate of updated rows. It shares Expression types with SelectBuilder.Where.
+func (b *AlterUpdateBuilder) Where(where Expression) *AlterUpdateBuilder {
+	b.where = where
+	return b
+}
+
+// AndWhere appends a predicate to WHERE clause with AND. If WHERE clause is absent, it is equivalent to Where.
+func (b *AlterUpdateBuilder) AndWhere(where Expression) *AlterUpdateBuilder {
+	b.where = andExpressions(b.where, where)
+	return b
+}
+
+// AllowEmptyWhere allows updating all rows, when WHERE clause is absent or always true.
+func (b *AlterUpdateBuilder) AllowEmptyWhere() *AlterUpdateBuilder {
+	b.allowAll = true
+	return b
+}
+
+// Setting adds a setting to SETTINGS clause, e.g. `Setting(SettingMutationsSync, 2)`.
+func (b *AlterUpdateBuilder) Setting(name string, value any) *AlterUpdateBuilder {
+	b.settings = b.settings.set(name, value)
+	return b
+}
+
+func (b *AlterUpdateBuilder) BuildString() (string, error) {
+	where, err := b.checkWhere()
+	if err != nil {
+		return "", err
+	}
+	cmd, err := updateCommand(b.set, b.partition, where)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("ALTER TABLE ")
+	sb.WriteString(string(b.table))
+	b.writeCluster(&sb)
+	sb.WriteByte(' ')
+	sb.WriteString(cmd)
+	return b.finish(&sb)
+}
+
+// mutation is common parts of DELETE and UPDATE queries.
+type mutation struct {
+	table     Table
+	cluster   string
+	partition Expression
+	where     Expression
+	allowAll  bool
+	settings  querySettings
+}
+
+// checkWhere returns the WHERE predicate, refusing predicates matching all rows unless allowed.
+func (m *mutation) checkWhere() (Expression, error) {
+	if m.table == "" {
+		return nil, errors.New("no table")
+	}
+	return mutationWhere(m.where, m.allowAll)
+}
+
+// mutationWhere returns the predicate of a mutation, refusing predicates matching all rows unless allowAll.
+func mutationWhere(where Expression, allowAll bool) (Expression, error) {
+	if v, ok := constantBool(Simplify(where)); where == nil || (ok && v) {
+		if !allowAll {
+			return nil, errors.New("WHERE clause is empty or always true, call AllowEmptyWhere to affect all rows")
+		}
+		if where == nil {
+			return True(), nil
+		}
+	}
+	return where, nil
+}
+
+func (m *mutation) writeCluster(sb *strings.Builder) {
+	if m.cluster != "" {
+		sb.WriteString(" ON CLUSTER ")
+		sb.WriteString(m.cluster)
+	}
+}
+
+func (m *mutation) finish(sb *strings.Builder) (string, error) {
+	settings, err := m.settings.clause()
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(settings)
+	return sb.String(), nil
+}
@@ -0,0 +1,71 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestDeleteFrom(t *testing.T) {
+	retention := LessThan(Column("ts"), Ago(Interval(90, UnitDay)))
+	tests := []struct {
+		name string
+		b    interface{ BuildString() (string, error) }
+		want string
+	}{
+		{
+			name: "delete",
+			b:    DeleteFrom("events").Where(retention),
+			want: "DELETE FROM events WHERE (ts < (now() - INTERVAL 90 DAY))",
+		},
+		{
+			name: "delete with settings",
+			b: DeleteFrom("db.events").OnCluster("main").InPartition(LiteralExpression(202401)).
+				Where(retention).AndWhere(Equal(Column("tenant"), LiteralExpressionQuoted("t1"))).
+				Setting(SettingLightweightDeletesSync, 2).Setting("comment", "it's retention"),
+			want: "DELETE FROM db.events ON CLUSTER main IN PARTITION 202401 WHERE ((ts < (now() - INTERVAL 90 DAY)) AND (tenant = 't1')) " +
+				"SETTINGS lightweight_deletes_sync = 2, comment = 'it\\'s retention'",
+		},
+		{
+			name: "delete all",
+			b:    DeleteFrom("events").AllowEmptyWhere(),
+			want: "DELETE FROM events WHERE true",
+		},
+		{
+			name: "update",
+			b: AlterUpdate("events").Set("email", LiteralExpressionQuoted("")).Set("name", Fn("concat", Column("name"), LiteralExpressionQuoted("_"))).
+				Where(Equal(Column("user_id"), LiteralExpression(42))).
+				Setting(SettingMutationsSync, 1).Setting(SettingMutationsSync, 2),
+			want: "ALTER TABLE events UPDATE email = '', name = concat(name, '_') WHERE (user_id = 42) SETTINGS mutations_sync = 2",
+		},
+		{
+			name: "update in partition",
+			b:    AlterUpdate("events").OnCluster("main").InPartition(PartitionID("all")).Set("a", LiteralExpression(0)).Where(Column("b")),
+			want: "ALTER TABLE events ON CLUSTER main UPDATE a = 0 IN PARTITION ID 'all' WHERE b",
+		},
+		{
+			name: "alter table with settings",
+			b:    AlterTable("events").Delete(retention).Setting(SettingMutationsSync, true),
+			want: "ALTER TABLE events DELETE WHERE (ts < (now() - INTERVAL 90 DAY)) SETTINGS mutations_sync = 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := must(tt.b.BuildString()); got != tt.want {
+				t.Errorf("BuildString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMutations_EmptyWhereGuard(t *testing.T) {
+	for name, b := range map[string]interface{ BuildString() (string, error) }{
+		"delete without where":    DeleteFrom("events"),
+		"delete with always true": DeleteFrom("events").Where(And(Equal(LiteralExpression(1), LiteralExpression(1)))),
+		"update without where":    AlterUpdate("events").Set("a", LiteralExpression(1)),
+		"update without set":      AlterUpdate("events").Where(Column("b")),
+		"invalid setting":         DeleteFrom("events").Where(Column("a")).Setting("x", []int{1}),
+	} {
+		if _, err := b.BuildString(); err == nil {
+			t.Errorf("%s: expected error, got nothing", name)
+		}
+	}
+}
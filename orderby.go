@@ -1,7 +1,5 @@
 package click
 
-import "strings"
-
 // OrderByExpression is an Expression in ORDER BY clause.
 // Any Expression that can be selected may implement OrderByExpression,
 // customizing how it will look like when being ordered by.
@@ -28,19 +26,21 @@ func (o orderByExpression) Expression() string {
 }
 
 func (o orderByExpression) OrderByExpression() string {
-	var sb strings.Builder
-	sb.WriteString(o.expression.Expression())
+	return o.expression.Expression() + o.directionSuffix()
+}
+
+func (o orderByExpression) directionSuffix() string {
 	switch o.orderDirection {
 	case OrderDefault:
 		// default order, add nothing
+		return ""
 	case OrderAscending:
-		sb.WriteString(" ASC")
+		return " ASC"
 	case OrderDescending:
-		sb.WriteString(" DESC")
+		return " DESC"
 	default:
 		panic("invalid order direction")
 	}
-	return sb.String()
 }
 
 func Desc(v Expression) OrderByExpression {
@@ -0,0 +1,226 @@
+package click
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// KeysetPaginator implements keyset (cursor) pagination over a SelectBuilder.
+// Instead of skipping rows with OFFSET, every page continues right after the last row of the previous page,
+// filtering with the ORDER BY keys of that row. The keys must identify rows uniquely.
+// See https://use-the-index-luke.com/no-offset
+type KeysetPaginator struct {
+	query    *SelectBuilder
+	keys     []orderByExpression
+	pageSize int
+}
+
+// NewKeysetPaginator creates a paginator of the query. Keys are the ORDER BY keys, which may be wrapped with Asc or Desc.
+// Keys without direction are ascending. ORDER BY, LIMIT and OFFSET clauses of the query are replaced in generated pages.
+// The query is cloned, later modifications on it do not affect the paginator.
+func NewKeysetPaginator(query *SelectBuilder, pageSize int, keys ...Expression) (*KeysetPaginator, error) {
+	if query == nil {
+		return nil, errors.New("nil query")
+	}
+	if pageSize <= 0 {
+		return nil, errors.New("page size must be positive")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys")
+	}
+	p := &KeysetPaginator{
+		query:    query.Clone(),
+		keys:     make([]orderByExpression, len(keys)),
+		pageSize: pageSize,
+	}
+	for i, k := range keys {
+		if k == nil {
+			return nil, fmt.Errorf("nil key at index %d", i)
+		}
+		if o, ok := k.(orderByExpression); ok {
+			p.keys[i] = o
+		} else {
+			p.keys[i] = orderByExpression{expression: k}
+		}
+	}
+	return p, nil
+}
+
+// FirstPage returns the query of the first page.
+func (p *KeysetPaginator) FirstPage() *SelectBuilder {
+	orderBy := make([]Expression, len(p.keys))
+	for i := range p.keys {
+		orderBy[i] = p.keys[i]
+	}
+	return p.query.Clone().ReplaceOrderBy(orderBy...).Limit(p.pageSize).ClearOffset()
+}
+
+// NextPage returns the query of the page after the row whose key values are lastKeys, in the same order as keys.
+func (p *KeysetPaginator) NextPage(lastKeys ...any) (*SelectBuilder, error) {
+	if len(lastKeys) != len(p.keys) {
+		return nil, fmt.Errorf("expected %d key values, got %d", len(p.keys), len(lastKeys))
+	}
+	values := make([]Expression, len(lastKeys))
+	for i, v := range lastKeys {
+		lit, err := keyLiteral(v)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", p.keys[i].Expression(), err)
+		}
+		values[i] = lit
+	}
+	return p.FirstPage().AndWhere(p.after(values)), nil
+}
+
+// PageAfter returns the query of the page after the cursor. Empty cursor means the first page.
+func (p *KeysetPaginator) PageAfter(cursor string) (*SelectBuilder, error) {
+	if cursor == "" {
+		return p.FirstPage(), nil
+	}
+	lastKeys, err := p.DecodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	return p.NextPage(lastKeys...)
+}
+
+// after builds the predicate selecting rows after the given key values.
+// If all keys share the same direction, it is a single tuple comparison, e.g. `((a, b) > (1, 2))`.
+// Otherwise, it is expanded to `((a > 1) OR ((a = 1) AND (b < 2)))`.
+func (p *KeysetPaginator) after(values []Expression) Expression {
+	sameDirection := true
+	for i := range p.keys {
+		if p.keys[i].descending() != p.keys[0].descending() {
+			sameDirection = false
+			break
+		}
+	}
+	if sameDirection {
+		if len(p.keys) == 1 {
+			return p.keys[0].after(values[0])
+		}
+		keys := make(Tuple, len(p.keys))
+		for i := range p.keys {
+			keys[i] = p.keys[i].expression
+		}
+		return orderByExpression{expression: keys, orderDirection: p.keys[0].orderDirection}.after(Tuple(values))
+	}
+	alternatives := make([]Expression, len(p.keys))
+	for i := range p.keys {
+		conditions := make([]Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, Equal(p.keys[j].expression, values[j]))
+		}
+		conditions = append(conditions, p.keys[i].after(values[i]))
+		alternatives[i] = And(conditions...)
+	}
+	return Or(alternatives...)
+}
+
+func (o orderByExpression) descending() bool {
+	return o.orderDirection == OrderDescending
+}
+
+func (o orderByExpression) after(v Expression) Expression {
+	if o.descending() {
+		return LessThan(o.expression, v)
+	}
+	return GreaterThan(o.expression, v)
+}
+
+// Cursor encodes key values of the last row in current page into an opaque URL-safe token.
+// Key values must be strings, numbers, booleans or timestamps. time.Time values are encoded with nanoseconds,
+// other timestamps are encoded as Unix seconds, the same as LiteralExpression.
+func (p *KeysetPaginator) Cursor(lastKeys ...any) (string, error) {
+	if len(lastKeys) != len(p.keys) {
+		return "", fmt.Errorf("expected %d key values, got %d", len(p.keys), len(lastKeys))
+	}
+	values := make([]any, len(lastKeys))
+	for i, v := range lastKeys {
+		if _, err := keyLiteral(v); err != nil {
+			return "", fmt.Errorf("key %s: %w", p.keys[i].Expression(), err)
+		}
+		if t, ok := v.(time.Time); ok {
+			v = cursorTime{Time: t.UTC().Format(time.RFC3339Nano)}
+		} else if ts, ok := v.(interface{ Unix() int64 }); ok {
+			v = ts.Unix()
+		}
+		values[i] = v
+	}
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// cursorTime is a time.Time key value in cursors, which is distinguished from strings.
+type cursorTime struct {
+	Time string `json:"t"`
+}
+
+// DecodeCursor decodes key values from a token created by Cursor.
+// Numbers are decoded as json.Number to preserve precision, and time.Time values are decoded in UTC.
+func (p *KeysetPaginator) DecodeCursor(cursor string) ([]any, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var values []any
+	if err := d.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid cursor: %w", err)
+	}
+	if len(values) != len(p.keys) {
+		return nil, fmt.Errorf("invalid cursor: expected %d key values, got %d", len(p.keys), len(values))
+	}
+	for i, v := range values {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		ts, ok := m["t"].(string)
+		if !ok || len(m) != 1 {
+			return nil, fmt.Errorf("invalid cursor: unknown key value %v", v)
+		}
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		values[i] = t
+	}
+	return values, nil
+}
+
+// keyLiteral converts a key value to literal expression, quoting strings.
+// Timestamps are Unix seconds, except time.Time values with fractional seconds, which are DateTime64.
+func keyLiteral(v any) (Expression, error) {
+	if v == nil {
+		return nil, errors.New("NULL key value is not supported")
+	}
+	if n, ok := v.(json.Number); ok {
+		return LiteralExpression(n), nil
+	}
+	if t, ok := v.(time.Time); ok && t.Nanosecond() != 0 {
+		return DateTime64(t), nil
+	}
+	if ts, ok := v.(interface{ Unix() int64 }); ok {
+		return LiteralExpression(ts.Unix()), nil
+	}
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.String:
+		return LiteralExpressionQuoted(fmt.Sprint(v)), nil
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return LiteralExpression(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported key value type %T", v)
+	}
+}
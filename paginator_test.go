@@ -0,0 +1,92 @@
+package click
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeysetPaginator_SameDirection(t *testing.T) {
+	q := Select(Column("ts"), Column("id")).From(Table("events")).
+		Where(Equal(Column("user"), LiteralExpressionQuoted("u1"))).
+		OrderBy(Column("whatever")).Offset(100)
+	p, err := NewKeysetPaginator(q, 10, Desc(Column("ts")), Desc(Column("id")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := must(p.FirstPage().BuildString()); v != "SELECT ts, id FROM events WHERE (user = 'u1') ORDER BY ts DESC, id DESC LIMIT 10" {
+		t.Fatal(v)
+	}
+	next := must(p.NextPage(time.Unix(1700000000, 0), "abc"))
+	if v := must(next.BuildString()); v != "SELECT ts, id FROM events WHERE ((user = 'u1') AND ((ts, id) < (1700000000, 'abc'))) ORDER BY ts DESC, id DESC LIMIT 10" {
+		t.Fatal(v)
+	}
+}
+
+func TestKeysetPaginator_SingleKey(t *testing.T) {
+	p := must(NewKeysetPaginator(Select(Column("id")).From(Table("tbl")), 5, Column("id")))
+	if v := must(must(p.NextPage(42)).BuildString()); v != "SELECT id FROM tbl WHERE (id > 42) ORDER BY id LIMIT 5" {
+		t.Fatal(v)
+	}
+}
+
+func TestKeysetPaginator_MixedDirection(t *testing.T) {
+	p := must(NewKeysetPaginator(Select(Column("a")).From(Table("tbl")), 5, Asc(Column("a")), Desc(Column("b")), Column("c")))
+	v := must(must(p.NextPage(1, 2, 3)).BuildString())
+	if v != "SELECT a FROM tbl WHERE ((a > 1) OR ((a = 1) AND (b < 2)) OR ((a = 1) AND (b = 2) AND (c > 3))) ORDER BY a ASC, b DESC, c LIMIT 5" {
+		t.Fatal(v)
+	}
+}
+
+func TestKeysetPaginator_Cursor(t *testing.T) {
+	p := must(NewKeysetPaginator(Select(Column("id")).From(Table("tbl")), 5, Column("name"), Column("id")))
+	cursor := must(p.Cursor("it's", uint64(18446744073709551615)))
+	v := must(must(p.PageAfter(cursor)).BuildString())
+	if v != `SELECT id FROM tbl WHERE ((name, id) > ('it\'s', 18446744073709551615)) ORDER BY name, id LIMIT 5` {
+		t.Fatal(v)
+	}
+	if v := must(must(p.PageAfter("")).BuildString()); v != "SELECT id FROM tbl ORDER BY name, id LIMIT 5" {
+		t.Fatal(v)
+	}
+}
+
+func TestKeysetPaginator_CursorTime(t *testing.T) {
+	p := must(NewKeysetPaginator(Select(Column("id")).From(Table("tbl")), 5, Column("ts"), Column("name")))
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.FixedZone("UTC+8", 8*3600))
+	cursor := must(p.Cursor(ts, "2024-01-02T03:04:05Z"))
+	keys := must(p.DecodeCursor(cursor))
+	if got, ok := keys[0].(time.Time); !ok || !got.Equal(ts) || keys[1] != "2024-01-02T03:04:05Z" {
+		t.Fatalf("DecodeCursor() = %#v", keys)
+	}
+	v := must(must(p.PageAfter(cursor)).BuildString())
+	if v != "SELECT id FROM tbl WHERE ((ts, name) > (toDateTime64('2024-01-01 19:04:05.123456789', 9, 'UTC'), '2024-01-02T03:04:05Z')) ORDER BY ts, name LIMIT 5" {
+		t.Fatal(v)
+	}
+	v = must(must(p.PageAfter(must(p.Cursor(time.Unix(1700000000, 0), "a")))).BuildString())
+	if v != "SELECT id FROM tbl WHERE ((ts, name) > (1700000000, 'a')) ORDER BY ts, name LIMIT 5" {
+		t.Fatal(v)
+	}
+}
+
+func TestKeysetPaginator_Errors(t *testing.T) {
+	q := Select(Column("id")).From(Table("tbl"))
+	if _, err := NewKeysetPaginator(q, 0, Column("id")); err == nil {
+		t.Fatal("expected error on invalid page size")
+	}
+	if _, err := NewKeysetPaginator(q, 1); err == nil {
+		t.Fatal("expected error on empty keys")
+	}
+	p := must(NewKeysetPaginator(q, 1, Column("id")))
+	if _, err := p.NextPage(1, 2); err == nil {
+		t.Fatal("expected error on key count mismatch")
+	}
+	if _, err := p.NextPage(nil); err == nil {
+		t.Fatal("expected error on NULL key")
+	}
+	if _, err := p.PageAfter("!!!"); err == nil {
+		t.Fatal("expected error on invalid cursor")
+	}
+	other := must(NewKeysetPaginator(q, 1, Column("a"), Column("b")))
+	if _, err := p.PageAfter(must(other.Cursor(1, 2))); err == nil {
+		t.Fatal("expected error on cursor of another paginator")
+	}
+}
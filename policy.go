@@ -0,0 +1,269 @@
+package click
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"github.com/keuin/click/internal/ident"
+)
+
+// Policy is a set of mandatory predicates on tables, i.e. row-level security.
+// Every SELECT reading a table directly gets the predicates of that table appended to its WHERE or PREWHERE clause
+// with AND, including nested queries in FROM clause.
+// Queries reading a protected table in a way that predicates can not be injected, e.g. with remote() or merge(),
+// are rejected.
+//
+// Predicates are rendered verbatim in every query. To filter by a per-request value, like a tenant ID,
+// use QueryParameter and pass the value with the query, e.g. `Equal(Column("tenant_id"), QueryParameter("tenant", "UInt64"))`.
+//
+// A Policy is safe for concurrent use.
+type Policy struct {
+	mu    sync.RWMutex
+	rules map[Table][]policyRule
+}
+
+type policyRule struct {
+	predicate Expression
+	prewhere  bool
+}
+
+// NewPolicy creates an empty policy.
+func NewPolicy() *Policy {
+	return &Policy{}
+}
+
+// DefaultPolicy is applied by SelectBuilder.Build and SelectBuilder.BuildString.
+var DefaultPolicy = NewPolicy()
+
+// RegisterPolicy registers a WHERE predicate on the table in DefaultPolicy.
+func RegisterPolicy(table Table, predicate Expression) {
+	DefaultPolicy.Where(table, predicate)
+}
+
+// RegisterPrewherePolicy registers a PREWHERE predicate on the table in DefaultPolicy.
+func RegisterPrewherePolicy(table Table, predicate Expression) {
+	DefaultPolicy.Prewhere(table, predicate)
+}
+
+// Where registers a predicate injected into WHERE clause of queries reading the table.
+// Table names without database match the table in any database.
+func (p *Policy) Where(table Table, predicate Expression) *Policy {
+	return p.register(table, policyRule{predicate: predicate})
+}
+
+// Prewhere registers a predicate injected into PREWHERE clause of queries reading the table.
+// Table names without database match the table in any database.
+func (p *Policy) Prewhere(table Table, predicate Expression) *Policy {
+	return p.register(table, policyRule{predicate: predicate, prewhere: true})
+}
+
+func (p *Policy) register(table Table, rule policyRule) *Policy {
+	if table == "" {
+		panic("empty table in policy")
+	}
+	if rule.predicate == nil {
+		panic("nil predicate in policy")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rules == nil {
+		p.rules = make(map[Table][]policyRule)
+	}
+	p.rules[table] = append(p.rules[table], rule)
+	return p
+}
+
+// Apply returns a copy of the query with predicates injected. The query itself is not modified.
+// Predicates already present in the clause are not injected again, so applying a policy is idempotent.
+func (p *Policy) Apply(q *SelectBuilder) (*SelectBuilder, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.apply(q.Clone())
+}
+
+// apply injects predicates into s in place, and into nested queries in FROM clause recursively.
+func (p *Policy) apply(s *SelectBuilder) (*SelectBuilder, error) {
+	var tables []Table
+	from, err := rewriteFrom(s.from, func(f FromExpression) (FromExpression, error) {
+		switch f := f.(type) {
+		case *SelectBuilder:
+			return p.apply(f)
+		case Table:
+			tables = append(tables, f)
+		case tableFunction:
+			if err := p.checkTableFunction(f); err != nil {
+				return nil, err
+			}
+		case tableAlias, fileLikeFunction:
+		default:
+			return nil, fmt.Errorf("policy: unknown FROM source %T may read protected tables", f)
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.from = from
+	for _, t := range tables {
+		for _, rule := range p.rulesOf(t) {
+			if rule.prewhere {
+				s.prewhere = andOnce(s.prewhere, rule.predicate)
+			} else {
+				s.where = andOnce(s.where, rule.predicate)
+			}
+		}
+	}
+	return s, nil
+}
+
+func (p *Policy) rulesOf(t Table) []policyRule {
+	var ret []policyRule
+	for protected, rules := range p.rules {
+		if sameTable(protected, t) {
+			ret = append(ret, rules...)
+		}
+	}
+	return ret
+}
+
+func (p *Policy) empty() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.rules) == 0
+}
+
+// checkTableFunction rejects table functions reading protected tables, bypassing the query on them.
+func (p *Policy) checkTableFunction(f tableFunction) error {
+	tables := make([]Table, 0, len(p.rules))
+	for t := range p.rules {
+		tables = append(tables, t)
+	}
+	return checkTableFunction(f, tables, "policy")
+}
+
+// checkTableFunction rejects table functions like remote() and merge() reading any of the protected tables,
+// since checks of kind, like policy, can not be applied to them. Table functions whose tables can not be resolved,
+// like view() and unknown ones, are rejected as well, unless nothing is protected.
+func checkTableFunction(f tableFunction, protected []Table, kind string) error {
+	if len(protected) == 0 {
+		return nil
+	}
+	bypass := func(t Table) error {
+		return fmt.Errorf("%s: table %s is protected, %s() bypasses the %s", kind, t, f.name, kind)
+	}
+	switch f.name {
+	case "numbers", "numbers_mt", "zeros", "zeros_mt", "generateRandom", "values", "null":
+		// reading no tables
+		return nil
+	case "remote", "remoteSecure", "cluster", "clusterAllReplicas":
+		t, ok := remoteTable(f.args)
+		if !ok {
+			return fmt.Errorf("%s: %s() with non-literal table can not be checked", kind, f.name)
+		}
+		for _, v := range protected {
+			if sameTable(v, t) {
+				return bypass(v)
+			}
+		}
+		return nil
+	case "merge":
+		// merge('regexp') reads tables of the current database, which is unknown
+		var database, pattern string
+		ok := false
+		switch len(f.args) {
+		case 1:
+			pattern, ok = stringLiteral(f.args[0])
+		case 2:
+			var ok1, ok2 bool
+			if database, ok1 = stringLiteral(f.args[0]); !ok1 {
+				database, ok1 = identifierArg(f.args[0])
+			}
+			pattern, ok2 = stringLiteral(f.args[1])
+			ok = ok1 && ok2
+		}
+		if !ok {
+			return fmt.Errorf("%s: %s() with non-literal table regexp can not be checked", kind, f.name)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: merge(): %w", kind, err)
+		}
+		for _, t := range protected {
+			db, name := ident.SplitTable(string(t))
+			if (db == "" || database == "" || db == database) && re.MatchString(name) {
+				return bypass(t)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("%s: table function %s() may read protected tables and can not be checked", kind, f.name)
+	}
+}
+
+// remoteTable returns the table read by remote() and cluster() functions, in forms of
+// `remote('addresses', 'db', 'table')`, `remote('addresses', db.table)` and `remote('addresses', 'db.table')`,
+// followed by optional user, password and sharding key.
+func remoteTable(args []Expression) (Table, bool) {
+	if len(args) < 2 {
+		return "", false
+	}
+	if name, ok := identifierArg(args[1]); ok {
+		return Table(name), true
+	}
+	database, ok := stringLiteral(args[1])
+	if !ok {
+		return "", false
+	}
+	if len(args) >= 3 {
+		if table, ok := stringLiteral(args[2]); ok {
+			return Table(database + "." + table), true
+		}
+	}
+	return Table(database), true
+}
+
+// identifierArg returns the name of an unquoted identifier argument like `db.events`.
+func identifierArg(e Expression) (string, bool) {
+	var s string
+	if c, ok := e.(Column); ok {
+		s = string(c)
+	} else if v, quoted, ok := InspectLiteral(e); ok && !quoted {
+		s, _ = v.(string)
+	}
+	s = unquoteIdent(s)
+	return s, isIdentifier(s)
+}
+
+func stringLiteral(e Expression) (string, bool) {
+	v, quoted, ok := InspectLiteral(e)
+	if !ok || !quoted {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// sameTable reports whether two table names refer to the same table.
+// A name without database matches the table in any database.
+func sameTable(a, b Table) bool {
+	dbA, nameA := ident.SplitTable(string(a))
+	dbB, nameB := ident.SplitTable(string(b))
+	return nameA == nameB && (dbA == "" || dbB == "" || dbA == dbB)
+}
+
+// andOnce is andExpressions, but skips the predicate if it is already a term of the AND concatenation l.
+// Terms are compared structurally, so that the comparison does not depend on how values are rendered.
+func andOnce(l, r Expression) Expression {
+	terms := []Expression{l}
+	if c, ok := l.(concatenatedExpression); ok && c.Op == OpAnd {
+		terms = c.Expr
+	}
+	for _, t := range terms {
+		if t != nil && reflect.DeepEqual(t, r) {
+			return l
+		}
+	}
+	return andExpressions(l, r)
+}
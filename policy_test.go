@@ -0,0 +1,134 @@
+package click
+
+import (
+	"reflect"
+	"testing"
+)
+
+func tenantPolicy() *Policy {
+	return NewPolicy().
+		Where(Table("events"), Equal(Column("tenant_id"), QueryParameter("tenant", "UInt64"))).
+		Prewhere(Table("db.logs"), Equal(Column("tenant_id"), QueryParameter("tenant", "UInt64")))
+}
+
+func TestPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *SelectBuilder
+		want  string
+	}{
+		{
+			name:  "where",
+			query: Select(Count()).From(Table("events")),
+			want:  "SELECT count() FROM events WHERE (tenant_id = {tenant:UInt64})",
+		},
+		{
+			name:  "existing where",
+			query: Select(Count()).From(Table("db.events").As("e")).Where(And(Column("a"), Column("b"))),
+			want:  "SELECT count() FROM db.events AS e WHERE (a AND b AND (tenant_id = {tenant:UInt64}))",
+		},
+		{
+			name:  "prewhere",
+			query: Select(Count()).From(Table("db.logs")).Where(Column("a")),
+			want:  "SELECT count() FROM db.logs PREWHERE (tenant_id = {tenant:UInt64}) WHERE a",
+		},
+		{
+			name:  "other database",
+			query: Select(Count()).From(Table("other.logs")),
+			want:  "SELECT count() FROM other.logs",
+		},
+		{
+			name:  "nested query",
+			query: Select(Count()).From(TableAlias(Select(Column("a")).From(Table("events")), "sub")).Where(Column("a")),
+			want:  "SELECT count() FROM (\nSELECT a FROM events WHERE (tenant_id = {tenant:UInt64})\n) AS sub WHERE a",
+		},
+		{
+			name:  "already filtered",
+			query: Select(Count()).From(Table("events")).Where(Equal(Column("tenant_id"), QueryParameter("tenant", "UInt64"))),
+			want:  "SELECT count() FROM events WHERE (tenant_id = {tenant:UInt64})",
+		},
+	}
+	p := tenantPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := must(tt.query.BuildString())
+			got := must(must(p.Apply(tt.query)).BuildString())
+			if got != tt.want {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+			if v := must(tt.query.BuildString()); v != before {
+				t.Errorf("original query is modified: %s", v)
+			}
+		})
+	}
+}
+
+func TestPolicy_Bypass(t *testing.T) {
+	p := tenantPolicy()
+	for _, from := range []FromExpression{
+		Remote("host:9000", "db", "events"),
+		Cluster("main", "db", "logs"),
+		Merge("db", "^event"),
+		TableAlias(Select(Column("a")).From(Merge("db", "^lo")), "sub"),
+		TableFunction("remote", LiteralExpressionQuoted("host:9000"), LiteralExpression("db.events")),
+		TableFunction("cluster", LiteralExpressionQuoted("main"), Column("events")),
+		TableFunction("remote", LiteralExpressionQuoted("host:9000"), LiteralExpressionQuoted("db.events")),
+		TableFunction("remote", LiteralExpressionQuoted("host:9000")),
+		TableFunction("merge", LiteralExpressionQuoted("^ev")),
+		TableFunction("merge", Column("db"), LiteralExpressionQuoted("^ev")),
+		Merge("", "^ev"),
+		TableFunction("view", LiteralExpression("SELECT * FROM events")),
+		TableFunction("mysql", LiteralExpressionQuoted("host:3306"), LiteralExpressionQuoted("db"), LiteralExpressionQuoted("events")),
+	} {
+		if _, err := p.Apply(Select(Count()).From(from)); err == nil {
+			t.Errorf("expected error on %s", must(from.FromExpression(defaultStyle)))
+		}
+	}
+	for _, from := range []FromExpression{
+		Remote("host:9000", "db", "other"),
+		TableFunction("remote", LiteralExpressionQuoted("host:9000"), LiteralExpression("db.other")),
+		Merge("db", "^other"),
+		Numbers(10),
+	} {
+		if _, err := p.Apply(Select(Count()).From(from)); err != nil {
+			t.Errorf("%s: %v", must(from.FromExpression(defaultStyle)), err)
+		}
+	}
+	// nothing is protected
+	if _, err := NewPolicy().Apply(Select(Count()).From(TableFunction("view", LiteralExpression("SELECT 1")))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultPolicy_Build(t *testing.T) {
+	old := DefaultPolicy
+	DefaultPolicy = NewPolicy()
+	defer func() { DefaultPolicy = old }()
+	RegisterPolicy(Table("events"), Equal(Column("tenant_id"), LiteralExpression(1)))
+
+	q := Select(Count()).From(Table("events"))
+	if v := must(q.BuildString()); v != "SELECT count() FROM events WHERE (tenant_id = 1)" {
+		t.Fatal(v)
+	}
+	sealed := must(q.Build())
+	if v := sealed.String(); v != "SELECT count() FROM events WHERE (tenant_id = 1)" {
+		t.Fatal(v)
+	}
+	if _, err := Select(Count()).From(Merge("", "events")).Build(); err == nil {
+		t.Fatal("expected error on bypassing query")
+	}
+	simple := SimpleQuery{Select: []Expression{Count()}, From: "events"}
+	if v := must(simple.BuildString()); v != "SELECT count() FROM events WHERE (tenant_id = 1)" {
+		t.Fatal(v)
+	}
+}
+
+func TestPolicy_ApplyOnce(t *testing.T) {
+	p := NewPolicy().Where(Table("events"), Equal(Column("tenant_id"), Arg("t1")))
+	q := Select(Count()).From(Table("events")).Where(Equal(Column("tenant_id"), Arg("t1")))
+	got := must(p.Apply(must(p.Apply(q))))
+	query, args := ExpressionArgs(got.where, PlaceholderQuestion)
+	if query != "(tenant_id = ?)" || !reflect.DeepEqual(args, []any{"t1"}) {
+		t.Fatalf("WHERE = %q, args = %v", query, args)
+	}
+}
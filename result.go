@@ -0,0 +1,240 @@
+package click
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/keuin/click/chtype"
+)
+
+// ResultColumn is a column in the result of a query.
+type ResultColumn struct {
+	// Name is the alias, or the name ClickHouse gives to the expression, like `count()` or `plus(a, 1)`.
+	Name string
+	// Type is the inferred type, or the zero Type if unknown.
+	Type chtype.Type
+}
+
+// ResultColumns returns names and types of result columns of the query, without executing it.
+// Names follow As aliases, or the default naming of ClickHouse for unaliased expressions,
+// where operators are named as functions, e.g. `(a + 1)` is named `plus(a, 1)`.
+// Qualified columns keep their qualifiers, like the analyzer of ClickHouse 24.3 and later.
+//
+// Types are inferred with the schema, which may be nil, from types of columns and return types of
+// well-known functions, including aggregate functions with combinators like sumIf and uniqArray.
+// `*` is expanded to all columns of the source, which must be known.
+func (s *SelectBuilder) ResultColumns(schema Schema) ([]ResultColumn, error) {
+	if len(s.selects) == 0 {
+		return nil, errors.New("no selects")
+	}
+	c := typeChecker{schema: schema}
+	return c.resultColumns(c.queryScope(s), s)
+}
+
+func (c *typeChecker) resultColumns(sc *scope, q *SelectBuilder) ([]ResultColumn, error) {
+	var ret []ResultColumn
+	for _, e := range q.selects {
+		if col, ok := e.(Column); ok && isStar(string(col)) {
+			if sc.columns == nil {
+				return nil, fmt.Errorf("cannot expand %s: columns of %s are unknown", col, sc.source)
+			}
+			for _, name := range sc.names {
+				ret = append(ret, ResultColumn{Name: name, Type: sc.columns[name]})
+			}
+			continue
+		}
+		ret = append(ret, ResultColumn{Name: columnName(e), Type: c.typeOf(sc, e)})
+	}
+	return ret, nil
+}
+
+// isStar reports whether the column is `*` or a qualified asterisk like `t.*`.
+func isStar(s string) bool {
+	return s == "*" || strings.HasSuffix(s, ".*")
+}
+
+// binaryFunctions are functions of binary operators, which name result columns.
+var binaryFunctions = map[Operator]string{
+	"=": "equals", "==": "equals", "!=": "notEquals", "<>": "notEquals",
+	"<": "less", ">": "greater", "<=": "lessOrEquals", ">=": "greaterOrEquals",
+	"+": "plus", "-": "minus", "*": "multiply", "/": "divide", "%": "modulo",
+	"IN": "in", "NOT IN": "notIn", "LIKE": "like", "NOT LIKE": "notLike", "ILIKE": "ilike",
+}
+
+// columnName returns the name of a result column.
+func columnName(e Expression) string {
+	switch e := e.(type) {
+	case Column:
+		return unquoteIdent(string(e))
+	case literalValue, argExpr:
+		return e.Expression()
+	case asExpression:
+		return unquoteIdent(e.Right.Expression())
+	case fnCall:
+		return e.name + "(" + columnNames(e.args) + ")"
+	case BinaryExpression:
+		if fn, ok := binaryFunctions[e.Operator]; ok {
+			return fn + "(" + columnNames([]Expression{e.LeftOperand, e.RightOperand}) + ")"
+		}
+	case concatenatedExpression:
+		return strings.ToLower(string(e.Op)) + "(" + columnNames(e.Expr) + ")"
+	case Tuple:
+		for _, v := range e {
+			if _, ok := v.(literalValue); !ok {
+				return "tuple(" + columnNames(e) + ")"
+			}
+		}
+		return "(" + columnNames(e) + ")"
+	case intervalExpr:
+		unit := string(e.unit)
+		return "toInterval" + unit[:1] + strings.ToLower(unit[1:]) + "(" + columnName(e.value) + ")"
+	case LambdaExpression:
+		params := make([]Expression, len(e.params))
+		for i, p := range e.params {
+			params[i] = Column(p)
+		}
+		return "lambda(tuple(" + columnNames(params) + "), " + columnName(e.body) + ")"
+	}
+	return e.Expression()
+}
+
+func columnNames(exprs []Expression) string {
+	names := make([]string, len(exprs))
+	for i, e := range exprs {
+		names[i] = columnName(e)
+	}
+	return strings.Join(names, ", ")
+}
+
+// aggregateType returns the result type of an aggregate function with combinators, or the zero Type if unknown.
+// See https://clickhouse.com/docs/sql-reference/aggregate-functions/reference
+func aggregateType(base string, combinators []string, args []chtype.Type) chtype.Type {
+	// combinators are applied to arguments from the outermost, which is the last suffix
+	for i := len(combinators) - 1; i >= 0; i-- {
+		switch combinators[i] {
+		case "If":
+			if len(args) == 0 {
+				return chtype.Type{}
+			}
+			args = args[:len(args)-1]
+		case "Array", "ForEach":
+			for j := range args {
+				if args[j].Base().Name != "Array" {
+					return chtype.Type{}
+				}
+				args[j] = args[j].Base().Elem()
+			}
+		case "Distinct", "OrDefault", "OrNull":
+		default:
+			// states and other combinators
+			return chtype.Type{}
+		}
+	}
+	t := baseAggregateType(base, args)
+	if t.Name == "" {
+		return t
+	}
+	// aggregate functions of Nullable arguments return NULL if all values are NULL, except counting functions
+	if len(args) > 0 && args[0].IsNullable() && !t.IsNullable() && t.Name != "Array" &&
+		base != "count" && !strings.HasPrefix(base, "uniq") {
+		t = nullable(t)
+	}
+	for _, comb := range combinators {
+		switch comb {
+		case "ForEach":
+			t = chtype.Type{Name: "Array", Elems: []chtype.Type{t}}
+		case "OrNull":
+			if !t.IsNullable() {
+				t = nullable(t)
+			}
+		}
+	}
+	return t
+}
+
+func baseAggregateType(name string, args []chtype.Type) chtype.Type {
+	switch name {
+	case "count", "uniq", "uniqExact", "uniqCombined", "uniqCombined64", "uniqHLL12", "uniqTheta", "uniqUpTo",
+		"sequenceCount":
+		return chtype.Type{Name: "UInt64"}
+	case "sequenceMatch", "windowFunnel":
+		return chtype.Type{Name: "UInt8"}
+	case "retention":
+		return chtype.MustParse("Array(UInt8)")
+	}
+	if len(args) == 0 || args[0].Name == "" {
+		return chtype.Type{}
+	}
+	t, base := args[0], args[0].Base()
+	switch name {
+	case "min", "max", "any", "anyLast", "anyHeavy", "argMin", "argMax", "any_value", "first_value", "last_value":
+		return t
+	case "groupArray", "groupUniqArray", "topK", "topKWeighted":
+		// NULLs are skipped
+		return chtype.Type{Name: "Array", Elems: []chtype.Type{base}}
+	case "groupBitAnd", "groupBitOr", "groupBitXor", "sumWithOverflow":
+		return base
+	case "sum":
+		return sumType(base)
+	case "avg", "avgWeighted", "sumKahan", "stddevPop", "stddevSamp", "varPop", "varSamp", "covarPop", "covarSamp",
+		"corr", "skewPop", "skewSamp", "kurtPop", "kurtSamp", "entropy":
+		return chtype.Type{Name: "Float64"}
+	case "median", "quantile", "quantileExact", "quantileTDigest", "quantileTiming":
+		return quantileType(name, base)
+	case "quantiles", "quantilesExact", "quantilesTDigest", "quantilesTiming":
+		return chtype.Type{Name: "Array", Elems: []chtype.Type{quantileType(strings.Replace(name, "quantiles", "quantile", 1), base)}}
+	}
+	return chtype.Type{}
+}
+
+// sumType returns the result type of sum, which widens integers to 64 bits and decimals to the maximum precision.
+func sumType(t chtype.Type) chtype.Type {
+	switch {
+	case t.Name == "Bool":
+		return chtype.Type{Name: "UInt64"}
+	case t.IsInteger():
+		bits := t.IntBits()
+		if bits < 64 {
+			bits = 64
+		}
+		name := fmt.Sprintf("Int%d", bits)
+		if t.IsUnsigned() {
+			name = "U" + name
+		}
+		return chtype.Type{Name: name}
+	case t.IsFloat():
+		return chtype.Type{Name: "Float64"}
+	case t.IsDecimal():
+		precision, scale, err := t.DecimalPrecisionScale()
+		if err != nil {
+			return chtype.Type{}
+		}
+		if precision <= 38 {
+			precision = 38
+		} else {
+			precision = 76
+		}
+		return chtype.MustParse(fmt.Sprintf("Decimal(%d, %d)", precision, scale))
+	}
+	return chtype.Type{}
+}
+
+// quantileType returns the result type of quantile functions, which keep types of dates and times.
+func quantileType(name string, t chtype.Type) chtype.Type {
+	switch {
+	case t.IsTemporal():
+		return t
+	case name == "quantileExact" && t.IsNumeric():
+		return t
+	case name == "quantileTiming":
+		return chtype.Type{Name: "Float32"}
+	case t.IsNumeric():
+		return chtype.Type{Name: "Float64"}
+	}
+	return chtype.Type{}
+}
+
+func nullable(t chtype.Type) chtype.Type {
+	return chtype.Type{Name: "Nullable", Elems: []chtype.Type{t}}
+}
@@ -0,0 +1,123 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestSelectBuilder_ResultColumns(t *testing.T) {
+	events := Table("db.events")
+	tests := []struct {
+		name  string
+		query *SelectBuilder
+		// want are names and types like `count() UInt64`, the type is omitted if unknown
+		want []string
+	}{
+		{
+			name: "columns and aliases",
+			query: Select(Column("id"), Column("e.name"), As(ToDate(Column("ts")), Column("day")), As(Column("id"), Column("x"))).
+				From(events.As("e")),
+			want: []string{"id UInt64", "e.name LowCardinality(String)", "day Date", "x UInt64"},
+		},
+		{
+			name: "default names",
+			query: Select(
+				Count(),
+				Plus(Column("id"), LiteralExpression(1)),
+				And(Column("a"), Fn("not", Column("b"))),
+				In(Column("level"), Tuple{LiteralExpressionQuoted("info"), LiteralExpressionQuoted("error")}),
+				Tuple{Column("id"), Column("name")},
+				Ago(Interval(7, UnitDay)),
+				ArrayMap(Lambda([]LambdaParameter{"x"}, Plus(LambdaParameter("x"), LiteralExpression(1))), Column("tags")),
+				Arg("v"),
+			).From(events),
+			want: []string{
+				"count() UInt64",
+				"plus(id, 1)",
+				"and(a, not(b)) UInt8",
+				"in(level, ('info', 'error')) UInt8",
+				"tuple(id, name) Tuple(UInt64, LowCardinality(String))",
+				"minus(now(), toIntervalDay(7)) DateTime",
+				"arrayMap(lambda(tuple(x), plus(x, 1)), tags)",
+				"'v' String",
+			},
+		},
+		{
+			name: "aggregates",
+			query: Select(
+				Sum(Column("id")),
+				Sum(Column("duration")),
+				Avg(Column("id")),
+				Fn("sumIf", Column("id"), Column("ok")),
+				Fn("maxOrNull", Column("ts")),
+				Fn("uniqExactArray", Column("tags")),
+				Fn("groupArray", Column("name")),
+				Fn("quantiles", Column("ts")),
+				Fn("sumForEach", Column("tags")),
+				Fn("sumState", Column("id")),
+				Fn("countIf", Column("ok")),
+			).From(events),
+			want: []string{
+				"sum(id) UInt64",
+				"sum(duration) Nullable(Float64)",
+				"avg(id) Float64",
+				"sumIf(id, ok) UInt64",
+				"maxOrNull(ts) Nullable(DateTime64(3, 'UTC'))",
+				"uniqExactArray(tags) UInt64",
+				"groupArray(name) Array(String)",
+				"quantiles(ts) Array(DateTime64(3, 'UTC'))",
+				"sumForEach(tags)",
+				"sumState(id)",
+				"countIf(ok) UInt64",
+			},
+		},
+		{
+			name:  "star",
+			query: Select(Column("*")).From(events),
+			want:  []string{"id UInt64", "ts DateTime64(3, 'UTC')", "name LowCardinality(String)", "level Enum8('info' = 1, 'error' = 2)", "duration Nullable(Float64)", "tags Array(String)", "ip IPv4", "attrs.key Array(String)"},
+		},
+		{
+			name: "nested query",
+			query: Select(Column("n"), Sum(Column("n"))).
+				From(Select(As(Count(), Column("n")), Column("name")).From(events).GroupBy(Column("name"))),
+			want: []string{"n UInt64", "sum(n) UInt64"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := tt.query.ResultColumns(testSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cols) != len(tt.want) {
+				t.Fatalf("got %d columns: %+v", len(cols), cols)
+			}
+			for i, c := range cols {
+				got := c.Name
+				if c.Type.Name != "" {
+					got += " " + c.Type.String()
+				}
+				if got != tt.want[i] {
+					t.Errorf("column %d = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectBuilder_ResultColumns_NoSchema(t *testing.T) {
+	q := Select(As(Count(), Column("n")), Column("id"), LiteralExpression(1)).From(Table("events"))
+	cols, err := q.ResultColumns(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cols) != 3 || cols[0].Name != "n" || cols[0].Type.String() != "UInt64" || cols[1].Name != "id" ||
+		cols[1].Type.Name != "" || cols[2].Name != "1" || cols[2].Type.String() != "Int64" {
+		t.Fatalf("ResultColumns() = %+v", cols)
+	}
+	if _, err := Select(Column("*")).From(Table("events")).ResultColumns(nil); err == nil {
+		t.Error("expected error expanding * of unknown table")
+	}
+	if _, err := (&SelectBuilder{}).ResultColumns(nil); err == nil {
+		t.Error("expected error on empty query")
+	}
+}
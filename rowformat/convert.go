@@ -0,0 +1,591 @@
+package rowformat
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keuin/click/chtype"
+	"github.com/keuin/click/internal/fields"
+)
+
+// fromText converts a value in ClickHouse text representation to Go value according to its type.
+// Nested values (arrays, maps and tuples) are in SQL literal syntax, e.g. `[1,2]` and `{'a':1}`.
+func fromText(s string, t chtype.Type) (any, error) {
+	switch t.Name {
+	case "Nullable":
+		if s == `\N` || s == "NULL" {
+			return nil, nil
+		}
+		return fromText(s, t.Elem())
+	case "LowCardinality", "SimpleAggregateFunction":
+		return fromText(s, t.Elem())
+	case "Array":
+		elems, err := splitLiteral(s, '[', ']')
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]any, len(elems))
+		for i := range elems {
+			if ret[i], err = fromLiteral(elems[i], t.Elem()); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case "Tuple":
+		elems, err := splitLiteral(s, '(', ')')
+		if err != nil {
+			return nil, err
+		}
+		if len(elems) != len(t.Elems) {
+			return nil, fmt.Errorf("expected %d tuple elements, got %d", len(t.Elems), len(elems))
+		}
+		ret := make([]any, len(elems))
+		for i := range elems {
+			if ret[i], err = fromLiteral(elems[i], t.Elems[i]); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case "Map":
+		elems, err := splitLiteral(s, '{', '}')
+		if err != nil {
+			return nil, err
+		}
+		ret := make(map[any]any, len(elems))
+		for _, elem := range elems {
+			kv, err := splitTopLevel(elem, ':')
+			if err != nil {
+				return nil, err
+			}
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("invalid map element: %s", elem)
+			}
+			k, err := fromLiteral(kv[0], t.Elems[0])
+			if err != nil {
+				return nil, err
+			}
+			if ret[k], err = fromLiteral(kv[1], t.Elems[1]); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	}
+	return scalarFromText(s, t)
+}
+
+// fromLiteral converts a SQL literal inside nested values, where strings are quoted.
+func fromLiteral(s string, t chtype.Type) (any, error) {
+	s = strings.TrimSpace(s)
+	if s == "NULL" && t.IsNullable() {
+		return nil, nil
+	}
+	if strings.HasPrefix(s, "'") {
+		unquoted, err := chtype.Unquote(s)
+		if err != nil {
+			return nil, err
+		}
+		s = unquoted
+	}
+	return fromText(s, t)
+}
+
+func scalarFromText(s string, t chtype.Type) (any, error) {
+	if bits := t.IntBits(); bits > 0 {
+		if bits > 64 {
+			v, ok := new(big.Int).SetString(s, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s value: %q", t, s)
+			}
+			return v, nil
+		}
+		if t.IsUnsigned() {
+			v, err := strconv.ParseUint(s, 10, bits)
+			if err != nil {
+				return nil, err
+			}
+			return sizedUint(v, bits), nil
+		}
+		v, err := strconv.ParseInt(s, 10, bits)
+		if err != nil {
+			return nil, err
+		}
+		return sizedInt(v, bits), nil
+	}
+	switch t.Name {
+	case "Float32":
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	case "Float64":
+		return strconv.ParseFloat(s, 64)
+	case "Bool":
+		return strconv.ParseBool(s)
+	case "Date", "Date32":
+		return time.ParseInLocation("2006-01-02", s, time.UTC)
+	case "DateTime", "DateTime32", "DateTime64":
+		loc, err := location(t)
+		if err != nil {
+			return nil, err
+		}
+		if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+			// date_time_output_format=unix_timestamp
+			return time.Unix(v, 0).In(loc), nil
+		}
+		return parseTime(s, loc)
+	}
+	return s, nil
+}
+
+func sizedInt(v int64, bits int) any {
+	switch bits {
+	case 8:
+		return int8(v)
+	case 16:
+		return int16(v)
+	case 32:
+		return int32(v)
+	default:
+		return v
+	}
+}
+
+func sizedUint(v uint64, bits int) any {
+	switch bits {
+	case 8:
+		return uint8(v)
+	case 16:
+		return uint16(v)
+	case 32:
+		return uint32(v)
+	default:
+		return v
+	}
+}
+
+// location returns the time zone of DateTime types. Values without explicit time zone are in UTC.
+func location(t chtype.Type) (*time.Location, error) {
+	tz := t.TimeZone()
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("load time zone of %s: %w", t, err)
+	}
+	return loc, nil
+}
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if v, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return v, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q", s)
+}
+
+// fromJSON converts a decoded JSON value to Go value according to its type.
+// JSON numbers are expected to be decoded as json.Number.
+func fromJSON(v any, t chtype.Type) (any, error) {
+	if v == nil {
+		if !t.IsNullable() {
+			return nil, fmt.Errorf("unexpected null of type %s", t)
+		}
+		return nil, nil
+	}
+	base := t.Base()
+	switch v := v.(type) {
+	case string:
+		return scalarFromText(v, base)
+	case json.Number:
+		return scalarFromText(v.String(), base)
+	case bool:
+		return v, nil
+	case []any:
+		ret := make([]any, len(v))
+		for i := range v {
+			var elemType chtype.Type
+			switch {
+			case base.Name == "Array":
+				elemType = base.Elem()
+			case base.Name == "Tuple" && i < len(base.Elems):
+				elemType = base.Elems[i]
+			default:
+				return nil, fmt.Errorf("unexpected array of type %s", t)
+			}
+			var err error
+			if ret[i], err = fromJSON(v[i], elemType); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case map[string]any:
+		switch base.Name {
+		case "Map":
+			ret := make(map[any]any, len(v))
+			for k, e := range v {
+				key, err := scalarFromText(k, base.Elems[0].Base())
+				if err != nil {
+					return nil, err
+				}
+				if ret[key], err = fromJSON(e, base.Elems[1]); err != nil {
+					return nil, err
+				}
+			}
+			return ret, nil
+		case "Tuple":
+			// named tuple as JSON object
+			ret := make([]any, len(base.Elems))
+			for i, name := range base.FieldNames {
+				var err error
+				if ret[i], err = fromJSON(v[name], base.Elems[i]); err != nil {
+					return nil, err
+				}
+			}
+			return ret, nil
+		}
+		return nil, fmt.Errorf("unexpected object of type %s", t)
+	}
+	return nil, fmt.Errorf("unexpected JSON value %T", v)
+}
+
+// fromUntypedJSON converts a decoded JSON value without type information.
+// Numbers are converted to int64, uint64 or float64, whichever fits first.
+func fromUntypedJSON(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return u
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = fromUntypedJSON(v[i])
+		}
+		return v
+	case map[string]any:
+		for k := range v {
+			v[k] = fromUntypedJSON(v[k])
+		}
+		return v
+	}
+	return v
+}
+
+// splitLiteral splits elements of an array, tuple or map literal enclosed by open and close.
+func splitLiteral(s string, open, close byte) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != open || s[len(s)-1] != close {
+		return nil, fmt.Errorf("invalid literal: %q", s)
+	}
+	return chtype.SplitArgs(s[1 : len(s)-1])
+}
+
+// splitTopLevel splits s by sep outside of quoted strings and brackets.
+func splitTopLevel(s string, sep byte) ([]string, error) {
+	var (
+		parts []string
+		depth int
+		quote bool
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote && c == '\\':
+			i++
+		case c == '\'':
+			quote = !quote
+		case quote:
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if quote || depth != 0 {
+		return nil, fmt.Errorf("invalid literal: %q", s)
+	}
+	return append(parts, s[start:]), nil
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	bigIntType          = reflect.TypeOf(&big.Int{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// assign sets dst to v, converting v to the type of dst.
+func assign(dst reflect.Value, v any) error {
+	if v == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Pointer && dst.Type() != bigIntType {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return assign(dst.Elem(), v)
+	}
+	src := reflect.ValueOf(v)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	if dst.Type() == timeType {
+		return assignTime(dst, v)
+	}
+	if s, ok := v.(string); ok && dst.Addr().Type().Implements(textUnmarshalerType) {
+		return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowInt(i) {
+			return fmt.Errorf("value %v overflows %s", v, dst.Type())
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := toUint64(v)
+		if err != nil {
+			return err
+		}
+		if dst.OverflowUint(u) {
+			return fmt.Errorf("value %v overflows %s", v, dst.Type())
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(v)
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Bool:
+		switch src.Kind() {
+		case reflect.String:
+			b, err := strconv.ParseBool(src.String())
+			if err != nil {
+				return err
+			}
+			dst.SetBool(b)
+		case reflect.Int8, reflect.Uint8, reflect.Int64, reflect.Uint64:
+			i, err := toInt64(v)
+			if err != nil {
+				return err
+			}
+			dst.SetBool(i != 0)
+		default:
+			return fmt.Errorf("cannot convert %T to bool", v)
+		}
+	case reflect.String:
+		switch v := v.(type) {
+		case time.Time:
+			dst.SetString(v.Format("2006-01-02 15:04:05.999999999"))
+		case []byte:
+			dst.SetString(string(v))
+		default:
+			if src.Kind() == reflect.Slice || src.Kind() == reflect.Map {
+				return fmt.Errorf("cannot convert %T to string", v)
+			}
+			dst.SetString(fmt.Sprint(v))
+		}
+	case reflect.Slice:
+		if s, ok := v.(string); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(s))
+			return nil
+		}
+		if src.Kind() != reflect.Slice {
+			return fmt.Errorf("cannot convert %T to %s", v, dst.Type())
+		}
+		ret := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := assign(ret.Index(i), src.Index(i).Interface()); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		dst.Set(ret)
+	case reflect.Array:
+		if src.Kind() != reflect.Slice || src.Len() != dst.Len() {
+			return fmt.Errorf("cannot convert %T to %s", v, dst.Type())
+		}
+		for i := 0; i < src.Len(); i++ {
+			if err := assign(dst.Index(i), src.Index(i).Interface()); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	case reflect.Map:
+		if src.Kind() != reflect.Map {
+			return fmt.Errorf("cannot convert %T to %s", v, dst.Type())
+		}
+		ret := reflect.MakeMapWithSize(dst.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(dst.Type().Key()).Elem()
+			if err := assign(k, iter.Key().Interface()); err != nil {
+				return fmt.Errorf("map key: %w", err)
+			}
+			e := reflect.New(dst.Type().Elem()).Elem()
+			if err := assign(e, iter.Value().Interface()); err != nil {
+				return fmt.Errorf("map value: %w", err)
+			}
+			ret.SetMapIndex(k, e)
+		}
+		dst.Set(ret)
+	case reflect.Struct:
+		return assignStruct(dst, v)
+	default:
+		return fmt.Errorf("cannot convert %T to %s", v, dst.Type())
+	}
+	return nil
+}
+
+// assignStruct sets struct from tuple (in field order) or JSON object (by field name).
+func assignStruct(dst reflect.Value, v any) error {
+	switch v := v.(type) {
+	case []any:
+		var exported []int
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Type().Field(i).IsExported() {
+				exported = append(exported, i)
+			}
+		}
+		if len(exported) != len(v) {
+			return fmt.Errorf("cannot convert %d-element tuple to %s", len(v), dst.Type())
+		}
+		for i, x := range exported {
+			if err := assign(dst.Field(x), v[i]); err != nil {
+				return fmt.Errorf("tuple element %d: %w", i, err)
+			}
+		}
+		return nil
+	case map[string]any:
+		fieldMap := fields.Of(dst.Type())
+		for k, e := range v {
+			if index, ok := fieldMap.Lookup(k); ok {
+				if err := assign(fields.ByIndex(dst, index), e); err != nil {
+					return fmt.Errorf("field %s: %w", k, err)
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot convert %T to %s", v, dst.Type())
+}
+
+func assignTime(dst reflect.Value, v any) error {
+	switch v := v.(type) {
+	case string:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			dst.Set(reflect.ValueOf(time.Unix(i, 0).UTC()))
+			return nil
+		}
+		t, err := parseTime(v, time.UTC)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	default:
+		i, err := toInt64(v)
+		if err != nil {
+			return fmt.Errorf("cannot convert %T to time.Time", v)
+		}
+		dst.Set(reflect.ValueOf(time.Unix(i, 0).UTC()))
+		return nil
+	}
+}
+
+var errNotNumber = errors.New("not a number")
+
+func toInt64(v any) (int64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v overflows int64", v)
+		}
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", v)
+		}
+		return int64(f), nil
+	case reflect.String:
+		return strconv.ParseInt(rv.String(), 10, 64)
+	}
+	if b, ok := v.(*big.Int); ok && b.IsInt64() {
+		return b.Int64(), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to integer: %w", v, errNotNumber)
+}
+
+func toUint64(v any) (uint64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < 0 {
+			return 0, fmt.Errorf("value %v overflows unsigned integer", v)
+		}
+		return uint64(rv.Int()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("value %v is not an unsigned integer", v)
+		}
+		return uint64(f), nil
+	case reflect.String:
+		return strconv.ParseUint(rv.String(), 10, 64)
+	}
+	if b, ok := v.(*big.Int); ok && b.IsUint64() {
+		return b.Uint64(), nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to unsigned integer: %w", v, errNotNumber)
+}
+
+func toFloat64(v any) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.String:
+		return strconv.ParseFloat(rv.String(), 64)
+	}
+	if b, ok := v.(*big.Int); ok {
+		f, _ := new(big.Float).SetInt(b).Float64()
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to float: %w", v, errNotNumber)
+}
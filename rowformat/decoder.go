@@ -0,0 +1,152 @@
+// Package rowformat decodes and encodes rows in ClickHouse input/output formats.
+// See https://clickhouse.com/docs/interfaces/formats
+package rowformat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/chtype"
+	"github.com/keuin/click/internal/fields"
+)
+
+// Column is a result column. Type is zero value if the format does not carry column types.
+type Column struct {
+	Name string
+	Type chtype.Type
+}
+
+// rowReader reads rows of a specific format.
+type rowReader interface {
+	// readRow reads the next row, returning io.EOF if there are no more rows.
+	// Values are converted to Go values according to column types if known, see Decoder.Scan.
+	// Columns may change between rows in formats without header, like JSONEachRow.
+	readRow() (columns []Column, values []any, err error)
+}
+
+// Decoder streams rows of a query result.
+//
+// Values are converted to Go values according to ClickHouse types in the header, if the format has one:
+// (U)Int8-64 and Float32/64 as Go types of the same width, (U)Int128/256 as *big.Int, Bool as bool,
+// Date and DateTime types as time.Time, Array and Tuple as []any, Map as map[any]any, NULL as nil,
+// and others (String, FixedString, Decimal, Enum, UUID, IPv4/6, ...) as string.
+// Values of formats without types, like JSONEachRow, are kept as decoded from the text.
+type Decoder struct {
+	r       rowReader
+	columns []Column
+	values  []any
+	err     error
+}
+
+// NewDecoder creates a decoder reading results in format f.
+// Supported formats are TabSeparated, CSV, JSONEachRow, JSONCompactEachRow (all with names and types variants),
+// and RowBinaryWithNamesAndTypes.
+func NewDecoder(r io.Reader, f click.Format) (*Decoder, error) {
+	var rr rowReader
+	switch f {
+	case click.FormatTabSeparated, click.FormatTabSeparatedWithNames, click.FormatTabSeparatedWithNamesAndTypes:
+		rr = newTSVReader(r, f != click.FormatTabSeparated, f == click.FormatTabSeparatedWithNamesAndTypes)
+	case click.FormatCSV, click.FormatCSVWithNames, click.FormatCSVWithNamesAndTypes:
+		rr = newCSVReader(r, f != click.FormatCSV, f == click.FormatCSVWithNamesAndTypes)
+	case click.FormatJSONEachRow:
+		rr = newJSONEachRowReader(r)
+	case click.FormatJSONCompactEachRow, click.FormatJSONCompactEachRowWithNames, click.FormatJSONCompactEachRowWithNamesAndTypes:
+		rr = newJSONCompactEachRowReader(r, f != click.FormatJSONCompactEachRow, f == click.FormatJSONCompactEachRowWithNamesAndTypes)
+	case click.FormatRowBinaryWithNamesAndTypes:
+		rr = newRowBinaryReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", f)
+	}
+	return &Decoder{r: rr}, nil
+}
+
+// Next reads the next row, returning false at the end of result or on error.
+// Caller should check Err after Next returns false.
+func (d *Decoder) Next() bool {
+	if d.err != nil {
+		return false
+	}
+	columns, values, err := d.r.readRow()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			d.err = err
+		}
+		d.values = nil
+		return false
+	}
+	d.columns, d.values = columns, values
+	return true
+}
+
+// Err returns the first error encountered, excluding io.EOF.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// Columns returns columns of the current row. Column names are absent in formats without header, like CSV.
+func (d *Decoder) Columns() []Column {
+	return d.columns
+}
+
+// Values returns values of the current row.
+func (d *Decoder) Values() []any {
+	return d.values
+}
+
+// Scan copies the current row into dst, which is one of:
+//   - pointer to struct: columns are mapped to fields by `ch` tag, or field name if the tag is absent.
+//     Columns without corresponding fields are ignored. Tag `ch:"-"` skips the field.
+//   - pointer to map[string]any: column names are keys.
+//   - pointer to []any: values in column order.
+//
+// Values are converted to field types where possible, e.g. strings to numbers, numbers to strings,
+// strings to time.Time, and strings to encoding.TextUnmarshaler implementations.
+func (d *Decoder) Scan(dst any) error {
+	if d.values == nil {
+		return errors.New("no current row")
+	}
+	switch dst := dst.(type) {
+	case *[]any:
+		*dst = append((*dst)[:0], d.values...)
+		return nil
+	case *map[string]any:
+		if err := d.requireNames(); err != nil {
+			return err
+		}
+		if *dst == nil {
+			*dst = make(map[string]any, len(d.values))
+		}
+		for i, c := range d.columns {
+			(*dst)[c.Name] = d.values[i]
+		}
+		return nil
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported scan destination %T", dst)
+	}
+	if err := d.requireNames(); err != nil {
+		return err
+	}
+	fieldMap := fields.Of(rv.Elem().Type())
+	for i, c := range d.columns {
+		index, ok := fieldMap.Lookup(c.Name)
+		if !ok {
+			continue
+		}
+		if err := assign(fields.ByIndex(rv.Elem(), index), d.values[i]); err != nil {
+			return fmt.Errorf("scan column %s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) requireNames() error {
+	if len(d.columns) < len(d.values) {
+		return errors.New("column names are not available in this format")
+	}
+	return nil
+}
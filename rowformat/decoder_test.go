@@ -0,0 +1,249 @@
+package rowformat
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keuin/click"
+)
+
+type event struct {
+	ID      uint64    `ch:"id"`
+	Name    string    `ch:"name"`
+	Time    time.Time `ch:"ts"`
+	Tags    []string  `ch:"tags"`
+	Score   *float64  `ch:"score"`
+	Ignored string    `ch:"-"`
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+var wantEvents = []event{
+	{ID: 1, Name: "a\tb", Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Tags: []string{"x", "y'z"}, Score: ptr(1.5)},
+	{ID: 18446744073709551615, Name: "c", Time: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), Tags: []string{}},
+}
+
+func decodeEvents(t *testing.T, f click.Format, data string) []event {
+	t.Helper()
+	d, err := NewDecoder(strings.NewReader(data), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ret []event
+	for d.Next() {
+		var e event
+		if err := d.Scan(&e); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, e)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func TestDecoder_Formats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format click.Format
+		data   string
+	}{
+		{
+			name:   "JSONEachRow",
+			format: click.FormatJSONEachRow,
+			data: `{"id":"1","name":"a\tb","ts":"2024-01-02 03:04:05","tags":["x","y'z"],"score":1.5}
+{"id":"18446744073709551615","name":"c","ts":"2024-01-03 00:00:00","tags":[],"score":null}
+`,
+		},
+		{
+			name:   "JSONCompactEachRowWithNamesAndTypes",
+			format: click.FormatJSONCompactEachRowWithNamesAndTypes,
+			data: `["id", "name", "ts", "tags", "score"]
+["UInt64", "String", "DateTime", "Array(String)", "Nullable(Float64)"]
+["1", "a\tb", "2024-01-02 03:04:05", ["x","y'z"], 1.5]
+["18446744073709551615", "c", "2024-01-03 00:00:00", [], null]
+`,
+		},
+		{
+			name:   "TabSeparatedWithNamesAndTypes",
+			format: click.FormatTabSeparatedWithNamesAndTypes,
+			data: "id\tname\tts\ttags\tscore\n" +
+				"UInt64\tString\tDateTime\tArray(String)\tNullable(Float64)\n" +
+				"1\ta\\tb\t2024-01-02 03:04:05\t['x','y\\\\'z']\t1.5\n" +
+				"18446744073709551615\tc\t2024-01-03 00:00:00\t[]\t\\N\n",
+		},
+		{
+			name:   "CSVWithNames",
+			format: click.FormatCSVWithNames,
+			data: "\"id\",\"name\",\"ts\",\"score\"\n" +
+				"1,\"a\tb\",\"2024-01-02 03:04:05\",1.5\n" +
+				"18446744073709551615,\"c\",\"2024-01-03 00:00:00\",\\N\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := wantEvents
+			if tt.format == click.FormatCSVWithNames {
+				// arrays are omitted in CSV test data
+				want = []event{wantEvents[0], wantEvents[1]}
+				want[0].Tags, want[1].Tags = nil, nil
+			}
+			if got := decodeEvents(t, tt.format, tt.data); !reflect.DeepEqual(got, want) {
+				t.Errorf("decoded %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecoder_ScanMap(t *testing.T) {
+	d := must(NewDecoder(strings.NewReader("a\tb\tc\tm\nInt8\tNullable(UInt16)\tTuple(String, Date)\tMap(String, Array(UInt8))\n"+
+		"-1\t\\N\t('x','2024-01-02')\t{'k':[1,2]}\n"), click.FormatTabSeparatedWithNamesAndTypes))
+	if !d.Next() {
+		t.Fatal(d.Err())
+	}
+	var m map[string]any
+	if err := d.Scan(&m); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"a": int8(-1),
+		"b": nil,
+		"c": []any{"x", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		"m": map[any]any{"k": []any{uint8(1), uint8(2)}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Fatalf("got %#v, want %#v", m, want)
+	}
+	if d.Next() || d.Err() != nil {
+		t.Fatal("expected end of rows", d.Err())
+	}
+}
+
+func TestDecoder_ScanWithoutNames(t *testing.T) {
+	d := must(NewDecoder(strings.NewReader("1,\"a\"\n"), click.FormatCSV))
+	if !d.Next() {
+		t.Fatal(d.Err())
+	}
+	var row []any
+	if err := d.Scan(&row); err != nil || !reflect.DeepEqual(row, []any{"1", "a"}) {
+		t.Fatal(row, err)
+	}
+	var e event
+	if err := d.Scan(&e); err == nil {
+		t.Fatal("expected error scanning struct without column names")
+	}
+}
+
+// rowBinaryBuilder writes RowBinary test data by hand.
+type rowBinaryBuilder struct {
+	bytes.Buffer
+}
+
+func (b *rowBinaryBuilder) str(s string) *rowBinaryBuilder {
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+	b.WriteString(s)
+	return b
+}
+
+func (b *rowBinaryBuilder) le(v any) *rowBinaryBuilder {
+	_ = binary.Write(b, binary.LittleEndian, v)
+	return b
+}
+
+func TestDecoder_RowBinaryWithNamesAndTypes(t *testing.T) {
+	var b rowBinaryBuilder
+	b.le(uint8(8))
+	for _, name := range []string{"i", "s", "n", "dt", "u", "d", "a", "big"} {
+		b.str(name)
+	}
+	for _, typ := range []string{"Int16", "LowCardinality(String)", "Nullable(UInt8)", "DateTime64(3, 'UTC')", "UUID", "Decimal(9, 2)", "Array(Enum8('a' = 1, 'b' = -2))", "Int128"} {
+		b.str(typ)
+	}
+	b.le(int16(-2)).str("hello")
+	b.le(uint8(1))
+	b.le(int64(1704164645123))
+	b.le(uint64(0x0123456789abcdef)).le(uint64(0xfedcba9876543210))
+	b.le(int32(-12345))
+	b.le(uint8(2)).le(int8(1)).le(int8(-2))
+	b.le(int64(-1)).le(int64(-1))
+
+	d := must(NewDecoder(&b, click.FormatRowBinaryWithNamesAndTypes))
+	if !d.Next() {
+		t.Fatal(d.Err())
+	}
+	want := []any{
+		int16(-2),
+		"hello",
+		nil,
+		time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC),
+		"01234567-89ab-cdef-fedc-ba9876543210",
+		"-123.45",
+		[]any{"a", "b"},
+		big.NewInt(-1),
+	}
+	if !reflect.DeepEqual(d.Values(), want) {
+		t.Fatalf("got %#v, want %#v", d.Values(), want)
+	}
+	if d.Next() || d.Err() != nil {
+		t.Fatal("expected end of rows", d.Err())
+	}
+}
+
+func TestDecoder_RowBinaryTruncated(t *testing.T) {
+	var b rowBinaryBuilder
+	b.le(uint8(1)).str("x").str("UInt32").le(uint16(1))
+	d := must(NewDecoder(&b, click.FormatRowBinaryWithNamesAndTypes))
+	if d.Next() || d.Err() == nil {
+		t.Fatal("expected error on truncated row")
+	}
+}
+
+func TestDecoder_RowBinaryCorruptedLength(t *testing.T) {
+	var b rowBinaryBuilder
+	b.le(uint8(1)).str("a").str("Array(UInt8)")
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutUvarint(buf[:], 1<<29)])
+	b.le(uint8(1))
+	d := must(NewDecoder(&b, click.FormatRowBinaryWithNamesAndTypes))
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if d.Next() || d.Err() == nil {
+		t.Fatal("expected error on truncated array")
+	}
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("%d bytes are allocated for a truncated array", n)
+	}
+}
+
+func TestDecoder_RowBinaryInvalidTimeZone(t *testing.T) {
+	var b rowBinaryBuilder
+	b.le(uint8(1)).str("ts").str("DateTime('Nowhere/Invalid')")
+	d := must(NewDecoder(&b, click.FormatRowBinaryWithNamesAndTypes))
+	if d.Next() || d.Err() == nil || !strings.Contains(d.Err().Error(), "column ts") {
+		t.Fatalf("expected error on invalid time zone, got %v", d.Err())
+	}
+}
+
+func TestNewDecoder_UnsupportedFormat(t *testing.T) {
+	if _, err := NewDecoder(strings.NewReader(""), click.FormatParquet); err == nil {
+		t.Fatal("expected error, got nothing")
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
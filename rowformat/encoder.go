@@ -0,0 +1,561 @@
+package rowformat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/chtype"
+	"github.com/keuin/click/internal/fields"
+)
+
+// RowBinaryEncoder writes rows in RowBinary or RowBinaryWithNamesAndTypes format, typically as INSERT data.
+//
+// Values are converted according to column types. Accepted Go values are:
+//   - integers and floats: Go numbers of any width, in range of the column type, or *big.Int for (U)Int128/256
+//   - String and FixedString: string or []byte, FixedString is padded with zero bytes
+//   - Date, DateTime and DateTime64: time.Time
+//   - Decimal: string like `123.45`, integers, or floats which are rounded to the scale
+//   - UUID, IPv4 and IPv6: string in text form, or [16]byte and net.IP respectively
+//   - Enum8 and Enum16: element name as string, or its integer value
+//   - Nullable: nil or nil pointer as NULL
+//   - Array: slices or arrays; Map: maps; Tuple: []any or structs with exported fields in order
+//
+// Pointers are dereferenced. Encoded rows are buffered, caller must call Flush after writing all rows.
+type RowBinaryEncoder struct {
+	w          *bufio.Writer
+	columns    []Column
+	withHeader bool
+	started    bool
+	row        bytes.Buffer
+	buf        [binary.MaxVarintLen64]byte
+}
+
+// NewRowBinaryEncoder creates an encoder writing RowBinary format, without header.
+func NewRowBinaryEncoder(w io.Writer, columns []Column) *RowBinaryEncoder {
+	return &RowBinaryEncoder{
+		w:       bufio.NewWriter(w),
+		columns: columns,
+	}
+}
+
+// NewRowBinaryWithNamesAndTypesEncoder creates an encoder writing RowBinaryWithNamesAndTypes format.
+// The header is written before the first row, or on Flush if there are no rows.
+func NewRowBinaryWithNamesAndTypesEncoder(w io.Writer, columns []Column) *RowBinaryEncoder {
+	e := NewRowBinaryEncoder(w, columns)
+	e.withHeader = true
+	return e
+}
+
+// Format returns the format written by the encoder.
+func (e *RowBinaryEncoder) Format() click.Format {
+	if e.withHeader {
+		return click.FormatRowBinaryWithNamesAndTypes
+	}
+	return click.FormatRowBinary
+}
+
+// InsertQuery creates INSERT query of the encoded data, e.g. `INSERT INTO t (a, b) FORMAT RowBinary`.
+func (e *RowBinaryEncoder) InsertQuery(table click.Table) *click.InsertBuilder {
+	columns := make([]click.Column, len(e.columns))
+	for i := range e.columns {
+		columns[i] = click.Column(e.columns[i].Name)
+	}
+	return click.InsertInto(table, columns...).Format(e.Format())
+}
+
+func (e *RowBinaryEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if !e.withHeader {
+		return nil
+	}
+	e.writeLength(len(e.columns))
+	for _, c := range e.columns {
+		e.writeString(c.Name)
+	}
+	for _, c := range e.columns {
+		e.writeString(c.Type.String())
+	}
+	return nil
+}
+
+// Encode writes a row. Values are in column order.
+func (e *RowBinaryEncoder) Encode(values ...any) error {
+	if len(values) != len(e.columns) {
+		return fmt.Errorf("expected %d values, got %d", len(e.columns), len(values))
+	}
+	if err := e.start(); err != nil {
+		return err
+	}
+	// encode the entire row before writing, so an invalid row does not corrupt the stream
+	e.row.Reset()
+	for i, c := range e.columns {
+		if err := encodeValue(&e.row, c.Type, reflect.ValueOf(values[i])); err != nil {
+			return fmt.Errorf("column %s: %w", c.Name, err)
+		}
+	}
+	_, err := e.w.Write(e.row.Bytes())
+	return err
+}
+
+// EncodeStruct writes a row from struct fields, mapped to columns the same way as Decoder.Scan.
+// Columns without corresponding fields are errors.
+func (e *RowBinaryEncoder) EncodeStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("unsupported row type %T", v)
+	}
+	fieldMap := fields.Of(rv.Type())
+	values := make([]any, len(e.columns))
+	for i, c := range e.columns {
+		index, ok := fieldMap.Lookup(c.Name)
+		if !ok {
+			return fmt.Errorf("no field for column %s in %s", c.Name, rv.Type())
+		}
+		f, err := rv.FieldByIndexErr(index)
+		if err != nil {
+			// nil embedded struct pointer
+			values[i] = nil
+			continue
+		}
+		values[i] = f.Interface()
+	}
+	return e.Encode(values...)
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (e *RowBinaryEncoder) Flush() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+func (e *RowBinaryEncoder) writeLength(n int) {
+	e.w.Write(e.buf[:binary.PutUvarint(e.buf[:], uint64(n))])
+}
+
+func (e *RowBinaryEncoder) writeString(s string) {
+	e.writeLength(len(s))
+	e.w.WriteString(s)
+}
+
+func writeLength(w io.Writer, n int) error {
+	var buf [binary.MaxVarintLen64]byte
+	_, err := w.Write(buf[:binary.PutUvarint(buf[:], uint64(n))])
+	return err
+}
+
+func writeLE(w io.Writer, v uint64, size int) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	_, err := w.Write(buf[:size])
+	return err
+}
+
+var errNull = errors.New("unexpected NULL in non-nullable column")
+
+// encodeValue writes v of type t in RowBinary format.
+func encodeValue(w io.Writer, t chtype.Type, v reflect.Value) error {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.Type() != bigIntType {
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+	// wrappers are unwrapped first, e.g. NULL in LowCardinality(Nullable(String))
+	for t.Name == "LowCardinality" || t.Name == "SimpleAggregateFunction" {
+		t = t.Elem()
+	}
+	if t.Name == "Nullable" {
+		if !v.IsValid() {
+			_, err := w.Write([]byte{1})
+			return err
+		}
+		if _, err := w.Write([]byte{0}); err != nil {
+			return err
+		}
+		return encodeValue(w, t.Elem(), v)
+	}
+	if !v.IsValid() {
+		return errNull
+	}
+	if bits := t.IntBits(); bits > 0 {
+		return encodeInt(w, t, bits, v.Interface())
+	}
+	switch t.Name {
+	case "Array":
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if err := writeLength(w, v.Len()); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(w, t.Elem(), v.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	case "Map":
+		if v.Kind() != reflect.Map {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if err := writeLength(w, v.Len()); err != nil {
+			return err
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := encodeValue(w, t.Elems[0], iter.Key()); err != nil {
+				return fmt.Errorf("map key: %w", err)
+			}
+			if err := encodeValue(w, t.Elems[1], iter.Value()); err != nil {
+				return fmt.Errorf("map value: %w", err)
+			}
+		}
+		return nil
+	case "Tuple":
+		return encodeTuple(w, t, v)
+	case "Bool":
+		if v.Kind() != reflect.Bool {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		b := byte(0)
+		if v.Bool() {
+			b = 1
+		}
+		_, err := w.Write([]byte{b})
+		return err
+	case "Float32", "Float64":
+		f, err := toFloat64(v.Interface())
+		if err != nil || v.Kind() == reflect.String {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if t.Name == "Float32" {
+			return writeLE(w, uint64(math.Float32bits(float32(f))), 4)
+		}
+		return writeLE(w, math.Float64bits(f), 8)
+	case "String":
+		b, err := bytesOf(v)
+		if err != nil {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if err := writeLength(w, len(b)); err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	case "FixedString":
+		n, err := t.FixedStringLength()
+		if err != nil {
+			return err
+		}
+		b, err := bytesOf(v)
+		if err != nil {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		if len(b) > n {
+			return fmt.Errorf("value of %d bytes exceeds %s", len(b), t)
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		_, err = w.Write(make([]byte, n-len(b)))
+		return err
+	case "Date", "Date32", "DateTime", "DateTime32", "DateTime64":
+		ts, ok := v.Interface().(time.Time)
+		if !ok {
+			return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+		}
+		return encodeTime(w, t, ts)
+	case "UUID":
+		return encodeUUID(w, v)
+	case "IPv4", "IPv6":
+		return encodeIP(w, t, v)
+	case "Enum8", "Enum16":
+		return encodeEnum(w, t, v)
+	}
+	if t.IsDecimal() {
+		return encodeDecimal(w, t, v.Interface())
+	}
+	return fmt.Errorf("unsupported type %s", t)
+}
+
+func bytesOf(v reflect.Value) ([]byte, error) {
+	switch {
+	case v.Kind() == reflect.String:
+		return []byte(v.String()), nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return v.Bytes(), nil
+	case v.Kind() == reflect.Array && v.Type().Elem().Kind() == reflect.Uint8:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return b, nil
+	default:
+		return nil, fmt.Errorf("cannot convert %s to bytes", v.Type())
+	}
+}
+
+func encodeInt(w io.Writer, t chtype.Type, bits int, v any) error {
+	if _, ok := v.(string); ok {
+		return fmt.Errorf("cannot encode string as %s", t)
+	}
+	var x *big.Int
+	if b, ok := v.(*big.Int); ok {
+		x = b
+	} else if t.IsUnsigned() {
+		u, err := toUint64(v)
+		if err != nil {
+			return fmt.Errorf("cannot encode %T as %s: %w", v, t, err)
+		}
+		x = new(big.Int).SetUint64(u)
+	} else {
+		i, err := toInt64(v)
+		if err != nil {
+			return fmt.Errorf("cannot encode %T as %s: %w", v, t, err)
+		}
+		x = big.NewInt(i)
+	}
+	b, err := encodeBigInt(x, bits, !t.IsUnsigned())
+	if err != nil {
+		return fmt.Errorf("value %v out of range of %s", v, t)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// encodeBigInt encodes v as a little-endian two's complement integer of given bits.
+func encodeBigInt(v *big.Int, bits int, signed bool) ([]byte, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	lo, hi := new(big.Int), new(big.Int).Sub(limit, big.NewInt(1))
+	if signed {
+		lo.Neg(new(big.Int).Rsh(limit, 1))
+		hi.Rsh(limit, 1).Sub(hi, big.NewInt(1))
+	}
+	if v.Cmp(lo) < 0 || v.Cmp(hi) > 0 {
+		return nil, errors.New("out of range")
+	}
+	u := new(big.Int).Set(v)
+	if u.Sign() < 0 {
+		u.Add(u, limit)
+	}
+	be := u.FillBytes(make([]byte, bits/8))
+	for i, j := 0, len(be)-1; i < j; i, j = i+1, j-1 {
+		be[i], be[j] = be[j], be[i]
+	}
+	return be, nil
+}
+
+func encodeTuple(w io.Writer, t chtype.Type, v reflect.Value) error {
+	var elems []reflect.Value
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				elems = append(elems, v.Field(i))
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+	}
+	if len(elems) != len(t.Elems) {
+		return fmt.Errorf("cannot encode %d elements as %s", len(elems), t)
+	}
+	for i := range elems {
+		if err := encodeValue(w, t.Elems[i], elems[i]); err != nil {
+			return fmt.Errorf("tuple element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func encodeTime(w io.Writer, t chtype.Type, ts time.Time) error {
+	switch t.Name {
+	case "Date", "Date32":
+		// calendar date in the location of the time
+		y, m, d := ts.Date()
+		days := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / secondsPerDay
+		if t.Name == "Date" {
+			if days < 0 || days > math.MaxUint16 {
+				return fmt.Errorf("date %v out of range of %s", ts, t)
+			}
+			return writeLE(w, uint64(days), 2)
+		}
+		if days < math.MinInt32 || days > math.MaxInt32 {
+			return fmt.Errorf("date %v out of range of %s", ts, t)
+		}
+		return writeLE(w, uint64(uint32(int32(days))), 4)
+	case "DateTime", "DateTime32":
+		sec := ts.Unix()
+		if sec < 0 || sec > math.MaxUint32 {
+			return fmt.Errorf("time %v out of range of %s", ts, t)
+		}
+		return writeLE(w, uint64(sec), 4)
+	default:
+		scale := pow10(t.DateTimePrecision())
+		ticks := ts.Unix()*scale + int64(ts.Nanosecond())/(1e9/scale)
+		return writeLE(w, uint64(ticks), 8)
+	}
+}
+
+func encodeUUID(w io.Writer, v reflect.Value) error {
+	var b [16]byte
+	switch {
+	case v.Kind() == reflect.String:
+		s := strings.ReplaceAll(v.String(), "-", "")
+		if len(s) != 32 {
+			return fmt.Errorf("invalid UUID: %q", v.String())
+		}
+		if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+			return fmt.Errorf("invalid UUID: %q", v.String())
+		}
+	case v.Kind() == reflect.Array && v.Len() == 16 && v.Type().Elem().Kind() == reflect.Uint8:
+		reflect.Copy(reflect.ValueOf(b[:]), v)
+	default:
+		return fmt.Errorf("cannot encode %s as UUID", v.Type())
+	}
+	// two little-endian UInt64 halves
+	var out [16]byte
+	for i := 0; i < 8; i++ {
+		out[i] = b[7-i]
+		out[8+i] = b[15-i]
+	}
+	_, err := w.Write(out[:])
+	return err
+}
+
+func encodeIP(w io.Writer, t chtype.Type, v reflect.Value) error {
+	var ip net.IP
+	switch x := v.Interface().(type) {
+	case string:
+		ip = net.ParseIP(x)
+	case net.IP:
+		ip = x
+	}
+	if ip == nil {
+		return fmt.Errorf("cannot encode %v as %s", v.Interface(), t)
+	}
+	if t.Name == "IPv4" {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			return fmt.Errorf("cannot encode %v as %s", v.Interface(), t)
+		}
+		_, err := w.Write([]byte{ip4[3], ip4[2], ip4[1], ip4[0]})
+		return err
+	}
+	_, err := w.Write(ip.To16())
+	return err
+}
+
+func encodeEnum(w io.Writer, t chtype.Type, v reflect.Value) error {
+	values, err := t.EnumValues()
+	if err != nil {
+		return err
+	}
+	var x int64
+	if v.Kind() == reflect.String {
+		n, ok := values[v.String()]
+		if !ok {
+			return fmt.Errorf("unknown element %q of %s", v.String(), t)
+		}
+		x = int64(n)
+	} else if x, err = toInt64(v.Interface()); err != nil {
+		return fmt.Errorf("cannot encode %s as %s", v.Type(), t)
+	}
+	if t.Name == "Enum8" {
+		if x < math.MinInt8 || x > math.MaxInt8 {
+			return fmt.Errorf("value %d out of range of %s", x, t)
+		}
+		return writeLE(w, uint64(uint8(int8(x))), 1)
+	}
+	if x < math.MinInt16 || x > math.MaxInt16 {
+		return fmt.Errorf("value %d out of range of %s", x, t)
+	}
+	return writeLE(w, uint64(uint16(int16(x))), 2)
+}
+
+func encodeDecimal(w io.Writer, t chtype.Type, v any) error {
+	precision, scale, err := t.DecimalPrecisionScale()
+	if err != nil {
+		return err
+	}
+	var x *big.Int
+	switch v := v.(type) {
+	case string:
+		if x, err = parseDecimal(v, scale); err != nil {
+			return err
+		}
+	case float32, float64:
+		f, _ := toFloat64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("cannot encode %v as %s", f, t)
+		}
+		if x, err = parseDecimal(strconv.FormatFloat(f, 'f', scale, 64), scale); err != nil {
+			return err
+		}
+	default:
+		if b, ok := v.(*big.Int); ok {
+			x = new(big.Int).Set(b)
+		} else if i, err := toInt64(v); err == nil {
+			x = big.NewInt(i)
+		} else if u, err := toUint64(v); err == nil {
+			x = new(big.Int).SetUint64(u)
+		} else {
+			return fmt.Errorf("cannot encode %T as %s", v, t)
+		}
+		x.Mul(x, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	}
+	if len(new(big.Int).Abs(x).String()) > precision {
+		return fmt.Errorf("value %v out of range of %s", v, t)
+	}
+	b, err := encodeBigInt(x, decimalSize(t)*8, true)
+	if err != nil {
+		return fmt.Errorf("value %v out of range of %s", v, t)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// parseDecimal parses decimal string s into integer scaled by 10^scale.
+// Digits beyond the scale are errors rather than being silently truncated.
+func parseDecimal(s string, scale int) (*big.Int, error) {
+	intPart, fracPart := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		intPart, fracPart = s[:dot], s[dot+1:]
+	}
+	if trimmed := strings.TrimRight(fracPart, "0"); len(trimmed) > scale {
+		return nil, fmt.Errorf("decimal %q has more than %d fractional digits", s, scale)
+	}
+	if len(fracPart) > scale {
+		fracPart = fracPart[:scale]
+	}
+	fracPart += strings.Repeat("0", scale-len(fracPart))
+	x, ok := new(big.Int).SetString(intPart+fracPart, 10)
+	if !ok || strings.ContainsAny(fracPart, "+-") {
+		return nil, fmt.Errorf("invalid decimal: %q", s)
+	}
+	return x, nil
+}
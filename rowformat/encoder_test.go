@@ -0,0 +1,174 @@
+package rowformat
+
+import (
+	"bytes"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/chtype"
+)
+
+func columns(nameTypes ...string) []Column {
+	ret := make([]Column, 0, len(nameTypes)/2)
+	for i := 0; i < len(nameTypes); i += 2 {
+		ret = append(ret, Column{Name: nameTypes[i], Type: chtype.MustParse(nameTypes[i+1])})
+	}
+	return ret
+}
+
+func TestRowBinaryEncoder_RoundTrip(t *testing.T) {
+	cols := columns(
+		"u8", "UInt8",
+		"i64", "Int64",
+		"u256", "UInt256",
+		"f32", "Float32",
+		"s", "LowCardinality(String)",
+		"fs", "FixedString(4)",
+		"d", "Date",
+		"d32", "Date32",
+		"dt", "DateTime('UTC')",
+		"dt64", "DateTime64(6, 'UTC')",
+		"uuid", "UUID",
+		"ip4", "IPv4",
+		"ip6", "IPv6",
+		"n", "Nullable(Int32)",
+		"a", "Array(Nullable(String))",
+		"m", "Map(String, UInt16)",
+		"t", "Tuple(String, Bool)",
+		"e", "Enum16('a' = 1, 'b' = 1000)",
+		"dec", "Decimal(18, 3)",
+		"lcn", "LowCardinality(Nullable(String))",
+		"lcv", "LowCardinality(Nullable(String))",
+	)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456000, time.UTC)
+	var buf bytes.Buffer
+	e := NewRowBinaryWithNamesAndTypesEncoder(&buf, cols)
+	err := e.Encode(
+		200,
+		int64(-1),
+		new(big.Int).Lsh(big.NewInt(1), 255),
+		1.5,
+		"str",
+		[]byte("ab"),
+		ts,
+		time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		ts,
+		&ts,
+		"01234567-89ab-cdef-fedc-ba9876543210",
+		net.ParseIP("192.168.1.2"),
+		"::1",
+		(*int32)(nil),
+		[]*string{nil, ptr("x")},
+		map[string]uint16{"k": 7},
+		struct {
+			S string
+			B bool
+		}{"t", true},
+		"b",
+		"-12.5",
+		nil,
+		ptr("y"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	d := must(NewDecoder(&buf, click.FormatRowBinaryWithNamesAndTypes))
+	if !d.Next() {
+		t.Fatal(d.Err())
+	}
+	if !reflect.DeepEqual(d.Columns(), cols) {
+		t.Fatalf("columns: %v", d.Columns())
+	}
+	want := []any{
+		uint8(200),
+		int64(-1),
+		new(big.Int).Lsh(big.NewInt(1), 255),
+		float32(1.5),
+		"str",
+		"ab\x00\x00",
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ts,
+		"01234567-89ab-cdef-fedc-ba9876543210",
+		"192.168.1.2",
+		"::1",
+		nil,
+		[]any{nil, "x"},
+		map[any]any{"k": uint16(7)},
+		[]any{"t", true},
+		"b",
+		"-12.500",
+		nil,
+		"y",
+	}
+	got := d.Values()
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("column %s: got %#v, want %#v", cols[i].Name, got[i], want[i])
+		}
+	}
+	if d.Next() || d.Err() != nil {
+		t.Fatal("expected end of rows", d.Err())
+	}
+}
+
+func TestRowBinaryEncoder_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		typ   string
+		value any
+	}{
+		{"overflow", "UInt8", 256},
+		{"negative unsigned", "UInt64", -1},
+		{"null", "String", nil},
+		{"fixed string too long", "FixedString(2)", "abc"},
+		{"unknown enum", "Enum8('a' = 1)", "b"},
+		{"decimal digits", "Decimal(9, 2)", "1.234"},
+		{"decimal precision", "Decimal(3, 1)", 1000},
+		{"invalid UUID", "UUID", "not-a-uuid"},
+		{"IPv6 as IPv4", "IPv4", "::1"},
+		{"date out of range", "Date", time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"tuple size", "Tuple(UInt8, UInt8)", []any{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			e := NewRowBinaryEncoder(&buf, columns("c", tt.typ))
+			if err := e.Encode(tt.value); err == nil {
+				t.Fatal("expected error, got nothing")
+			}
+			if err := e.Flush(); err != nil || buf.Len() != 0 {
+				t.Fatal("invalid row is written", err, buf.Bytes())
+			}
+		})
+	}
+}
+
+func TestRowBinaryEncoder_EncodeStruct(t *testing.T) {
+	var buf bytes.Buffer
+	cols := columns("id", "UInt64", "name", "String", "tags", "Array(String)")
+	e := NewRowBinaryEncoder(&buf, cols)
+	if err := e.EncodeStruct(&event{ID: 1, Name: "a", Tags: []string{"x"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\x01\x00\x00\x00\x00\x00\x00\x00\x01a\x01\x01x"; buf.String() != want {
+		t.Fatalf("%q", buf.String())
+	}
+	if v := must(e.InsertQuery("events").BuildString()); v != "INSERT INTO events (id, name, tags) FORMAT RowBinary" {
+		t.Fatal(v)
+	}
+	if err := NewRowBinaryEncoder(&buf, columns("missing", "UInt8")).EncodeStruct(event{}); err == nil {
+		t.Fatal("expected error on column without field")
+	}
+}
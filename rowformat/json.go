@@ -0,0 +1,132 @@
+package rowformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/keuin/click/chtype"
+)
+
+type jsonEachRowReader struct {
+	d *json.Decoder
+}
+
+func newJSONEachRowReader(r io.Reader) *jsonEachRowReader {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return &jsonEachRowReader{d: d}
+}
+
+func (r *jsonEachRowReader) readRow() ([]Column, []any, error) {
+	tok, err := r.d.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected JSON object, got %v", tok)
+	}
+	var (
+		columns []Column
+		values  []any
+	)
+	// read key-value pairs one by one to preserve column order
+	for r.d.More() {
+		tok, err := r.d.Token()
+		if err != nil {
+			return nil, nil, unexpectedEOF(err)
+		}
+		var v any
+		if err := r.d.Decode(&v); err != nil {
+			return nil, nil, unexpectedEOF(err)
+		}
+		columns = append(columns, Column{Name: tok.(string)})
+		values = append(values, fromUntypedJSON(v))
+	}
+	if _, err := r.d.Token(); err != nil {
+		return nil, nil, unexpectedEOF(err)
+	}
+	return columns, values, nil
+}
+
+type jsonCompactEachRowReader struct {
+	d         *json.Decoder
+	withNames bool
+	withTypes bool
+	columns   []Column
+	typed     bool
+	done      bool
+}
+
+func newJSONCompactEachRowReader(r io.Reader, withNames, withTypes bool) *jsonCompactEachRowReader {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return &jsonCompactEachRowReader{d: d, withNames: withNames, withTypes: withTypes}
+}
+
+func (r *jsonCompactEachRowReader) readHeader() error {
+	r.done = true
+	if !r.withNames {
+		return nil
+	}
+	var names []string
+	if err := r.d.Decode(&names); err != nil {
+		return headerError(err)
+	}
+	r.columns = make([]Column, len(names))
+	for i := range names {
+		r.columns[i].Name = names[i]
+	}
+	if !r.withTypes {
+		return nil
+	}
+	var types []string
+	if err := r.d.Decode(&types); err != nil {
+		return headerError(err)
+	}
+	if len(types) != len(names) {
+		return fmt.Errorf("header has %d names but %d types", len(names), len(types))
+	}
+	for i := range types {
+		var err error
+		if r.columns[i].Type, err = chtype.Parse(types[i]); err != nil {
+			return fmt.Errorf("column %s: %w", names[i], err)
+		}
+	}
+	r.typed = true
+	return nil
+}
+
+func (r *jsonCompactEachRowReader) readRow() ([]Column, []any, error) {
+	if !r.done {
+		if err := r.readHeader(); err != nil {
+			return nil, nil, err
+		}
+	}
+	var row []any
+	if err := r.d.Decode(&row); err != nil {
+		return nil, nil, err
+	}
+	if r.columns != nil && len(row) != len(r.columns) {
+		return nil, nil, fmt.Errorf("expected %d fields, got %d", len(r.columns), len(row))
+	}
+	for i := range row {
+		if !r.typed {
+			row[i] = fromUntypedJSON(row[i])
+			continue
+		}
+		v, err := fromJSON(row[i], r.columns[i].Type)
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %s: %w", r.columns[i].Name, err)
+		}
+		row[i] = v
+	}
+	return r.columns, row, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
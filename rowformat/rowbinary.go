@@ -0,0 +1,414 @@
+package rowformat
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/keuin/click/chtype"
+)
+
+// maxStringSize limits length of strings and arrays read from RowBinary, to fail fast on corrupted input.
+const maxStringSize = 1 << 30
+
+// maxPrealloc limits elements allocated ahead of reading arrays and maps, which grow as elements are read,
+// so that corrupted lengths do not allocate huge memory.
+const maxPrealloc = 1024
+
+type rowBinaryReader struct {
+	r       *bufio.Reader
+	columns []Column
+	types   []*resolvedType
+	buf     [32]byte
+}
+
+// resolvedType is a type with enum values and time zones resolved once per column, instead of per value.
+type resolvedType struct {
+	chtype.Type
+	elems []*resolvedType
+	enum  map[int]string
+	loc   *time.Location
+}
+
+func resolveType(t chtype.Type) (*resolvedType, error) {
+	ret := &resolvedType{Type: t, elems: make([]*resolvedType, len(t.Elems))}
+	for i := range t.Elems {
+		var err error
+		if ret.elems[i], err = resolveType(t.Elems[i]); err != nil {
+			return nil, err
+		}
+	}
+	switch t.Name {
+	case "Enum8", "Enum16":
+		values, err := t.EnumValues()
+		if err != nil {
+			return nil, err
+		}
+		ret.enum = make(map[int]string, len(values))
+		for name, v := range values {
+			ret.enum[v] = name
+		}
+	case "DateTime", "DateTime32", "DateTime64":
+		var err error
+		if ret.loc, err = location(t); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (t *resolvedType) elem() *resolvedType {
+	return t.elems[len(t.elems)-1]
+}
+
+// preallocated returns the capacity to allocate for n elements.
+func preallocated(n int) int {
+	if n > maxPrealloc {
+		return maxPrealloc
+	}
+	return n
+}
+
+func newRowBinaryReader(r io.Reader) *rowBinaryReader {
+	return &rowBinaryReader{r: bufio.NewReader(r)}
+}
+
+func (r *rowBinaryReader) readHeader() error {
+	n, err := r.readLength()
+	if err != nil {
+		return headerError(err)
+	}
+	columns := make([]Column, n)
+	types := make([]*resolvedType, n)
+	for i := range columns {
+		if columns[i].Name, err = r.readString(); err != nil {
+			return headerError(unexpectedEOF(err))
+		}
+	}
+	for i := range columns {
+		typ, err := r.readString()
+		if err != nil {
+			return headerError(unexpectedEOF(err))
+		}
+		if columns[i].Type, err = chtype.Parse(typ); err != nil {
+			return fmt.Errorf("column %s: %w", columns[i].Name, err)
+		}
+		if types[i], err = resolveType(columns[i].Type); err != nil {
+			return fmt.Errorf("column %s: %w", columns[i].Name, err)
+		}
+	}
+	r.columns, r.types = columns, types
+	return nil
+}
+
+func (r *rowBinaryReader) readRow() ([]Column, []any, error) {
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return nil, nil, err
+		}
+	}
+	// distinguish end of result from truncated row
+	if _, err := r.r.Peek(1); err != nil {
+		return nil, nil, err
+	}
+	values := make([]any, len(r.columns))
+	for i, c := range r.columns {
+		v, err := r.readValue(r.types[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("column %s: %w", c.Name, unexpectedEOF(err))
+		}
+		values[i] = v
+	}
+	return r.columns, values, nil
+}
+
+func (r *rowBinaryReader) read(n int) ([]byte, error) {
+	b := r.buf[:n]
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r *rowBinaryReader) readLength() (int, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxStringSize {
+		return 0, fmt.Errorf("length %d exceeds limit", n)
+	}
+	return int(n), nil
+}
+
+func (r *rowBinaryReader) readString() (string, error) {
+	n, err := r.readLength()
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readValue reads a value of type t, see Decoder for Go types of values.
+func (r *rowBinaryReader) readValue(t *resolvedType) (any, error) {
+	if bits := t.IntBits(); bits > 0 {
+		b, err := r.read(bits / 8)
+		if err != nil {
+			return nil, err
+		}
+		return decodeInt(b, bits, !t.IsUnsigned()), nil
+	}
+	switch t.Name {
+	case "Nullable":
+		isNull, err := r.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if isNull != 0 {
+			return nil, nil
+		}
+		return r.readValue(t.elem())
+	case "LowCardinality", "SimpleAggregateFunction":
+		return r.readValue(t.elem())
+	case "Array":
+		n, err := r.readLength()
+		if err != nil {
+			return nil, err
+		}
+		ret := make([]any, 0, preallocated(n))
+		for i := 0; i < n; i++ {
+			v, err := r.readValue(t.elem())
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, v)
+		}
+		return ret, nil
+	case "Map":
+		n, err := r.readLength()
+		if err != nil {
+			return nil, err
+		}
+		ret := make(map[any]any, preallocated(n))
+		for i := 0; i < n; i++ {
+			k, err := r.readValue(t.elems[0])
+			if err != nil {
+				return nil, err
+			}
+			if ret[k], err = r.readValue(t.elems[1]); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case "Tuple":
+		ret := make([]any, len(t.Elems))
+		for i := range ret {
+			var err error
+			if ret[i], err = r.readValue(t.elems[i]); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case "Bool":
+		b, err := r.r.ReadByte()
+		return b != 0, err
+	case "Float32":
+		b, err := r.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case "Float64":
+		b, err := r.read(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case "String":
+		return r.readString()
+	case "FixedString":
+		n, err := t.FixedStringLength()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r.r, b); err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case "Date":
+		b, err := r.read(2)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(int64(binary.LittleEndian.Uint16(b))*secondsPerDay, 0).UTC(), nil
+	case "Date32":
+		b, err := r.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(int64(int32(binary.LittleEndian.Uint32(b)))*secondsPerDay, 0).UTC(), nil
+	case "DateTime", "DateTime32":
+		b, err := r.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(int64(binary.LittleEndian.Uint32(b)), 0).In(t.loc), nil
+	case "DateTime64":
+		b, err := r.read(8)
+		if err != nil {
+			return nil, err
+		}
+		ticks := int64(binary.LittleEndian.Uint64(b))
+		scale := pow10(t.DateTimePrecision())
+		return time.Unix(ticks/scale, ticks%scale*(1e9/scale)).In(t.loc), nil
+	case "UUID":
+		b, err := r.read(16)
+		if err != nil {
+			return nil, err
+		}
+		return decodeUUID(b), nil
+	case "IPv4":
+		b, err := r.read(4)
+		if err != nil {
+			return nil, err
+		}
+		return net.IPv4(b[3], b[2], b[1], b[0]).String(), nil
+	case "IPv6":
+		b, err := r.read(16)
+		if err != nil {
+			return nil, err
+		}
+		return net.IP(append([]byte{}, b...)).String(), nil
+	case "Enum8", "Enum16":
+		var v int
+		if t.Name == "Enum8" {
+			b, err := r.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			v = int(int8(b))
+		} else {
+			b, err := r.read(2)
+			if err != nil {
+				return nil, err
+			}
+			v = int(int16(binary.LittleEndian.Uint16(b)))
+		}
+		if name, ok := t.enum[v]; ok {
+			return name, nil
+		}
+		return nil, fmt.Errorf("unknown value %d of %s", v, t.Type)
+	}
+	if t.IsDecimal() {
+		_, scale, err := t.DecimalPrecisionScale()
+		if err != nil {
+			return nil, err
+		}
+		b, err := r.read(decimalSize(t.Type))
+		if err != nil {
+			return nil, err
+		}
+		return formatDecimal(decodeBigInt(b, true), scale), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t.Type)
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+func pow10(n int) int64 {
+	ret := int64(1)
+	for i := 0; i < n; i++ {
+		ret *= 10
+	}
+	return ret
+}
+
+// decodeInt decodes a little-endian integer.
+func decodeInt(b []byte, bits int, signed bool) any {
+	switch {
+	case bits == 8 && signed:
+		return int8(b[0])
+	case bits == 8:
+		return b[0]
+	case bits == 16 && signed:
+		return int16(binary.LittleEndian.Uint16(b))
+	case bits == 16:
+		return binary.LittleEndian.Uint16(b)
+	case bits == 32 && signed:
+		return int32(binary.LittleEndian.Uint32(b))
+	case bits == 32:
+		return binary.LittleEndian.Uint32(b)
+	case bits == 64 && signed:
+		return int64(binary.LittleEndian.Uint64(b))
+	case bits == 64:
+		return binary.LittleEndian.Uint64(b)
+	default:
+		return decodeBigInt(b, signed)
+	}
+}
+
+// decodeBigInt decodes a little-endian two's complement integer of any width.
+func decodeBigInt(b []byte, signed bool) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	v := new(big.Int).SetBytes(be)
+	if signed && len(b) > 0 && b[len(b)-1]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	return v
+}
+
+// decimalSize returns byte size of the underlying integer of decimal types.
+func decimalSize(t chtype.Type) int {
+	precision, _, _ := t.DecimalPrecisionScale()
+	switch {
+	case precision <= 9:
+		return 4
+	case precision <= 18:
+		return 8
+	case precision <= 38:
+		return 16
+	default:
+		return 32
+	}
+}
+
+// formatDecimal formats integer v scaled by 10^-scale, e.g. 12345 with scale 2 as `123.45`.
+func formatDecimal(v *big.Int, scale int) string {
+	s := new(big.Int).Abs(v).String()
+	if scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if v.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// decodeUUID decodes UUID stored as two little-endian UInt64 halves.
+func decodeUUID(b []byte) string {
+	var be [16]byte
+	for i := 0; i < 8; i++ {
+		be[7-i] = b[i]
+		be[15-i] = b[8+i]
+	}
+	s := hex.EncodeToString(be[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
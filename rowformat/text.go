@@ -0,0 +1,199 @@
+package rowformat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/keuin/click/chtype"
+)
+
+// textHeader reads optional names and types header rows of text formats,
+// and converts following rows with the types.
+type textHeader struct {
+	withNames bool
+	withTypes bool
+	columns   []Column
+	typed     bool
+	done      bool
+}
+
+// readHeader reads header rows with readFields, which returns raw fields of the next row.
+func (h *textHeader) readHeader(readFields func() ([]string, error)) error {
+	if h.done {
+		return nil
+	}
+	h.done = true
+	if !h.withNames {
+		return nil
+	}
+	names, err := readFields()
+	if err != nil {
+		return headerError(err)
+	}
+	h.columns = make([]Column, len(names))
+	for i := range names {
+		h.columns[i].Name = names[i]
+	}
+	if !h.withTypes {
+		return nil
+	}
+	types, err := readFields()
+	if err != nil {
+		return headerError(err)
+	}
+	if len(types) != len(names) {
+		return fmt.Errorf("header has %d names but %d types", len(names), len(types))
+	}
+	for i := range types {
+		if h.columns[i].Type, err = chtype.Parse(types[i]); err != nil {
+			return fmt.Errorf("column %s: %w", names[i], err)
+		}
+	}
+	h.typed = true
+	return nil
+}
+
+func headerError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("read header: %w", io.ErrUnexpectedEOF)
+	}
+	return fmt.Errorf("read header: %w", err)
+}
+
+// convert converts raw fields of a row. Fields are kept as strings if types are unknown.
+// isNull reports whether the field is a NULL value in the format.
+func (h *textHeader) convert(fields []string, isNull func(i int) bool) ([]any, error) {
+	if h.columns != nil && len(fields) != len(h.columns) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(h.columns), len(fields))
+	}
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		if isNull(i) {
+			continue
+		}
+		if !h.typed {
+			values[i] = f
+			continue
+		}
+		v, err := fromText(f, h.columns[i].Type)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", h.columns[i].Name, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+type tsvReader struct {
+	r *bufio.Reader
+	textHeader
+}
+
+func newTSVReader(r io.Reader, withNames, withTypes bool) *tsvReader {
+	return &tsvReader{
+		r:          bufio.NewReader(r),
+		textHeader: textHeader{withNames: withNames, withTypes: withTypes},
+	}
+}
+
+// readFields reads a line, returning escaped fields.
+func (r *tsvReader) readFields() ([]string, error) {
+	line, err := r.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(line, "\n"), "\t"), nil
+}
+
+func (r *tsvReader) readRow() ([]Column, []any, error) {
+	if err := r.readHeader(func() ([]string, error) {
+		fields, err := r.readFields()
+		if err != nil {
+			return nil, err
+		}
+		for i := range fields {
+			fields[i] = unescapeTSV(fields[i])
+		}
+		return fields, nil
+	}); err != nil {
+		return nil, nil, err
+	}
+	fields, err := r.readFields()
+	if err != nil {
+		return nil, nil, err
+	}
+	escaped := make([]bool, len(fields))
+	for i := range fields {
+		escaped[i] = fields[i] == `\N`
+		fields[i] = unescapeTSV(fields[i])
+	}
+	values, err := r.convert(fields, func(i int) bool { return escaped[i] })
+	return r.columns, values, err
+}
+
+// unescapeTSV unescapes a TabSeparated field.
+// See https://clickhouse.com/docs/interfaces/formats/TabSeparated#data-formatting
+func unescapeTSV(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '0':
+			sb.WriteByte(0)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+type csvReader struct {
+	r *csv.Reader
+	textHeader
+}
+
+func newCSVReader(r io.Reader, withNames, withTypes bool) *csvReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = false
+	return &csvReader{
+		r:          cr,
+		textHeader: textHeader{withNames: withNames, withTypes: withTypes},
+	}
+}
+
+func (r *csvReader) readRow() ([]Column, []any, error) {
+	if err := r.readHeader(r.r.Read); err != nil {
+		return nil, nil, err
+	}
+	fields, err := r.r.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := r.convert(fields, func(i int) bool {
+		// encoding/csv does not tell whether the field is quoted, so '\N' is always NULL in nullable columns
+		return fields[i] == `\N` && (!r.typed || r.columns[i].Type.IsNullable())
+	})
+	return r.columns, values, err
+}
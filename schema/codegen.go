@@ -0,0 +1,226 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/keuin/click/chtype"
+)
+
+// GenerateOptions controls code generation.
+type GenerateOptions struct {
+	// Package is the package name of generated code.
+	Package string
+	// Generator is the command name in the `Code generated by ... DO NOT EDIT.` header.
+	Generator string
+}
+
+// initialisms are upper-cased in Go identifiers, see https://go.dev/wiki/CodeReviewComments#initialisms
+var initialisms = map[string]bool{
+	"API": true, "DB": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "TCP": true, "TTL": true, "UDP": true, "UID": true, "URI": true, "URL": true,
+	"UTC": true, "UUID": true, "XML": true,
+}
+
+// Generate writes a Go file declaring, for each table, a click.Table constant, typed click.Column constants,
+// a slice of all columns, and a row struct with `ch` tags for rowformat.Decoder.Scan.
+// For table `db.page_views` with column `user_id`, it declares `PageViews`, `PageViewsUserID`,
+// `PageViewsColumns` and `PageViewsRow`. Names of tables in different databases are prefixed with the database
+// if ambiguous.
+func Generate(w io.Writer, s *Schema, opts GenerateOptions) error {
+	if !token.IsIdentifier(opts.Package) {
+		return fmt.Errorf("invalid package name: %q", opts.Package)
+	}
+	if len(s.Tables) == 0 {
+		return errors.New("no tables")
+	}
+	if opts.Generator == "" {
+		opts.Generator = "clickgen"
+	}
+	names := tableIdents(s.Tables)
+	var body bytes.Buffer
+	imports := make(map[string]bool)
+	used := make(map[string]bool)
+	for i, t := range s.Tables {
+		name := names[i]
+		for _, suffix := range []string{"", "Columns", "Row"} {
+			used[name+suffix] = true
+		}
+		fields := make([]string, len(t.Columns))
+		for j, c := range t.Columns {
+			field := uniqueIdent(identifier(string(c.Name)), fields[:j])
+			fields[j] = field
+			if used[name+field] {
+				return fmt.Errorf("table %s: identifier %s of column %s conflicts with other declarations",
+					t.Ref(), name+field, c.Name)
+			}
+			used[name+field] = true
+		}
+
+		fmt.Fprintf(&body, "// %s is table %s.\n", name, t.Ref())
+		fmt.Fprintf(&body, "const %s click.Table = %s\n\n", name, strconv.Quote(string(t.Ref())))
+		fmt.Fprintf(&body, "// columns of %s\nconst (\n", t.Ref())
+		for j, c := range t.Columns {
+			comment := c.Type.String()
+			if c.Comment != "" {
+				comment += ", " + oneLine(c.Comment)
+			}
+			fmt.Fprintf(&body, "%s%s click.Column = %s // %s\n", name, fields[j], strconv.Quote(string(c.Name)), comment)
+		}
+		fmt.Fprintf(&body, ")\n\n")
+		fmt.Fprintf(&body, "// %sColumns are all columns of %s in declaration order, e.g. `click.Select(%sColumns...)`.\n",
+			name, t.Ref(), name)
+		fmt.Fprintf(&body, "var %sColumns = []click.Expression{\n", name)
+		for j := range t.Columns {
+			fmt.Fprintf(&body, "%s%s,\n", name, fields[j])
+		}
+		fmt.Fprintf(&body, "}\n\n")
+		fmt.Fprintf(&body, "// %sRow is a row of %s.\n", name, t.Ref())
+		fmt.Fprintf(&body, "type %sRow struct {\n", name)
+		for j, c := range t.Columns {
+			fmt.Fprintf(&body, "%s %s `ch:%s`\n", fields[j], goType(c.Type, imports), strconv.Quote(string(c.Name)))
+		}
+		fmt.Fprintf(&body, "}\n\n")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by %s. DO NOT EDIT.\n\npackage %s\n\nimport (\n", opts.Generator, opts.Package)
+	for _, pkg := range []string{"math/big", "time"} {
+		if imports[pkg] {
+			fmt.Fprintf(&buf, "%s\n", strconv.Quote(pkg))
+		}
+	}
+	fmt.Fprintf(&buf, "\n\"github.com/keuin/click\"\n)\n\n")
+	buf.Write(body.Bytes())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format generated code: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// tableIdents names tables by table names, prefixed with database names if ambiguous.
+func tableIdents(tables []*Table) []string {
+	count := make(map[string]int)
+	for _, t := range tables {
+		count[identifier(t.Name)]++
+	}
+	ret := make([]string, len(tables))
+	for i, t := range tables {
+		name := identifier(t.Name)
+		if count[name] > 1 && t.Database != "" {
+			name = identifier(t.Database) + name
+		}
+		ret[i] = uniqueIdent(name, ret[:i])
+	}
+	return ret
+}
+
+// identifier converts a name like `user_id` or `http.status` to an exported Go identifier like `UserID` or `HTTPStatus`.
+func identifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	s := sb.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) || !unicode.IsUpper([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// uniqueIdent appends a number to name if it is used.
+func uniqueIdent(name string, used []string) string {
+	ret := name
+	for n := 2; ; n++ {
+		conflict := false
+		for _, u := range used {
+			if u == ret {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			return ret
+		}
+		ret = name + strconv.Itoa(n)
+	}
+}
+
+// goType returns the Go type decoded by rowformat.Decoder for the ClickHouse type, adding packages to import.
+func goType(t chtype.Type, imports map[string]bool) string {
+	switch t.Name {
+	case "LowCardinality", "SimpleAggregateFunction":
+		return goType(t.Elem(), imports)
+	case "Nullable":
+		typ := goType(t.Elem(), imports)
+		if strings.HasPrefix(typ, "*") || typ == "any" {
+			return typ
+		}
+		return "*" + typ
+	case "Array":
+		return "[]" + goType(t.Elem(), imports)
+	case "Map":
+		if len(t.Elems) != 2 {
+			return "any"
+		}
+		keyImports := make(map[string]bool)
+		k := goType(t.Elems[0], keyImports)
+		if strings.HasPrefix(k, "*") || strings.HasPrefix(k, "[]") || strings.HasPrefix(k, "map[") || k == "any" {
+			// keys must be comparable
+			k = "any"
+		} else {
+			for pkg := range keyImports {
+				imports[pkg] = true
+			}
+		}
+		return "map[" + k + "]" + goType(t.Elems[1], imports)
+	case "Bool":
+		return "bool"
+	case "Float32":
+		return "float32"
+	case "Float64":
+		return "float64"
+	case "UUID", "IPv4", "IPv6":
+		return "string"
+	}
+	switch {
+	case t.IsInteger():
+		if t.IntBits() > 64 {
+			imports["math/big"] = true
+			return "*big.Int"
+		}
+		if t.IsUnsigned() {
+			return "uint" + strconv.Itoa(t.IntBits())
+		}
+		return "int" + strconv.Itoa(t.IntBits())
+	case t.IsTemporal():
+		imports["time"] = true
+		return "time.Time"
+	case t.IsString(), t.IsDecimal(), t.IsEnum():
+		return "string"
+	default:
+		return "any"
+	}
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	dump := `{"database":"db","table":"page_views","name":"user_id","type":"UInt64"}
+{"database":"db","table":"page_views","name":"url","type":"LowCardinality(String)","comment":"page\nURL"}
+{"database":"db","table":"page_views","name":"ts","type":"DateTime64(3)"}
+{"database":"db","table":"page_views","name":"referer","type":"Nullable(String)"}
+{"database":"db","table":"page_views","name":"tags","type":"Array(String)"}
+{"database":"db","table":"page_views","name":"attrs","type":"Map(String, Float64)"}
+{"database":"db","table":"page_views","name":"big","type":"UInt256"}
+{"database":"db","table":"page_views","name":"1st","type":"Tuple(UInt8, String)"}
+{"database":"db","table":"users","name":"id","type":"UInt64"}
+{"database":"other","table":"users","name":"id","type":"UInt64"}
+`
+	s, err := Load(strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Generate(&buf, s, GenerateOptions{Package: "models"}); err != nil {
+		t.Fatal(err)
+	}
+	want := `// Code generated by clickgen. DO NOT EDIT.
+
+package models
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/keuin/click"
+)
+
+// PageViews is table db.page_views.
+const PageViews click.Table = "db.page_views"
+
+// columns of db.page_views
+const (
+	PageViewsUserID  click.Column = "user_id" // UInt64
+	PageViewsURL     click.Column = "url"     // LowCardinality(String), page URL
+	PageViewsTs      click.Column = "ts"      // DateTime64(3)
+	PageViewsReferer click.Column = "referer" // Nullable(String)
+	PageViewsTags    click.Column = "tags"    // Array(String)
+	PageViewsAttrs   click.Column = "attrs"   // Map(String, Float64)
+	PageViewsBig     click.Column = "big"     // UInt256
+	PageViewsX1st    click.Column = "1st"     // Tuple(UInt8, String)
+)
+
+// PageViewsColumns are all columns of db.page_views in declaration order, e.g. ` + "`click.Select(PageViewsColumns...)`" + `.
+var PageViewsColumns = []click.Expression{
+	PageViewsUserID,
+	PageViewsURL,
+	PageViewsTs,
+	PageViewsReferer,
+	PageViewsTags,
+	PageViewsAttrs,
+	PageViewsBig,
+	PageViewsX1st,
+}
+
+// PageViewsRow is a row of db.page_views.
+type PageViewsRow struct {
+	UserID  uint64             ` + "`ch:\"user_id\"`" + `
+	URL     string             ` + "`ch:\"url\"`" + `
+	Ts      time.Time          ` + "`ch:\"ts\"`" + `
+	Referer *string            ` + "`ch:\"referer\"`" + `
+	Tags    []string           ` + "`ch:\"tags\"`" + `
+	Attrs   map[string]float64 ` + "`ch:\"attrs\"`" + `
+	Big     *big.Int           ` + "`ch:\"big\"`" + `
+	X1st    any                ` + "`ch:\"1st\"`" + `
+}
+`
+	got := buf.String()
+	if !strings.HasPrefix(got, want) {
+		t.Fatalf("got:\n%s\nwant prefix:\n%s", got, want)
+	}
+	for _, decl := range []string{
+		"const DBUsers click.Table = \"db.users\"",
+		"const OtherUsers click.Table = \"other.users\"",
+		"OtherUsersID click.Column = \"id\"",
+		"type OtherUsersRow struct",
+	} {
+		if !strings.Contains(got, decl) {
+			t.Errorf("missing %q", decl)
+		}
+	}
+}
+
+func TestGenerate_Invalid(t *testing.T) {
+	s := &Schema{}
+	if err := Generate(&bytes.Buffer{}, s, GenerateOptions{Package: "models"}); err == nil {
+		t.Error("expected error on empty schema")
+	}
+	if err := s.AddColumn("t", Column{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Generate(&bytes.Buffer{}, s, GenerateOptions{Package: "my-models"}); err == nil {
+		t.Error("expected error on invalid package name")
+	}
+	// column `row` of table `t` conflicts with the row struct TRow
+	if err := s.AddColumn("t", Column{Name: "row"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Generate(&bytes.Buffer{}, s, GenerateOptions{Package: "models"}); err == nil {
+		t.Error("expected error on conflicting identifiers")
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	for name, want := range map[string]string{
+		"user_id":     "UserID",
+		"http.status": "HTTPStatus",
+		"camelCase":   "CamelCase",
+		"_x":          "X",
+		"2fa":         "X2fa",
+		"":            "X",
+	} {
+		if got := identifier(name); got != want {
+			t.Errorf("identifier(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
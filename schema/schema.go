@@ -0,0 +1,289 @@
+// Package schema models tables and columns of a ClickHouse database, loaded from dumps of system.columns
+// or DESCRIBE TABLE, and generates Go code with typed Column and Table constants.
+//
+// Dumps are produced by queries like
+//
+//	SELECT database, table, name, type, comment FROM system.columns WHERE database = 'db' FORMAT JSONEachRow
+//
+// in JSONEachRow, JSON, TabSeparatedWithNames or TabSeparatedWithNamesAndTypes format,
+// so that schemas can be loaded offline.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/keuin/click"
+	"github.com/keuin/click/chtype"
+	"github.com/keuin/click/internal/ident"
+	"github.com/keuin/click/rowformat"
+)
+
+// Column is a column of a table.
+type Column struct {
+	Name    click.Column
+	Type    chtype.Type
+	Comment string
+}
+
+// Table is a table with columns in their declaration order.
+type Table struct {
+	Database string
+	Name     string
+	Columns  []Column
+}
+
+// Ref returns the table name used in queries, `db.name` or `name` if the database is unknown.
+func (t *Table) Ref() click.Table {
+	if t.Database == "" {
+		return click.Table(t.Name)
+	}
+	return click.Table(t.Database + "." + t.Name)
+}
+
+// Column returns the column of the name.
+func (t *Table) Column(name click.Column) (Column, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Schema is a set of tables, ordered by database and table names.
+type Schema struct {
+	Tables []*Table
+}
+
+// Table looks up a table. Names without database, like `events`, match tables of any database if not ambiguous.
+// Qualified names, like `db.events`, fall back to tables loaded without database, e.g. from DESCRIBE TABLE.
+// Backquotes in names are ignored.
+func (s *Schema) Table(name click.Table) (*Table, bool) {
+	db, n := ident.SplitTable(string(name))
+	t, ok := s.lookup(func(t *Table) bool {
+		return t.Name == n && (db == "" || t.Database == db)
+	})
+	if !ok && db != "" {
+		return s.lookup(func(t *Table) bool {
+			return t.Name == n && t.Database == ""
+		})
+	}
+	return t, ok
+}
+
+// lookup finds the only table matching the predicate.
+func (s *Schema) lookup(match func(t *Table) bool) (*Table, bool) {
+	var found *Table
+	for _, t := range s.Tables {
+		if !match(t) {
+			continue
+		}
+		if found != nil {
+			return nil, false
+		}
+		found = t
+	}
+	return found, found != nil
+}
+
+// TableColumns implements click.Schema, so that queries can be type checked with SelectBuilder.TypeCheck.
+func (s *Schema) TableColumns(table click.Table) ([]click.ColumnInfo, bool) {
+	t, ok := s.Table(table)
+	if !ok {
+		return nil, false
+	}
+	ret := make([]click.ColumnInfo, len(t.Columns))
+	for i, c := range t.Columns {
+		ret[i] = click.ColumnInfo{Name: c.Name, Type: c.Type}
+	}
+	return ret, true
+}
+
+// AddColumn appends a column to the table, creating the table if absent.
+func (s *Schema) AddColumn(table click.Table, c Column) error {
+	db, name := ident.SplitTable(string(table))
+	if name == "" {
+		return errors.New("empty table name")
+	}
+	if c.Name == "" {
+		return fmt.Errorf("table %s: empty column name", table)
+	}
+	var t *Table
+	for _, v := range s.Tables {
+		if v.Database == db && v.Name == name {
+			t = v
+			break
+		}
+	}
+	if t == nil {
+		t = &Table{Database: db, Name: name}
+		s.Tables = append(s.Tables, t)
+		sort.SliceStable(s.Tables, func(i, j int) bool {
+			if s.Tables[i].Database != s.Tables[j].Database {
+				return s.Tables[i].Database < s.Tables[j].Database
+			}
+			return s.Tables[i].Name < s.Tables[j].Name
+		})
+	}
+	if _, ok := t.Column(c.Name); ok {
+		return fmt.Errorf("table %s: duplicated column %s", table, c.Name)
+	}
+	t.Columns = append(t.Columns, c)
+	return nil
+}
+
+// Load reads a system.columns dump, whose rows have `database` (optional), `table`, `name` and `type` fields,
+// and an optional `comment` field. The format is detected from the content, see the package documentation.
+func Load(r io.Reader) (*Schema, error) {
+	return load(r, "")
+}
+
+// LoadDescribe reads a DESCRIBE TABLE dump of the table, whose rows have `name` and `type` fields.
+func LoadDescribe(table click.Table, r io.Reader) (*Schema, error) {
+	if table == "" {
+		return nil, errors.New("empty table name")
+	}
+	return load(r, table)
+}
+
+func load(r io.Reader, table click.Table) (*Schema, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := readRows(data)
+	if err != nil {
+		return nil, err
+	}
+	s := &Schema{}
+	for i, row := range rows {
+		t := table
+		if t == "" {
+			if row["table"] == "" {
+				return nil, fmt.Errorf("row %d: no table, use LoadDescribe for DESCRIBE TABLE dumps", i+1)
+			}
+			t = click.Table(row["table"])
+			if row["database"] != "" {
+				t = click.Table(row["database"]) + "." + t
+			}
+		}
+		typ, err := chtype.Parse(row["type"])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: column %s: %w", i+1, row["name"], err)
+		}
+		if err := s.AddColumn(t, Column{Name: click.Column(row["name"]), Type: typ, Comment: row["comment"]}); err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+	}
+	return s, nil
+}
+
+// readRows decodes rows of the dump into string fields.
+func readRows(data []byte) ([]map[string]string, error) {
+	// trailing spaces are kept, since trailing tabs separate empty TSV fields
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 {
+		return nil, errors.New("empty dump")
+	}
+	var objects []map[string]any
+	switch data[0] {
+	case '[':
+		if err := json.Unmarshal(data, &objects); err != nil {
+			return nil, fmt.Errorf("decode JSON array: %w", err)
+		}
+	case '{':
+		d := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var v map[string]any
+			if err := d.Decode(&v); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("decode JSON: %w", err)
+			}
+			// output of FORMAT JSON, like {"meta": [...], "data": [{...}, ...], "rows": 1}
+			if rows, ok := v["data"].([]any); ok && v["meta"] != nil {
+				for _, row := range rows {
+					obj, ok := row.(map[string]any)
+					if !ok {
+						return nil, errors.New("decode JSON: rows in data are not objects")
+					}
+					objects = append(objects, obj)
+				}
+				continue
+			}
+			objects = append(objects, v)
+		}
+	default:
+		return readTSV(data)
+	}
+	ret := make([]map[string]string, len(objects))
+	for i, obj := range objects {
+		ret[i] = make(map[string]string, len(obj))
+		for k, v := range obj {
+			if s, ok := v.(string); ok {
+				ret[i][k] = s
+			}
+		}
+	}
+	return ret, nil
+}
+
+// readTSV decodes TabSeparatedWithNames, or TabSeparatedWithNamesAndTypes if the second line is types of fields.
+func readTSV(data []byte) ([]map[string]string, error) {
+	format := click.FormatTabSeparatedWithNames
+	lines := strings.SplitN(string(data), "\n", 3)
+	if len(lines) >= 2 && isTypesLine(lines[0], lines[1]) {
+		format = click.FormatTabSeparatedWithNamesAndTypes
+	}
+	d, err := rowformat.NewDecoder(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, err
+	}
+	var ret []map[string]string
+	for d.Next() {
+		var row map[string]any
+		if err := d.Scan(&row); err != nil {
+			return nil, err
+		}
+		m := make(map[string]string, len(row))
+		for k, v := range row {
+			if v != nil {
+				m[k] = fmt.Sprint(v)
+			}
+		}
+		ret = append(ret, m)
+	}
+	if err := d.Err(); err != nil {
+		return nil, fmt.Errorf("decode TSV: %w", err)
+	}
+	return ret, nil
+}
+
+// isTypesLine reports whether the second line of a TSV dump is the types line,
+// where types of name, type, table and database fields are String.
+func isTypesLine(header, line string) bool {
+	names := strings.Split(strings.TrimRight(header, "\r"), "\t")
+	types := strings.Split(strings.TrimRight(line, "\r"), "\t")
+	if len(names) != len(types) {
+		return false
+	}
+	for i, name := range names {
+		t, err := chtype.Parse(types[i])
+		if err != nil {
+			return false
+		}
+		switch name {
+		case "name", "type", "table", "database":
+			if !t.Base().IsString() {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keuin/click"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		dump string
+	}{
+		{
+			name: "JSONEachRow",
+			dump: `{"database":"db","table":"events","name":"id","type":"UInt64","comment":""}
+{"database":"db","table":"events","name":"ts","type":"DateTime64(3, 'UTC')","comment":"event time"}
+{"database":"db","table":"users","name":"id","type":"UInt64","comment":""}
+`,
+		},
+		{
+			name: "JSON",
+			dump: `{"meta":[{"name":"database","type":"String"}],"data":[
+{"database":"db","table":"events","name":"id","type":"UInt64"},
+{"database":"db","table":"events","name":"ts","type":"DateTime64(3, 'UTC')","comment":"event time"},
+{"database":"db","table":"users","name":"id","type":"UInt64"}],"rows":3}`,
+		},
+		{
+			name: "JSON array",
+			dump: `[{"database":"db","table":"events","name":"id","type":"UInt64"},
+{"database":"db","table":"events","name":"ts","type":"DateTime64(3, 'UTC')","comment":"event time"},
+{"database":"db","table":"users","name":"id","type":"UInt64"}]`,
+		},
+		{
+			name: "TabSeparatedWithNames",
+			dump: "database\ttable\tname\ttype\tcomment\n" +
+				"db\tevents\tid\tUInt64\t\n" +
+				"db\tevents\tts\tDateTime64(3, \\'UTC\\')\tevent time\n" +
+				"db\tusers\tid\tUInt64\t\n",
+		},
+		{
+			name: "TabSeparatedWithNamesAndTypes",
+			dump: "database\ttable\tname\ttype\tcomment\tposition\n" +
+				"String\tString\tString\tString\tString\tUInt64\n" +
+				"db\tevents\tid\tUInt64\t\t1\n" +
+				"db\tevents\tts\tDateTime64(3, \\'UTC\\')\tevent time\t2\n" +
+				"db\tusers\tid\tUInt64\t\t1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Load(strings.NewReader(tt.dump))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s.Tables) != 2 {
+				t.Fatalf("tables: %+v", s.Tables)
+			}
+			events, ok := s.Table("events")
+			if !ok || events.Ref() != "db.events" || len(events.Columns) != 2 {
+				t.Fatalf("events: %+v", events)
+			}
+			ts, ok := events.Column("ts")
+			if !ok || ts.Type.String() != "DateTime64(3, 'UTC')" || ts.Comment != "event time" {
+				t.Fatalf("ts: %+v", ts)
+			}
+			if _, ok := s.Table("db.users"); !ok {
+				t.Fatal("db.users not found")
+			}
+			if _, ok := s.Table("other.users"); ok {
+				t.Fatal("other.users found")
+			}
+		})
+	}
+}
+
+func TestLoadDescribe(t *testing.T) {
+	dump := "name\ttype\tdefault_type\tdefault_expression\tcomment\tcodec_expression\tttl_expression\n" +
+		"id\tUInt64\t\t\t\t\t\n" +
+		"tags\tArray(LowCardinality(String))\t\t\ttag list\t\t\n"
+	s, err := LoadDescribe("db.events", strings.NewReader(dump))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, ok := s.Table("`db`.`events`")
+	if !ok || len(events.Columns) != 2 || events.Columns[1].Type.Elem().Name != "LowCardinality" {
+		t.Fatalf("events: %+v", events)
+	}
+	if _, err := Load(strings.NewReader(dump)); err == nil {
+		t.Fatal("expected error loading DESCRIBE dump without table name")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for name, dump := range map[string]string{
+		"empty":        "",
+		"invalid type": `{"table":"t","name":"a","type":"Array("}`,
+		"duplicated":   `{"table":"t","name":"a","type":"UInt8"}{"table":"t","name":"a","type":"UInt8"}`,
+		"no name":      `{"table":"t","type":"UInt8"}`,
+		"bad json":     `{"table":`,
+	} {
+		if _, err := Load(strings.NewReader(dump)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSchema_AmbiguousTable(t *testing.T) {
+	s := &Schema{}
+	for _, table := range []click.Table{"b.t", "a.t"} {
+		if err := s.AddColumn(table, Column{Name: "x"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Tables[0].Database != "a" {
+		t.Fatalf("tables are not sorted: %+v", s.Tables)
+	}
+	if _, ok := s.Table("t"); ok {
+		t.Fatal("ambiguous table found")
+	}
+	if tbl, ok := s.Table("b.t"); !ok || tbl.Database != "b" {
+		t.Fatalf("b.t: %+v", tbl)
+	}
+}
+
+func TestSchema_TableWithoutDatabase(t *testing.T) {
+	s := &Schema{}
+	if err := s.AddColumn("events", Column{Name: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if tbl, ok := s.Table("db.events"); !ok || tbl.Name != "events" {
+		t.Fatalf("db.events: %+v", tbl)
+	}
+	if err := s.AddColumn("other.events", Column{Name: "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if tbl, ok := s.Table("other.events"); !ok || tbl.Database != "other" {
+		t.Fatalf("other.events: %+v", tbl)
+	}
+	if tbl, ok := s.Table("db.events"); !ok || tbl.Database != "" {
+		t.Fatalf("db.events: %+v", tbl)
+	}
+}
+
+func TestSchema_TableColumns(t *testing.T) {
+	s, err := Load(strings.NewReader(`{"database":"db","table":"events","name":"ts","type":"DateTime"}
+{"database":"db","table":"events","name":"name","type":"String"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := click.Select(click.Column("name")).From(click.Table("events")).
+		Where(click.Equal(click.Column("ts"), click.Column("name"))).
+		TypeCheck(s)
+	if _, err := q.BuildString(); err == nil || !strings.Contains(err.Error(), "cannot compare DateTime with String") {
+		t.Fatalf("BuildString() error = %v", err)
+	}
+}
@@ -9,13 +9,8 @@ import (
 
 func Select(values ...Expression) *SelectBuilder {
 	// clone to avoid unexpected modification on the argument
-	// not using slices.Clone to keep compatible with 1.18
-	selects := make([]Expression, len(values))
-	for i := range selects {
-		selects[i] = values[i]
-	}
 	return &SelectBuilder{
-		selects: selects,
+		selects: appendCopy(nil, values...),
 	}
 }
 
@@ -27,9 +22,16 @@ type SelectQuery interface {
 // SelectBuilder implements builder pattern for constructing SELECT SQLs.
 // Its zero value is a ready-to-use empty builder.
 // It's recommended to use Select as a shortcut.
+//
+// Methods adding or replacing clauses, like Where, OrderBy and Limit, modify the builder in place and return it.
+// To derive several queries from a shared base query, clone the base first, e.g. `base.Clone().Limit(10)`,
+// otherwise derived queries modify the base and each other. Methods documented as not modifying the builder,
+// like CountQuery, Simplify and Build, return new builders or snapshots.
+// A SelectBuilder is not safe for concurrent modification.
 type SelectBuilder struct {
 	selects  []Expression // Expression | SelectExpression
 	from     FromExpression
+	prewhere Expression
 	where    Expression
 	groupBy  []Expression
 	orderBy  []Expression // Expression | OrderByExpression
@@ -41,6 +43,8 @@ type SelectBuilder struct {
 	format   Format
 	style    RenderStyle
 	styleSet bool
+	schema   Schema
+	settings querySettings
 }
 
 func (s *SelectBuilder) FromExpression(style RenderStyle) (string, error) {
@@ -65,7 +69,7 @@ func (s *SelectBuilder) FromExpression(style RenderStyle) (string, error) {
 }
 
 func (s *SelectBuilder) Select(values ...Expression) *SelectBuilder {
-	s.selects = append(s.selects, values...)
+	s.selects = appendCopy(s.selects, values...)
 	return s
 }
 
@@ -79,18 +83,25 @@ func (s *SelectBuilder) Sample(v float64) *SelectBuilder {
 	return s
 }
 
+// Prewhere sets PREWHERE clause, which is evaluated before reading other columns of MergeTree tables.
+// See https://clickhouse.com/docs/sql-reference/statements/select/prewhere
+func (s *SelectBuilder) Prewhere(prewhere Expression) *SelectBuilder {
+	s.prewhere = prewhere
+	return s
+}
+
 func (s *SelectBuilder) Where(where Expression) *SelectBuilder {
 	s.where = where
 	return s
 }
 
 func (s *SelectBuilder) GroupBy(values ...Expression) *SelectBuilder {
-	s.groupBy = append(s.groupBy, values...)
+	s.groupBy = appendCopy(s.groupBy, values...)
 	return s
 }
 
 func (s *SelectBuilder) OrderBy(values ...Expression) *SelectBuilder {
-	s.orderBy = append(s.orderBy, values...)
+	s.orderBy = appendCopy(s.orderBy, values...)
 	return s
 }
 
@@ -99,6 +110,118 @@ func (s *SelectBuilder) Having(value Expression) *SelectBuilder {
 	return s
 }
 
+// AndWhere appends a predicate to WHERE clause with AND. If WHERE clause is absent, it is equivalent to Where.
+func (s *SelectBuilder) AndWhere(where Expression) *SelectBuilder {
+	s.where = andExpressions(s.where, where)
+	return s
+}
+
+// AndPrewhere appends a predicate to PREWHERE clause with AND. If PREWHERE clause is absent, it is equivalent to Prewhere.
+func (s *SelectBuilder) AndPrewhere(prewhere Expression) *SelectBuilder {
+	s.prewhere = andExpressions(s.prewhere, prewhere)
+	return s
+}
+
+// AndHaving appends a predicate to HAVING clause with AND. If HAVING clause is absent, it is equivalent to Having.
+func (s *SelectBuilder) AndHaving(value Expression) *SelectBuilder {
+	s.having = andExpressions(s.having, value)
+	return s
+}
+
+func andExpressions(l, r Expression) Expression {
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	if c, ok := l.(concatenatedExpression); ok && c.Op == OpAnd {
+		return concatenatedExpression{
+			Op:   OpAnd,
+			Expr: appendCopy(c.Expr, r),
+		}
+	}
+	return And(l, r)
+}
+
+// ReplaceSelect replaces all expressions in SELECT clause.
+func (s *SelectBuilder) ReplaceSelect(values ...Expression) *SelectBuilder {
+	s.selects = appendCopy(nil, values...)
+	return s
+}
+
+// ReplaceGroupBy replaces all expressions in GROUP BY clause.
+func (s *SelectBuilder) ReplaceGroupBy(values ...Expression) *SelectBuilder {
+	s.groupBy = appendCopy(nil, values...)
+	return s
+}
+
+// ReplaceOrderBy replaces all expressions in ORDER BY clause.
+func (s *SelectBuilder) ReplaceOrderBy(values ...Expression) *SelectBuilder {
+	s.orderBy = appendCopy(nil, values...)
+	return s
+}
+
+func (s *SelectBuilder) ClearSelect() *SelectBuilder {
+	s.selects = nil
+	return s
+}
+
+func (s *SelectBuilder) ClearSample() *SelectBuilder {
+	s.sample = 0
+	return s
+}
+
+func (s *SelectBuilder) ClearPrewhere() *SelectBuilder {
+	s.prewhere = nil
+	return s
+}
+
+func (s *SelectBuilder) ClearWhere() *SelectBuilder {
+	s.where = nil
+	return s
+}
+
+func (s *SelectBuilder) ClearGroupBy() *SelectBuilder {
+	s.groupBy = nil
+	return s
+}
+
+func (s *SelectBuilder) ClearOrderBy() *SelectBuilder {
+	s.orderBy = nil
+	return s
+}
+
+func (s *SelectBuilder) ClearHaving() *SelectBuilder {
+	s.having = nil
+	return s
+}
+
+func (s *SelectBuilder) ClearLimit() *SelectBuilder {
+	s.limit = 0
+	s.hasLimit = false
+	return s
+}
+
+func (s *SelectBuilder) ClearOffset() *SelectBuilder {
+	s.offset = 0
+	return s
+}
+
+func (s *SelectBuilder) ClearFormat() *SelectBuilder {
+	s.format = ""
+	return s
+}
+
+// CountQuery derives a query counting total rows of the query, ignoring ORDER BY, LIMIT, OFFSET and FORMAT.
+// The query is always wrapped as a nested query, since aggregates, DISTINCT and arrayJoin in the select list
+// change the number of rows, e.g. `SELECT sum(x) FROM t` returns a single row.
+// The builder itself is not modified.
+func (s *SelectBuilder) CountQuery() *SelectBuilder {
+	c := s.Clone().ClearOrderBy().ClearLimit().ClearOffset().ClearFormat()
+	return Select(Count()).From(c)
+}
+
 func (s *SelectBuilder) Limit(n int) *SelectBuilder {
 	s.limit = n
 	s.hasLimit = true
@@ -110,17 +233,59 @@ func (s *SelectBuilder) Offset(n int) *SelectBuilder {
 	return s
 }
 
+// Setting adds a setting to SETTINGS clause, e.g. `Setting(SettingMaxExecutionTime, 30)`.
+// See https://clickhouse.com/docs/sql-reference/statements/select#settings-in-select-query
+func (s *SelectBuilder) Setting(name string, value any) *SelectBuilder {
+	s.settings = s.settings.set(name, value)
+	return s
+}
+
+func (s *SelectBuilder) ClearSettings() *SelectBuilder {
+	s.settings = nil
+	return s
+}
+
 func (s *SelectBuilder) Format(f Format) *SelectBuilder {
 	s.format = f
 	return s
 }
 
 func (s *SelectBuilder) BuildString() (string, error) {
-	style := defaultStyle
+	q, err := s.prepare()
+	if err != nil {
+		return "", err
+	}
+	return q.buildString(q.renderStyle())
+}
+
+func (s *SelectBuilder) renderStyle() RenderStyle {
 	if s.styleSet {
-		style = s.style
+		return s.style
+	}
+	return defaultStyle
+}
+
+// prepare returns the query to be rendered, with DefaultPolicy and DefaultGuardrail applied,
+// and types checked if enabled.
+func (s *SelectBuilder) prepare() (*SelectBuilder, error) {
+	q := s
+	var err error
+	if !DefaultPolicy.empty() {
+		if q, err = DefaultPolicy.Apply(q); err != nil {
+			return nil, err
+		}
+	}
+	if !DefaultGuardrail.empty() {
+		if q, err = DefaultGuardrail.Apply(q); err != nil {
+			return nil, err
+		}
+	}
+	if q.schema != nil {
+		if err := CheckTypes(q, q.schema); err != nil {
+			return nil, err
+		}
 	}
-	return s.buildString(style)
+	return q, nil
 }
 
 // buildString ignores style settings in SelectBuilder itself, using the RenderStyle in argument.
@@ -137,13 +302,11 @@ func (s *SelectBuilder) buildString(style RenderStyle) (string, error) {
 	}
 	p.BeginClause("SELECT")
 	for i := range s.selects {
-		var v string
+		v := s.selects[i].Expression
 		if expr, ok := s.selects[i].(SelectExpression); ok {
-			v = expr.SelectExpression()
-		} else {
-			v = s.selects[i].Expression()
+			v = expr.SelectExpression
 		}
-		p.AddClauseArgument(v, i == len(s.selects)-1)
+		p.AddClauseArgument(p.expand(s.selects[i], v), i == len(s.selects)-1)
 	}
 	if s.from != nil {
 		p.BeginClause("FROM")
@@ -151,8 +314,7 @@ func (s *SelectBuilder) buildString(style RenderStyle) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("build FROM clause: %w", err)
 		}
-		_, isTable := s.from.(Table)
-		p.AddClauseArgumentPrefix(fromExpr, true, isTable)
+		p.AddClauseArgumentPrefix(fromExpr, true, isInlineFrom(s.from))
 	}
 	if s.sample > 0 {
 		if s.from == nil {
@@ -161,30 +323,35 @@ func (s *SelectBuilder) buildString(style RenderStyle) (string, error) {
 		p.BeginClause("SAMPLE")
 		p.AddClauseArgument(strconv.FormatFloat(s.sample, 'f', -1, 64), true)
 	}
+	if s.prewhere != nil {
+		if s.from == nil {
+			return "", errors.New("PREWHERE is present while FROM is absent")
+		}
+		p.BeginClause("PREWHERE")
+		p.AddClauseArgument(p.expand(s.prewhere, s.prewhere.Expression), true)
+	}
 	if s.where != nil {
 		p.BeginClause("WHERE")
-		p.AddClauseArgument(s.where.Expression(), true)
+		p.AddClauseArgument(p.expand(s.where, s.where.Expression), true)
 	}
 	if len(s.groupBy) > 0 {
 		p.BeginClause("GROUP BY")
 		for i := range s.groupBy {
-			p.AddClauseArgument(s.groupBy[i].Expression(), i == len(s.groupBy)-1)
+			p.AddClauseArgument(p.expand(s.groupBy[i], s.groupBy[i].Expression), i == len(s.groupBy)-1)
 		}
 	}
 	if s.having != nil {
 		p.BeginClause("HAVING")
-		p.AddClauseArgument(s.having.Expression(), true)
+		p.AddClauseArgument(p.expand(s.having, s.having.Expression), true)
 	}
 	if len(s.orderBy) > 0 {
 		p.BeginClause("ORDER BY")
 		for i := range s.orderBy {
-			var v string
+			v := s.orderBy[i].Expression
 			if expr, ok := s.orderBy[i].(OrderByExpression); ok {
-				v = expr.OrderByExpression()
-			} else {
-				v = s.orderBy[i].Expression()
+				v = expr.OrderByExpression
 			}
-			p.AddClauseArgument(v, i == len(s.orderBy)-1)
+			p.AddClauseArgument(p.expand(s.orderBy[i], v), i == len(s.orderBy)-1)
 		}
 	}
 	if s.hasLimit {
@@ -195,6 +362,14 @@ func (s *SelectBuilder) buildString(style RenderStyle) (string, error) {
 		p.BeginClause("OFFSET")
 		p.AddClauseArgument(strconv.Itoa(s.offset), true)
 	}
+	if len(s.settings) > 0 {
+		settings, err := s.settings.list()
+		if err != nil {
+			return "", fmt.Errorf("build SETTINGS clause: %w", err)
+		}
+		p.BeginClause("SETTINGS")
+		p.AddClauseArgument(settings, true)
+	}
 	if s.format != "" {
 		p.BeginClause("FORMAT")
 		p.AddClauseArgument(string(s.format), true)
@@ -212,12 +387,30 @@ func (s *SelectBuilder) PrettyPrint(b ...bool) *SelectBuilder {
 	return s
 }
 
+// Clone returns a deep copy of the builder. Nested queries built with SelectBuilder are cloned as well,
+// so modifications on the clone never affect the original builder, and vice versa.
+// Clone a builder before deriving queries from it, since other methods modify the builder in place.
+func (s *SelectBuilder) Clone() *SelectBuilder {
+	c := *s
+	c.selects = cloneExpressions(s.selects)
+	c.groupBy = cloneExpressions(s.groupBy)
+	c.orderBy = cloneExpressions(s.orderBy)
+	c.from = cloneFrom(s.from)
+	return &c
+}
+
+// Build validates the builder and returns a snapshot of it.
+// Later modifications on the builder do not affect the returned query.
+// DefaultPolicy is applied to the returned query.
 func (s *SelectBuilder) Build() (SelectQuery, error) {
-	_, err := s.BuildString()
+	q, err := s.prepare()
 	if err != nil {
 		return nil, err
 	}
-	return (*sealedSelect)(s), nil
+	if _, err := q.buildString(q.renderStyle()); err != nil {
+		return nil, err
+	}
+	return (*sealedSelect)(q.Clone()), nil
 }
 
 type sqlPrinter struct {
@@ -252,10 +445,50 @@ func (p *sqlPrinter) AddClauseArgumentPrefix(v string, lastElem, prefix bool) {
 	p.sb.WriteString(p.Style.ArgumentSuffix)
 }
 
+// expand renders multi-line expressions like CASE in pretty style, aligned with the clause argument.
+// Otherwise, it returns the single-line rendering of e by render, so that e is rendered only once.
+func (p *sqlPrinter) expand(e Expression, render func() string) string {
+	if p.Style.Indent != "" {
+		linePrefix := strings.Repeat(p.Style.Indent, p.Style.IndentLevel) + p.Style.ArgumentPrefix
+		if s, ok := multiline(e, linePrefix, p.Style.Indent); ok {
+			return s
+		}
+	}
+	return render()
+}
+
 func (p *sqlPrinter) String() string {
 	return strings.TrimSpace(p.sb.String())
 }
 
+// appendCopy is append which always allocates a new slice, so builders sharing the same underlying array,
+// e.g. shallow copies of a query template, never overwrite elements of each other.
+func appendCopy(s []Expression, values ...Expression) []Expression {
+	ret := make([]Expression, 0, len(s)+len(values))
+	ret = append(ret, s...)
+	return append(ret, values...)
+}
+
+func cloneExpressions(s []Expression) []Expression {
+	if s == nil {
+		return nil
+	}
+	return appendCopy(s)
+}
+
+// cloneFrom deep copies nested builders in FROM clause. Other FROM expressions, including sealed queries, are immutable.
+func cloneFrom(f FromExpression) FromExpression {
+	switch f := f.(type) {
+	case *SelectBuilder:
+		return f.Clone()
+	case tableAlias:
+		f.source = cloneFrom(f.source)
+		return f
+	default:
+		return f
+	}
+}
+
 // sealedSelect is complete, valid and unmodifiable SelectBuilder.
 type sealedSelect SelectBuilder
 
@@ -263,7 +496,8 @@ func (s sealedSelect) FromExpression(style RenderStyle) (string, error) {
 	return (*SelectBuilder)(&s).FromExpression(style)
 }
 
+// String renders the query as validated in Build, DefaultPolicy is not applied again.
 func (s sealedSelect) String() string {
-	str := must((*SelectBuilder)(&s).BuildString())
-	return str
+	b := (*SelectBuilder)(&s)
+	return must(b.buildString(b.renderStyle()))
 }
@@ -2,6 +2,7 @@ package click
 
 import (
 	"encoding/hex"
+	"strings"
 	"testing"
 )
 
@@ -143,3 +144,136 @@ FROM
 	}
 	t.Log(v)
 }
+
+func TestSelectBuilder_Clone(t *testing.T) {
+	base := Select(Column("a")).From(Table("tbl")).GroupBy(Column("a"))
+	c1 := base.Clone().Select(Count()).Where(GreaterThan(Column("a"), LiteralExpression(1)))
+	c2 := base.Clone().Select(Sum(Column("b"))).OrderBy(Column("a"))
+	if v := must(base.BuildString()); v != "SELECT a FROM tbl GROUP BY a" {
+		t.Fatal(v)
+	}
+	if v := must(c1.BuildString()); v != "SELECT a, count() FROM tbl WHERE (a > 1) GROUP BY a" {
+		t.Fatal(v)
+	}
+	if v := must(c2.BuildString()); v != "SELECT a, sum(b) FROM tbl GROUP BY a ORDER BY a" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_ShallowCopyAppend(t *testing.T) {
+	// builders sharing backing arrays must not overwrite elements of each other
+	base := Select(Column("a"), Column("b"), Column("c"))
+	c1, c2 := *base, *base
+	c1.Select(Column("x"))
+	c2.Select(Column("y"))
+	if v := must(c1.BuildString()); v != "SELECT a, b, c, x" {
+		t.Fatal(v)
+	}
+	if v := must(c2.BuildString()); v != "SELECT a, b, c, y" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_Build_Snapshot(t *testing.T) {
+	inner := Select(Column("a")).From(Table("tbl"))
+	b := Select(Count()).From(inner)
+	q := must(b.Build())
+	b.Select(Column("b")).Where(LiteralExpression(1))
+	inner.Select(Column("c")).Limit(1)
+	if v := q.String(); v != "SELECT count() FROM (\nSELECT a FROM tbl\n)" {
+		t.Fatalf("%q", v)
+	}
+}
+
+func TestSelectBuilder_AndWhere(t *testing.T) {
+	s := Select(Column("a")).From(Table("tbl")).
+		AndWhere(Equal(Column("a"), LiteralExpression(1))).
+		AndWhere(Equal(Column("b"), LiteralExpression(2))).
+		AndWhere(Equal(Column("c"), LiteralExpression(3)))
+	if v := must(s.BuildString()); v != "SELECT a FROM tbl WHERE ((a = 1) AND (b = 2) AND (c = 3))" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_AndHaving(t *testing.T) {
+	s := Select(Column("a"), Count()).From(Table("tbl")).GroupBy(Column("a")).
+		Having(GreaterThan(Count(), LiteralExpression(1))).
+		AndHaving(LessThan(Count(), LiteralExpression(10)))
+	if v := must(s.BuildString()); v != "SELECT a, count() FROM tbl GROUP BY a HAVING ((count() > 1) AND (count() < 10))" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_ReplaceAndClear(t *testing.T) {
+	s := Select(Column("a")).From(Table("tbl")).Sample(0.1).
+		Where(LiteralExpression(1)).GroupBy(Column("a")).Having(LiteralExpression(1)).
+		OrderBy(Column("a")).Limit(10).Offset(5).Format(FormatCSV)
+	s.ReplaceSelect(Column("b")).ReplaceGroupBy(Column("b")).ReplaceOrderBy(Desc(Column("b")))
+	if v := must(s.BuildString()); v != "SELECT b FROM tbl SAMPLE 0.1 WHERE 1 GROUP BY b HAVING 1 ORDER BY b DESC LIMIT 10 OFFSET 5 FORMAT CSV" {
+		t.Fatal(v)
+	}
+	s.ClearSample().ClearWhere().ClearGroupBy().ClearHaving().ClearOrderBy().ClearLimit().ClearOffset().ClearFormat()
+	if v := must(s.BuildString()); v != "SELECT b FROM tbl" {
+		t.Fatal(v)
+	}
+	if _, err := s.ClearSelect().BuildString(); err == nil {
+		t.Fatal("expected error, got nothing")
+	}
+}
+
+func TestSelectBuilder_CountQuery(t *testing.T) {
+	s := Select(Column("a"), Column("b")).From(Table("tbl")).
+		Where(Equal(Column("a"), LiteralExpression(1))).
+		OrderBy(Column("b")).Limit(10).Offset(20)
+	if v := must(s.CountQuery().BuildString()); v != "SELECT count() FROM (\nSELECT a, b FROM tbl WHERE (a = 1)\n)" {
+		t.Fatal(v)
+	}
+	if v := must(s.BuildString()); v != "SELECT a, b FROM tbl WHERE (a = 1) ORDER BY b LIMIT 10 OFFSET 20" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_CountQuery_Aggregate(t *testing.T) {
+	s := Select(Fn("sum", Column("x"))).From(Table("t"))
+	if v := must(s.CountQuery().BuildString()); v != "SELECT count() FROM (\nSELECT sum(x) FROM t\n)" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_CountQuery_GroupBy(t *testing.T) {
+	s := Select(Column("a"), Count()).From(Table("tbl")).GroupBy(Column("a")).OrderBy(Column("a")).Limit(10)
+	if v := must(s.CountQuery().PrettyPrint().BuildString()); v != "SELECT\n\tcount()\nFROM\n(\n\tSELECT\n\t\ta,\n\t\tcount()\n\tFROM\n\t\ttbl\n\tGROUP BY\n\t\ta\n)" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_Prewhere(t *testing.T) {
+	s := Select(Column("a")).From(Table("tbl")).Sample(0.5).Prewhere(Column("p")).AndPrewhere(Column("q")).Where(Column("w"))
+	if v := must(s.BuildString()); v != "SELECT a FROM tbl SAMPLE 0.5 PREWHERE (p AND q) WHERE w" {
+		t.Fatal(v)
+	}
+	if v := must(s.ClearPrewhere().BuildString()); v != "SELECT a FROM tbl SAMPLE 0.5 WHERE w" {
+		t.Fatal(v)
+	}
+	if _, err := Select(Column("a")).Prewhere(Column("p")).BuildString(); err == nil {
+		t.Fatal("expected error, got nothing")
+	}
+}
+
+func TestSelectBuilder_Setting(t *testing.T) {
+	s := Select(Column("a")).From(Table("tbl")).Limit(10).
+		Setting(SettingMaxExecutionTime, 30).Setting("use_query_cache", true).Setting(SettingMaxExecutionTime, 10).
+		Format(FormatJSONEachRow)
+	if v := must(s.BuildString()); v != "SELECT a FROM tbl LIMIT 10 SETTINGS max_execution_time = 10, use_query_cache = 1 FORMAT JSONEachRow" {
+		t.Fatal(v)
+	}
+	if v := must(s.Clone().PrettyPrint().BuildString()); !strings.Contains(v, "\nSETTINGS\n\tmax_execution_time = 10, use_query_cache = 1\n") {
+		t.Fatal(v)
+	}
+	if v := must(s.ClearSettings().BuildString()); v != "SELECT a FROM tbl LIMIT 10 FORMAT JSONEachRow" {
+		t.Fatal(v)
+	}
+	if _, err := Select(Column("a")).Setting("x", []int{1}).BuildString(); err == nil {
+		t.Fatal("expected error on unsupported setting value")
+	}
+}
@@ -0,0 +1,108 @@
+package click
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// common query settings, see https://clickhouse.com/docs/operations/settings/settings
+const (
+	// SettingMutationsSync makes ALTER mutations wait: 0 is asynchronous, 1 waits on the current server, 2 waits on all replicas.
+	SettingMutationsSync = "mutations_sync"
+	// SettingLightweightDeletesSync is the same as SettingMutationsSync, but for lightweight DELETE.
+	SettingLightweightDeletesSync = "lightweight_deletes_sync"
+	// SettingMaxExecutionTime is the query timeout in seconds, see Limits.MaxExecutionTime.
+	SettingMaxExecutionTime = "max_execution_time"
+)
+
+// querySettings is SETTINGS clause, in the order of first assignment.
+type querySettings []querySetting
+
+type querySetting struct {
+	name  string
+	value any
+}
+
+// set assigns the setting, replacing the previous value of the same name.
+func (s querySettings) set(name string, value any) querySettings {
+	ret := make(querySettings, 0, len(s)+1)
+	replaced := false
+	for _, v := range s {
+		if v.name == name {
+			v.value = value
+			replaced = true
+		}
+		ret = append(ret, v)
+	}
+	if !replaced {
+		ret = append(ret, querySetting{name: name, value: value})
+	}
+	return ret
+}
+
+// get returns the value of the setting.
+func (s querySettings) get(name string) (any, bool) {
+	for _, v := range s {
+		if v.name == name {
+			return v.value, true
+		}
+	}
+	return nil, false
+}
+
+// clause renders ` SETTINGS a = 1, b = 'x'`, or empty string if there are no settings.
+// Values must be strings, numbers or booleans.
+func (s querySettings) clause() (string, error) {
+	if len(s) == 0 {
+		return "", nil
+	}
+	list, err := s.list()
+	if err != nil {
+		return "", err
+	}
+	return " SETTINGS " + list, nil
+}
+
+// list renders `a = 1, b = 'x'`.
+func (s querySettings) list() (string, error) {
+	var sb strings.Builder
+	for i, v := range s {
+		if v.name == "" {
+			return "", errors.New("empty setting name")
+		}
+		value, err := settingValue(v.value)
+		if err != nil {
+			return "", fmt.Errorf("setting %s: %w", v.name, err)
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(v.name)
+		sb.WriteString(" = ")
+		sb.WriteString(value)
+	}
+	return sb.String(), nil
+}
+
+func settingValue(v any) (string, error) {
+	if v == nil {
+		return "", errors.New("NULL value")
+	}
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.String:
+		return quoted(fmt.Sprint(v)).Expression(), nil
+	case reflect.Bool:
+		if reflect.ValueOf(v).Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
@@ -76,7 +76,8 @@ func (q SimpleQuery) Build() (SelectQuery, error) {
 			b.Where(And(wheres...))
 		}
 	}
-	return sealedSelect(b), nil
+	// DefaultPolicy, DefaultGuardrail and type checking are applied as SelectBuilder.Build
+	return b.Build()
 }
 
 func (q SimpleQuery) BuildString() (string, error) {
@@ -0,0 +1,247 @@
+package click
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+)
+
+// True is the ClickHouse boolean literal `true`.
+func True() Expression {
+	return LiteralExpression(true)
+}
+
+// False is the ClickHouse boolean literal `false`.
+func False() Expression {
+	return LiteralExpression(false)
+}
+
+// Simplify returns an equivalent but simpler expression:
+//   - nested AND and OR of the same operator are flattened, and duplicated operands are removed
+//   - `true` and `false` operands of AND and OR are folded, e.g. `(a AND false)` becomes `false`
+//   - IN and NOT IN of a single-element tuple become = and !=, if the element is a non-NULL number, string or boolean
+//   - comparisons of two literals of the same kind (numbers, quoted strings or booleans) are folded into `true` or `false`
+//
+// Comparisons involving NULL are never folded, since they are NULL in ClickHouse.
+// The original expression is not modified.
+func Simplify(e Expression) Expression {
+	return rewriteExpression(e, simplifyNode)
+}
+
+// Simplify returns a copy of the builder with expressions in all clauses simplified, see Simplify.
+// WHERE, PREWHERE and HAVING clauses which are always true are removed.
+// The builder itself is not modified.
+func (s *SelectBuilder) Simplify() *SelectBuilder {
+	c := s.rewrite(simplifyNode)
+	for _, e := range []*Expression{&c.prewhere, &c.where, &c.having} {
+		if v, ok := constantBool(*e); ok && v {
+			*e = nil
+		}
+	}
+	return c
+}
+
+// simplifyNode simplifies e, assuming its children are already simplified.
+func simplifyNode(e Expression) Expression {
+	switch e := e.(type) {
+	case concatenatedExpression:
+		if e.Op == OpAnd || e.Op == OpOr {
+			return simplifyLogical(e.Op, e.Expr)
+		}
+	case BinaryExpression:
+		// NULL elements (with transform_null_in), subqueries and arrays are not equivalent to comparisons
+		if t, ok := e.RightOperand.(Tuple); ok && len(t) == 1 && scalarLiteral(t[0]) {
+			switch e.Operator {
+			case "IN":
+				return simplifyNode(Equal(e.LeftOperand, t[0]))
+			case "NOT IN":
+				return simplifyNode(NotEqual(e.LeftOperand, t[0]))
+			}
+		}
+		if v, ok := foldComparison(e); ok {
+			if v {
+				return True()
+			}
+			return False()
+		}
+	}
+	return e
+}
+
+// simplifyLogical flattens, deduplicates and folds operands of AND or OR.
+// For AND, `true` is the identity and `false` is absorbing, and vice versa for OR.
+func simplifyLogical(op Operator, operands []Expression) Expression {
+	identity := op == OpAnd
+	var terms []Expression
+	seen := make(map[string]bool)
+	var add func(e Expression) bool
+	add = func(e Expression) bool {
+		if c, ok := e.(concatenatedExpression); ok && c.Op == op {
+			for _, sub := range c.Expr {
+				if !add(sub) {
+					return false
+				}
+			}
+			return true
+		}
+		if v, ok := constantBool(e); ok {
+			return v == identity
+		}
+		if s := e.Expression(); !seen[s] {
+			seen[s] = true
+			terms = append(terms, e)
+		}
+		return true
+	}
+	for _, e := range operands {
+		if !add(e) {
+			return LiteralExpression(!identity)
+		}
+	}
+	switch len(terms) {
+	case 0:
+		return LiteralExpression(identity)
+	case 1:
+		return terms[0]
+	default:
+		return concatenatedExpression{Op: op, Expr: terms}
+	}
+}
+
+// scalarLiteral reports whether e is a literal or bound argument of a non-NULL number, string or boolean.
+func scalarLiteral(e Expression) bool {
+	v, quoted, ok := InspectLiteral(e)
+	if !ok {
+		// bound strings are values, not SQL expressions
+		v, ok = InspectArg(e)
+		quoted = true
+	}
+	if !ok || v == nil {
+		return false
+	}
+	if _, ok := v.(bool); ok {
+		return true
+	}
+	if _, ok := numberValue(v); ok {
+		return true
+	}
+	_, ok = stringValue(v)
+	return ok && quoted
+}
+
+// constantBool reports the value of boolean literals.
+func constantBool(e Expression) (value, ok bool) {
+	v, quoted, ok := InspectLiteral(e)
+	if !ok || quoted {
+		return false, false
+	}
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// foldComparison evaluates comparisons of two literals of the same kind.
+func foldComparison(e BinaryExpression) (result, ok bool) {
+	l, lQuoted, ok1 := InspectLiteral(e.LeftOperand)
+	r, rQuoted, ok2 := InspectLiteral(e.RightOperand)
+	if !ok1 || !ok2 || l == nil || r == nil || lQuoted != rQuoted {
+		return false, false
+	}
+	var cmp int
+	if lQuoted {
+		ls, ok1 := stringValue(l)
+		rs, ok2 := stringValue(r)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		cmp = compareStrings(ls, rs)
+	} else if lb, ok := l.(bool); ok {
+		rb, ok := r.(bool)
+		if !ok {
+			return false, false
+		}
+		cmp = compareBools(lb, rb)
+	} else {
+		ln, ok1 := numberValue(l)
+		rn, ok2 := numberValue(r)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		cmp = ln.Cmp(rn)
+	}
+	switch e.Operator {
+	case "=":
+		return cmp == 0, true
+	case "!=":
+		return cmp != 0, true
+	case "<":
+		return cmp < 0, true
+	case "<=":
+		return cmp <= 0, true
+	case ">":
+		return cmp > 0, true
+	case ">=":
+		return cmp >= 0, true
+	default:
+		return false, false
+	}
+}
+
+func stringValue(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return "", false
+	}
+	return rv.String(), true
+}
+
+// numberValue converts integers, floats and json.Number to an exact rational number of the rendered value.
+// Infinities and NaN are not comparable and rejected.
+func numberValue(v any) (*big.Rat, bool) {
+	if n, ok := v.(json.Number); ok {
+		return new(big.Rat).SetString(n.String())
+	}
+	if _, ok := v.(fmt.Stringer); ok {
+		// rendered with String() by LiteralExpression, e.g. time.Duration, which is not a number in SQL
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Rat).SetInt64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(rv.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+		// the value as rendered, so that float32(0.1) equals 0.1, like in SQL
+		return new(big.Rat).SetString(fmt.Sprint(v))
+	default:
+		return nil, false
+	}
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	default:
+		return 1
+	}
+}
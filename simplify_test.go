@@ -0,0 +1,143 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	a, b, c := Column("a"), Column("b"), Column("c")
+	tests := []struct {
+		name string
+		expr Expression
+		want string
+	}{
+		{
+			name: "flatten",
+			expr: And(a, And(b, And(c, a))),
+			want: "(a AND b AND c)",
+		},
+		{
+			name: "different operators are not flattened",
+			expr: And(a, Or(b, Or(c, b))),
+			want: "(a AND (b OR c))",
+		},
+		{
+			name: "duplicates",
+			expr: Or(Equal(a, LiteralExpression(1)), Equal(a, LiteralExpression(1))),
+			want: "(a = 1)",
+		},
+		{
+			name: "floats of different types",
+			expr: And(a, Equal(LiteralExpression(float32(0.1)), LiteralExpression(0.1))),
+			want: "a",
+		},
+		{
+			name: "single element IN",
+			expr: In(a, Tuple{LiteralExpressionQuoted("x")}),
+			want: "(a = 'x')",
+		},
+		{
+			name: "single element NOT IN",
+			expr: NotIn(a, Tuple{LiteralExpression(1)}),
+			want: "(a != 1)",
+		},
+		{
+			name: "single element IN of bound value",
+			expr: In(a, Tuple{Arg(1)}),
+			want: "(a = 1)",
+		},
+		{
+			name: "single NULL element IN",
+			expr: In(a, Tuple{LiteralExpression("NULL")}),
+			want: "(a IN (NULL))",
+		},
+		{
+			name: "single NULL element NOT IN",
+			expr: NotIn(a, Tuple{Arg(nil)}),
+			want: "(a NOT IN (NULL))",
+		},
+		{
+			name: "single subquery element IN",
+			expr: In(a, Tuple{LiteralExpression("SELECT b FROM t")}),
+			want: "(a IN (SELECT b FROM t))",
+		},
+		{
+			name: "single array element IN",
+			expr: In(a, Tuple{Fn("array", LiteralExpression(1), LiteralExpression(2))}),
+			want: "(a IN (array(1, 2)))",
+		},
+		{
+			name: "single column element IN",
+			expr: In(a, Tuple{c}),
+			want: "(a IN (c))",
+		},
+		{
+			name: "multiple element IN",
+			expr: In(a, Tuple{LiteralExpression(1), LiteralExpression(2)}),
+			want: "(a IN (1, 2))",
+		},
+		{
+			name: "constant guard",
+			expr: And(Equal(LiteralExpression(1), LiteralExpression(1)), a),
+			want: "a",
+		},
+		{
+			name: "constant false",
+			expr: And(a, LessThan(LiteralExpression(2), LiteralExpression(1.5))),
+			want: "false",
+		},
+		{
+			name: "constant true in OR",
+			expr: Or(a, NotEqual(LiteralExpressionQuoted("x"), LiteralExpressionQuoted("y"))),
+			want: "true",
+		},
+		{
+			name: "false in OR",
+			expr: Or(a, False(), In(LiteralExpression(1), Tuple{LiteralExpression(2)})),
+			want: "a",
+		},
+		{
+			name: "different kinds are not folded",
+			expr: Equal(LiteralExpression(1), LiteralExpressionQuoted("1")),
+			want: "(1 = '1')",
+		},
+		{
+			name: "NULL is not folded",
+			expr: Equal(LiteralExpression[any](nil), LiteralExpression[any](nil)),
+			want: "(<nil> = <nil>)",
+		},
+		{
+			name: "nested in function",
+			expr: If(And(True(), a), In(b, Tuple{LiteralExpression(2)}), Equal(LiteralExpression(uint64(18446744073709551615)), LiteralExpression(int64(-1)))),
+			want: "if(a, (b = 2), false)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Simplify(tt.expr).Expression(); got != tt.want {
+				t.Errorf("Simplify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAndOr_Empty(t *testing.T) {
+	if v := And().Expression(); v != "true" {
+		t.Fatal(v)
+	}
+	if v := Or().Expression(); v != "false" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelectBuilder_Simplify(t *testing.T) {
+	s := Select(Column("a")).From(Table("tbl")).
+		Where(And(Equal(LiteralExpression(1), LiteralExpression(1)), And())).
+		Having(And(Column("b"), In(Column("c"), Tuple{LiteralExpression(1)})))
+	if v := must(s.Simplify().BuildString()); v != "SELECT a FROM tbl HAVING (b AND (c = 1))" {
+		t.Fatal(v)
+	}
+	if v := must(s.BuildString()); v != "SELECT a FROM tbl WHERE ((1 = 1) AND true) HAVING (b AND (c IN (1)))" {
+		t.Fatalf("original query is modified: %s", v)
+	}
+}
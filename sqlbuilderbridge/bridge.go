@@ -0,0 +1,92 @@
+// Package sqlbuilderbridge makes click and github.com/huandu/go-sqlbuilder interoperable.
+// Bound values (see click.Arg) are passed as go-sqlbuilder arguments, and vice versa, instead of being inlined.
+package sqlbuilderbridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/keuin/click"
+)
+
+// Builder adapts a click expression as sqlbuilder.Builder, which can be built alone,
+// or be used as an argument of other builders, e.g. `sb.Where(sb.Var(Builder(expr)))`.
+// Its flavor is sqlbuilder.ClickHouse.
+func Builder(e click.Expression) sqlbuilder.Builder {
+	return builder{render: func(ph click.Placeholder) (string, []any, error) {
+		sql, args := click.ExpressionArgs(e, ph)
+		return sql, args, nil
+	}}
+}
+
+// Query adapts a query built with click as sqlbuilder.Builder, e.g. to be used as a subquery.
+// Its flavor is sqlbuilder.ClickHouse.
+func Query(q click.SelectQuery) (sqlbuilder.Builder, error) {
+	// validate the query ahead, since sqlbuilder.Builder cannot return errors
+	if _, _, err := click.BuildArgs(q, click.PlaceholderQuestion); err != nil {
+		return nil, err
+	}
+	return builder{render: func(ph click.Placeholder) (string, []any, error) {
+		return click.BuildArgs(q, ph)
+	}}, nil
+}
+
+type builder struct {
+	render func(ph click.Placeholder) (string, []any, error)
+}
+
+func (b builder) Build() (string, []any) {
+	return b.BuildWithFlavor(sqlbuilder.ClickHouse)
+}
+
+func (b builder) BuildWithFlavor(flavor sqlbuilder.Flavor, initialArg ...any) (string, []any) {
+	sql, args, err := b.render(placeholder(flavor, len(initialArg)))
+	if err != nil {
+		// the query is validated in Query, so this never happens
+		panic(err)
+	}
+	return sql, append(initialArg, args...)
+}
+
+func (b builder) Flavor() sqlbuilder.Flavor {
+	return sqlbuilder.ClickHouse
+}
+
+// placeholder returns placeholder style of the flavor, numbering arguments after existing ones.
+// See sqlbuilder.Args.CompileWithFlavor.
+func placeholder(flavor sqlbuilder.Flavor, offset int) click.Placeholder {
+	switch flavor {
+	case sqlbuilder.PostgreSQL:
+		return func(n int) string { return fmt.Sprintf("$%d", offset+n) }
+	case sqlbuilder.SQLServer:
+		return func(n int) string { return fmt.Sprintf("@p%d", offset+n) }
+	case sqlbuilder.Oracle:
+		return func(n int) string { return fmt.Sprintf(":%d", offset+n) }
+	default:
+		return click.PlaceholderQuestion
+	}
+}
+
+// Expression adapts sqlbuilder.Builder as a click expression, rendered in ClickHouse flavor.
+// Arguments of the builder are kept as bound values.
+func Expression(b sqlbuilder.Builder) click.Expression {
+	sql, args := b.BuildWithFlavor(sqlbuilder.ClickHouse)
+	return click.Raw(sql, args...)
+}
+
+// Condition converts conditions created with sqlbuilder.Cond into a click expression, concatenated with AND.
+//
+//	cond := sqlbuilder.NewCond()
+//	click.Select(...).Where(Condition(cond, cond.Equal("a", 1), cond.GreaterThan("b", 2)))
+func Condition(cond *sqlbuilder.Cond, exprs ...string) click.Expression {
+	if len(exprs) == 0 {
+		panic("empty conditions")
+	}
+	format := exprs[0]
+	if len(exprs) > 1 {
+		format = "(" + strings.Join(exprs, " AND ") + ")"
+	}
+	sql, args := cond.Args.CompileWithFlavor(format, sqlbuilder.ClickHouse)
+	return click.Raw(sql, args...)
+}
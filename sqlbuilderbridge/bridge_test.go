@@ -0,0 +1,72 @@
+package sqlbuilderbridge
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/keuin/click"
+)
+
+func TestBuilder(t *testing.T) {
+	e := click.And(
+		click.Equal(click.Column("a"), click.Arg("x")),
+		click.GreaterThan(click.Column("b"), click.Arg(1)),
+	)
+	sb := sqlbuilder.PostgreSQL.NewSelectBuilder()
+	sb.Select("id").From("tbl").Where(sb.Equal("c", 0), sb.Var(Builder(e)))
+	sql, args := sb.Build()
+	if sql != "SELECT id FROM tbl WHERE c = $1 AND ((a = $2) AND (b > $3))" {
+		t.Fatal(sql)
+	}
+	if !reflect.DeepEqual(args, []any{0, "x", 1}) {
+		t.Fatal(args)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	q, err := click.Select(click.Column("id")).From(click.Table("tbl")).
+		Where(click.Equal(click.Column("name"), click.LiteralExpressionQuoted("n"))).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb := sqlbuilder.ClickHouse.NewSelectBuilder()
+	sb.Select("count()").From(sb.BuilderAs(b, "t")).Where(sb.GreaterThan("id", 10))
+	sql, args := sb.Build()
+	if sql != "SELECT count() FROM (SELECT id FROM tbl WHERE (name = ?)) AS t WHERE id > ?" {
+		t.Fatal(sql)
+	}
+	if !reflect.DeepEqual(args, []any{"n", 10}) {
+		t.Fatal(args)
+	}
+}
+
+func TestExpression(t *testing.T) {
+	cond := sqlbuilder.NewCond()
+	where := click.And(
+		Condition(cond, cond.Equal("a", "it's"), cond.In("b", 1, 2)),
+		Expression(sqlbuilder.Build("c LIKE $0", "x%")),
+		click.Equal(click.Column("d"), click.Arg(3)),
+	)
+	q, err := click.Select(click.Column("id")).From(click.Table("tbl")).Where(where).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sql, args, err := click.BuildArgs(q, click.PlaceholderQuestion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sql != "SELECT id FROM tbl WHERE ((a = ? AND b IN (?, ?)) AND c LIKE ? AND (d = ?))" {
+		t.Fatal(sql)
+	}
+	if !reflect.DeepEqual(args, []any{"it's", 1, 2, "x%", 3}) {
+		t.Fatal(args)
+	}
+	if v := q.String(); v != `SELECT id FROM tbl WHERE ((a = 'it\'s' AND b IN (1, 2)) AND c LIKE 'x%' AND (d = 3))` {
+		t.Fatal(v)
+	}
+}
@@ -27,3 +27,35 @@ var (
 		ArgumentDelimiter: ",",
 	}
 )
+
+// Statement is a query built by this package, e.g. *SelectBuilder, *AlterTableBuilder, *CreateViewBuilder or *DeleteBuilder.
+type Statement interface {
+	BuildString() (string, error)
+}
+
+// PrettyString renders the statement in multiple lines if supported, see SelectBuilder.PrettyPrint.
+// The statement itself is not modified.
+func PrettyString(s Statement) (string, error) {
+	switch s := s.(type) {
+	case *SelectBuilder:
+		return s.Clone().PrettyPrint().BuildString()
+	case *AlterTableBuilder:
+		return s.clone().PrettyPrint().BuildString()
+	case *CreateViewBuilder:
+		return s.clone().PrettyPrint().BuildString()
+	case *CreateDictionaryBuilder:
+		return s.clone().PrettyPrint().BuildString()
+	default:
+		return s.BuildString()
+	}
+}
+
+// RawString renders the statement as written. Unlike BuildString of *SelectBuilder, DefaultPolicy, DefaultGuardrail
+// and type checking are not applied, so the result does not depend on global settings.
+// Other statements are rendered by BuildString.
+func RawString(s Statement) (string, error) {
+	if s, ok := s.(*SelectBuilder); ok {
+		return s.buildString(s.renderStyle())
+	}
+	return s.BuildString()
+}
@@ -0,0 +1,208 @@
+package click
+
+import (
+	"errors"
+	"strings"
+)
+
+// TableFunction is a ClickHouse table function call used as a FROM source, e.g. `numbers(10)`.
+// Arguments are rendered as-is, use LiteralExpressionQuoted for string arguments.
+// See https://clickhouse.com/docs/sql-reference/table-functions
+func TableFunction(name string, args ...Expression) FromExpression {
+	// clone to avoid unexpected modification on the argument
+	return tableFunction{
+		name: name,
+		args: appendCopy(nil, args...),
+	}
+}
+
+type tableFunction struct {
+	name string
+	args []Expression
+}
+
+func (f tableFunction) FromExpression(_ RenderStyle) (string, error) {
+	if f.name == "" {
+		return "", errors.New("empty table function name")
+	}
+	var sb strings.Builder
+	sb.WriteString(f.name)
+	sb.WriteByte('(')
+	for i := range f.args {
+		if i != 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(f.args[i].Expression())
+	}
+	sb.WriteByte(')')
+	return sb.String(), nil
+}
+
+// Remote reads table `database.table` from remote servers, without creating a Distributed table.
+// See https://clickhouse.com/docs/sql-reference/table-functions/remote
+func Remote(addresses, database, table string) FromExpression {
+	return TableFunction("remote", quoted(addresses), quoted(database), quoted(table))
+}
+
+// RemoteWithAuth is Remote with explicit user and password.
+func RemoteWithAuth(addresses, database, table, user, password string) FromExpression {
+	return TableFunction("remote", quoted(addresses), quoted(database), quoted(table), quoted(user), quoted(password))
+}
+
+// RemoteSecure is Remote over a secured connection.
+func RemoteSecure(addresses, database, table string) FromExpression {
+	return TableFunction("remoteSecure", quoted(addresses), quoted(database), quoted(table))
+}
+
+// Cluster reads table `database.table` from all shards of a configured cluster.
+// See https://clickhouse.com/docs/sql-reference/table-functions/cluster
+func Cluster(cluster, database, table string) FromExpression {
+	return TableFunction("cluster", quoted(cluster), quoted(database), quoted(table))
+}
+
+// ClusterAllReplicas is Cluster but reads from all replicas of each shard.
+func ClusterAllReplicas(cluster, database, table string) FromExpression {
+	return TableFunction("clusterAllReplicas", quoted(cluster), quoted(database), quoted(table))
+}
+
+// Numbers generates a single UInt64 column `number` with values [0, n).
+// See https://clickhouse.com/docs/sql-reference/table-functions/numbers
+func Numbers(n uint64) FromExpression {
+	return TableFunction("numbers", LiteralExpression(n))
+}
+
+// NumbersRange generates a single UInt64 column `number` with values [offset, offset+n).
+func NumbersRange(offset, n uint64) FromExpression {
+	return TableFunction("numbers", LiteralExpression(offset), LiteralExpression(n))
+}
+
+// Merge reads all tables in database whose names match the regexp.
+// See https://clickhouse.com/docs/sql-reference/table-functions/merge
+func Merge(database, tablesRegexp string) FromExpression {
+	return TableFunction("merge", quoted(database), quoted(tablesRegexp))
+}
+
+// GenerateRandom generates random rows with given table structure, e.g. `a UInt8, b String`.
+// Optional arguments are random_seed, max_string_length and max_array_length, in order.
+// See https://clickhouse.com/docs/sql-reference/table-functions/generate
+func GenerateRandom(structure string, options ...int64) FromExpression {
+	args := []Expression{quoted(structure)}
+	for _, v := range options {
+		args = append(args, LiteralExpression(v))
+	}
+	return TableFunction("generateRandom", args...)
+}
+
+// S3 reads objects from S3 compatible storage.
+// Format and structure are optional, empty values are omitted. Structure requires format.
+// See https://clickhouse.com/docs/sql-reference/table-functions/s3
+func S3(path string, format Format, structure string) FromExpression {
+	return fileLikeFunction{name: "s3", path: path, format: format, structure: structure}
+}
+
+// S3WithCredentials is S3 with explicit access key.
+func S3WithCredentials(path, accessKeyID, secretAccessKey string, format Format, structure string) FromExpression {
+	return fileLikeFunction{
+		name:        "s3",
+		path:        path,
+		credentials: []string{accessKeyID, secretAccessKey},
+		format:      format,
+		structure:   structure,
+	}
+}
+
+// File reads files in the server's user_files directory.
+// Format and structure are optional, empty values are omitted. Structure requires format.
+// See https://clickhouse.com/docs/sql-reference/table-functions/file
+func File(path string, format Format, structure string) FromExpression {
+	return fileLikeFunction{name: "file", path: path, format: format, structure: structure}
+}
+
+// URL reads data from a HTTP or HTTPS server.
+// Format and structure are optional, empty values are omitted. Structure requires format.
+// See https://clickhouse.com/docs/sql-reference/table-functions/url
+func URL(url string, format Format, structure string) FromExpression {
+	return fileLikeFunction{name: "url", path: url, format: format, structure: structure}
+}
+
+// fileLikeFunction is a table function in form of `name(path[, credentials...][, format[, structure]])`.
+type fileLikeFunction struct {
+	name        string
+	path        string
+	credentials []string
+	format      Format
+	structure   string
+}
+
+func (f fileLikeFunction) FromExpression(style RenderStyle) (string, error) {
+	if f.path == "" {
+		return "", errors.New(f.name + ": empty path")
+	}
+	if f.structure != "" && f.format == "" {
+		return "", errors.New(f.name + ": structure requires format")
+	}
+	return tableFunction{name: f.name, args: f.arguments()}.FromExpression(style)
+}
+
+func (f fileLikeFunction) arguments() []Expression {
+	args := []Expression{quoted(f.path)}
+	for _, c := range f.credentials {
+		args = append(args, quoted(c))
+	}
+	if f.format != "" {
+		args = append(args, quoted(string(f.format)))
+	}
+	if f.structure != "" {
+		args = append(args, quoted(f.structure))
+	}
+	return args
+}
+
+// TableAlias names a FROM source, rendering as `source AS alias`.
+func TableAlias(source FromExpression, alias string) FromExpression {
+	if source == nil {
+		panic("empty source in table alias")
+	}
+	if alias == "" {
+		panic("empty table alias")
+	}
+	return tableAlias{
+		source: source,
+		alias:  alias,
+	}
+}
+
+type tableAlias struct {
+	source FromExpression
+	alias  string
+}
+
+func (a tableAlias) FromExpression(style RenderStyle) (string, error) {
+	s, err := a.source.FromExpression(style)
+	if err != nil {
+		return "", err
+	}
+	return s + " AS " + a.alias, nil
+}
+
+// As is a shortcut of TableAlias.
+func (t Table) As(alias string) FromExpression {
+	return TableAlias(t, alias)
+}
+
+// isInlineFrom reports whether the FROM source renders in a single line, like a table name,
+// and thus should be indented as a normal clause argument.
+func isInlineFrom(f FromExpression) bool {
+	switch f := f.(type) {
+	case Table, tableFunction, fileLikeFunction:
+		return true
+	case tableAlias:
+		return isInlineFrom(f.source)
+	default:
+		return false
+	}
+}
+
+func quoted(s string) Expression {
+	return LiteralExpressionQuoted(s)
+}
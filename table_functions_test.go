@@ -0,0 +1,122 @@
+package click
+
+import (
+	"testing"
+)
+
+func TestTableFunctions(t *testing.T) {
+	tests := []struct {
+		name string
+		from FromExpression
+		want string
+	}{
+		{
+			name: "remote",
+			from: Remote("host-{1..3}:9000", "db", "tbl"),
+			want: "remote('host-{1..3}:9000', 'db', 'tbl')",
+		},
+		{
+			name: "remote with auth",
+			from: RemoteWithAuth("host:9000", "db", "tbl", "user", "pa'ss"),
+			want: `remote('host:9000', 'db', 'tbl', 'user', 'pa\'ss')`,
+		},
+		{
+			name: "cluster",
+			from: Cluster("main", "db", "tbl"),
+			want: "cluster('main', 'db', 'tbl')",
+		},
+		{
+			name: "numbers",
+			from: Numbers(10),
+			want: "numbers(10)",
+		},
+		{
+			name: "numbers range",
+			from: NumbersRange(5, 10),
+			want: "numbers(5, 10)",
+		},
+		{
+			name: "merge",
+			from: Merge("db", "^events_"),
+			want: "merge('db', '^events_')",
+		},
+		{
+			name: "generateRandom",
+			from: GenerateRandom("a UInt8, b String", 1, 10),
+			want: "generateRandom('a UInt8, b String', 1, 10)",
+		},
+		{
+			name: "s3 path only",
+			from: S3("https://bucket/data.csv", "", ""),
+			want: "s3('https://bucket/data.csv')",
+		},
+		{
+			name: "s3 with credentials",
+			from: S3WithCredentials("https://bucket/*.parquet", "key", "secret", FormatParquet, ""),
+			want: "s3('https://bucket/*.parquet', 'key', 'secret', 'Parquet')",
+		},
+		{
+			name: "file with structure",
+			from: File("data.tsv", FormatTabSeparated, "a UInt8, b String"),
+			want: "file('data.tsv', 'TabSeparated', 'a UInt8, b String')",
+		},
+		{
+			name: "url",
+			from: URL("https://example.com/data.json", FormatJSONEachRow, ""),
+			want: "url('https://example.com/data.json', 'JSONEachRow')",
+		},
+		{
+			name: "table alias",
+			from: Table("tbl").As("t"),
+			want: "tbl AS t",
+		},
+		{
+			name: "table function alias",
+			from: TableAlias(Numbers(3), "n"),
+			want: "numbers(3) AS n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.from.FromExpression(defaultStyle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("FromExpression() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableFunctions_StructureWithoutFormat(t *testing.T) {
+	_, err := Select(Count()).From(File("data.csv", "", "a UInt8")).BuildString()
+	if err == nil {
+		t.Fatal("expected error, got nothing")
+	}
+}
+
+func TestTableFunction_CopyArguments(t *testing.T) {
+	args := []Expression{LiteralExpression(10)}
+	f := TableFunction("numbers", args...)
+	args[0] = LiteralExpression(20)
+	if v := must(Select(Count()).From(f).BuildString()); v != "SELECT count() FROM numbers(10)" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelect_FromTableFunction_Pretty(t *testing.T) {
+	s := Select(Count()).From(TableAlias(Numbers(10), "n"))
+	v := must(s.PrettyPrint().BuildString())
+	if v != "SELECT\n\tcount()\nFROM\n\tnumbers(10) AS n" {
+		t.Fatal(v)
+	}
+}
+
+func TestSelect_FromAliasedNestedQuery_Pretty(t *testing.T) {
+	s := Select(Count()).From(TableAlias(Select(LiteralExpression(1)), "sub"))
+	v := must(s.PrettyPrint().BuildString())
+	if v != "SELECT\n\tcount()\nFROM\n(\n\tSELECT\n\t\t1\n) AS sub" {
+		t.Fatal(v)
+	}
+}
@@ -0,0 +1,717 @@
+package click
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keuin/click/chtype"
+	"github.com/keuin/click/internal/ident"
+)
+
+// ColumnInfo is a column of a table in Schema.
+type ColumnInfo struct {
+	Name Column
+	Type chtype.Type
+}
+
+// Schema provides columns of tables for type checking, see SelectBuilder.TypeCheck.
+// Package schema loads a Schema from dumps of system.columns.
+type Schema interface {
+	// TableColumns returns columns of the table in declaration order, or false if the table is unknown.
+	TableColumns(table Table) ([]ColumnInfo, bool)
+}
+
+// MapSchema is a Schema of tables in a map.
+// Table names without database match tables of any database if not ambiguous.
+type MapSchema map[Table][]ColumnInfo
+
+func (m MapSchema) TableColumns(table Table) ([]ColumnInfo, bool) {
+	if cols, ok := m[table]; ok {
+		return cols, true
+	}
+	var found []ColumnInfo
+	n := 0
+	for t, cols := range m {
+		if sameTable(t, table) {
+			found = cols
+			n++
+		}
+	}
+	return found, n == 1
+}
+
+// TypeError is an error found by type checking, in an expression of a clause.
+type TypeError struct {
+	Clause Clause
+	// Expression is the rendered expression, like `(ts = name)`.
+	Expression string
+	Message    string
+}
+
+func (e *TypeError) Error() string {
+	return e.Clause.String() + ": " + e.Expression + ": " + e.Message
+}
+
+// TypeErrors are all errors found by type checking a query.
+type TypeErrors []*TypeError
+
+func (e TypeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// TypeCheck makes Build and BuildString check the query against the schema with CheckTypes.
+// A nil schema disables type checking.
+func (s *SelectBuilder) TypeCheck(schema Schema) *SelectBuilder {
+	s.schema = schema
+	return s
+}
+
+// CheckTypes checks the query and its nested queries against the schema, returning TypeErrors if any:
+//   - columns must exist in the table, or be aliases defined by As in SELECT clause
+//   - operands of comparisons and IN must have compatible types, e.g. String and DateTime are not,
+//     while quoted strings must be parsable as the type they are compared with
+//   - elements of tuples in IN must have compatible types
+//   - aggregate functions like sum and avg must have numeric arguments, and must not be in WHERE or GROUP BY
+//   - in aggregating queries, columns in SELECT, HAVING and ORDER BY must be aggregated or in GROUP BY
+//
+// Expressions of unknown types, like results of most functions and sources other than tables, are not checked.
+func CheckTypes(q *SelectBuilder, schema Schema) error {
+	c := typeChecker{schema: schema}
+	c.checkQuery(q)
+	if len(c.errs) == 0 {
+		return nil
+	}
+	return c.errs
+}
+
+type typeChecker struct {
+	schema Schema
+	errs   TypeErrors
+}
+
+// scope is the names visible in a query.
+type scope struct {
+	source string
+	// columns of the FROM source, nil if unknown
+	columns map[string]chtype.Type
+	// names of columns in declaration order
+	names []string
+	// qualifiers of columns, like table names and table aliases
+	qualifiers []string
+	// SELECT aliases
+	aliases   map[string]Expression
+	resolving map[string]bool
+}
+
+func (c *typeChecker) errorf(clause Clause, expr string, format string, args ...any) {
+	err := &TypeError{Clause: clause, Expression: expr, Message: fmt.Sprintf(format, args...)}
+	for _, v := range c.errs {
+		if *v == *err {
+			return
+		}
+	}
+	c.errs = append(c.errs, err)
+}
+
+// queryScope returns the scope of the query, with columns of the FROM source and SELECT aliases.
+func (c *typeChecker) queryScope(q *SelectBuilder) *scope {
+	sc := c.fromScope(q.from)
+	for _, e := range q.selects {
+		if expr, a, ok := InspectAs(e); ok {
+			if col, ok := a.(Column); ok {
+				sc.aliases[unquoteIdent(string(col))] = expr
+			}
+		}
+	}
+	return sc
+}
+
+// checkQuery checks the query, returning its scope.
+func (c *typeChecker) checkQuery(q *SelectBuilder) *scope {
+	sc := c.queryScope(q)
+	c.checkAll(sc, ClauseSelect, q.selects...)
+	c.checkAll(sc, ClausePrewhere, q.prewhere)
+	c.checkAll(sc, ClauseWhere, q.where)
+	c.checkAll(sc, ClauseGroupBy, q.groupBy...)
+	c.checkAll(sc, ClauseHaving, q.having)
+	c.checkAll(sc, ClauseOrderBy, q.orderBy...)
+	c.checkGrouping(sc, q)
+	return sc
+}
+
+// fromScope returns the scope of the FROM source, checking nested queries.
+func (c *typeChecker) fromScope(f FromExpression) *scope {
+	sc := &scope{aliases: make(map[string]Expression), resolving: make(map[string]bool)}
+	if f == nil {
+		// queries without FROM read system.one
+		sc.source = "system.one"
+		sc.addColumn("dummy", chtype.Type{Name: "UInt8"})
+		return sc
+	}
+	walkFrom(f, func(f FromExpression) {
+		switch f := f.(type) {
+		case tableAlias:
+			sc.qualifiers = append(sc.qualifiers, unquoteIdent(f.alias))
+		case Table:
+			db, name := ident.SplitTable(string(f))
+			sc.source = string(f)
+			sc.qualifiers = append(sc.qualifiers, name)
+			if db != "" {
+				sc.qualifiers = append(sc.qualifiers, db+"."+name)
+			}
+			if c.schema == nil {
+				return
+			}
+			cols, ok := c.schema.TableColumns(f)
+			if !ok {
+				c.errorf(ClauseFrom, string(f), "unknown table")
+				return
+			}
+			for _, col := range cols {
+				sc.addColumn(unquoteIdent(string(col.Name)), col.Type)
+			}
+		default:
+			sub, ok := InspectSubquery(f)
+			if !ok {
+				return
+			}
+			sc.source = "subquery"
+			cols, err := c.resultColumns(c.checkQuery(sub), sub)
+			if err != nil {
+				return
+			}
+			for _, col := range cols {
+				sc.addColumn(col.Name, col.Type)
+			}
+		}
+	})
+	return sc
+}
+
+func (c *typeChecker) checkAll(sc *scope, clause Clause, exprs ...Expression) {
+	for _, e := range exprs {
+		c.check(sc, clause, e)
+	}
+}
+
+func (c *typeChecker) check(sc *scope, clause Clause, e Expression) {
+	switch e := e.(type) {
+	case nil:
+		return
+	case asExpression:
+		// the alias is not a column
+		c.check(sc, clause, e.Left)
+		return
+	case Column:
+		if _, ok := c.columnType(sc, e); !ok {
+			c.errorf(clause, e.Expression(), "unknown column of %s", sc.source)
+		}
+		return
+	case BinaryExpression:
+		c.checkBinary(sc, clause, e)
+	case fnCall:
+		c.checkFunction(sc, clause, e)
+	}
+	for _, ch := range children(e) {
+		c.check(sc, clause, ch)
+	}
+}
+
+var comparisonOperators = map[Operator]bool{"=": true, "==": true, "!=": true, "<>": true, "<": true, ">": true, "<=": true, ">=": true}
+
+func (c *typeChecker) checkBinary(sc *scope, clause Clause, e BinaryExpression) {
+	switch {
+	case comparisonOperators[e.Operator]:
+		if msg := c.mismatch(sc, e.LeftOperand, e.RightOperand); msg != "" {
+			c.errorf(clause, e.Expression(), "%s", msg)
+		}
+	case e.Operator == "IN" || e.Operator == "NOT IN":
+		elems, ok := e.RightOperand.(Tuple)
+		if !ok {
+			return
+		}
+		if c.typeOf(sc, e.LeftOperand).Name != "" {
+			for _, v := range elems {
+				if msg := c.mismatch(sc, e.LeftOperand, v); msg != "" {
+					c.errorf(clause, e.Expression(), "%s", msg)
+					return
+				}
+			}
+			return
+		}
+		for _, v := range elems[1:] {
+			if msg := c.mismatch(sc, elems[0], v); msg != "" {
+				c.errorf(clause, e.Expression(), "tuple elements have different types: %s", msg)
+				return
+			}
+		}
+	}
+}
+
+// numericAggregates are aggregate functions accepting only numeric arguments.
+var numericAggregates = map[string]bool{
+	"sum": true, "sumWithOverflow": true, "sumKahan": true, "avg": true, "avgWeighted": true,
+	"stddevPop": true, "stddevSamp": true, "varPop": true, "varSamp": true, "covarPop": true, "covarSamp": true,
+	"corr": true, "skewPop": true, "skewSamp": true, "kurtPop": true, "kurtSamp": true,
+}
+
+func (c *typeChecker) checkFunction(sc *scope, clause Clause, f fnCall) {
+	base, combinators, ok := aggregateFunction(f.name)
+	if !ok {
+		return
+	}
+	switch clause {
+	case ClausePrewhere, ClauseWhere, ClauseGroupBy:
+		c.errorf(clause, f.Expression(), "aggregate function is not allowed in %s clause", clause)
+	}
+	if !numericAggregates[base] || len(f.args) == 0 {
+		return
+	}
+	t := c.typeOf(sc, f.args[0]).Base()
+	for _, comb := range combinators {
+		switch comb {
+		case "Array", "ForEach":
+			t = t.Elem().Base()
+		case "Merge", "Map", "Resample":
+			return
+		}
+	}
+	if t.Name != "" && typeCategory(t) != "numeric" {
+		c.errorf(clause, f.Expression(), "%s requires a numeric argument, got %s", f.name, t)
+	}
+}
+
+// checkGrouping checks that columns in SELECT, HAVING and ORDER BY clauses of an aggregating query
+// are aggregated or in GROUP BY clause.
+func (c *typeChecker) checkGrouping(sc *scope, q *SelectBuilder) {
+	aggregating := len(q.groupBy) > 0
+	for _, e := range append(append([]Expression{q.having}, q.selects...), q.orderBy...) {
+		if e != nil && containsAggregate(e) {
+			aggregating = true
+		}
+	}
+	if !aggregating {
+		return
+	}
+	keys := make(map[string]bool)
+	for _, g := range q.groupBy {
+		keys[g.Expression()] = true
+		if col, ok := g.(Column); ok {
+			name := sc.unqualified(unquoteIdent(string(col)))
+			keys[name] = true
+			if e, ok := sc.aliases[name]; ok {
+				keys[e.Expression()] = true
+			}
+		}
+	}
+	check := func(clause Clause, exprs ...Expression) {
+		for _, e := range exprs {
+			if u := c.ungrouped(sc, keys, e); u != nil {
+				c.errorf(clause, u.Expression(), "column is neither aggregated nor in GROUP BY")
+			}
+		}
+	}
+	check(ClauseSelect, q.selects...)
+	check(ClauseHaving, q.having)
+	check(ClauseOrderBy, q.orderBy...)
+}
+
+// ungrouped returns the first column in e, which is neither aggregated nor in GROUP BY keys.
+func (c *typeChecker) ungrouped(sc *scope, keys map[string]bool, e Expression) Expression {
+	if e == nil || keys[e.Expression()] {
+		return nil
+	}
+	switch v := e.(type) {
+	case Column:
+		name := unquoteIdent(string(v))
+		if !isIdentifier(name) || keys[sc.unqualified(name)] {
+			return nil
+		}
+		if _, ok := sc.aliases[name]; ok {
+			// checked where the alias is defined
+			return nil
+		}
+		return v
+	case asExpression:
+		if keys[v.Right.Expression()] {
+			return nil
+		}
+		return c.ungrouped(sc, keys, v.Left)
+	case fnCall:
+		if _, _, ok := aggregateFunction(v.name); ok {
+			return nil
+		}
+	}
+	for _, ch := range children(e) {
+		if u := c.ungrouped(sc, keys, ch); u != nil {
+			return u
+		}
+	}
+	return nil
+}
+
+func containsAggregate(e Expression) bool {
+	found := false
+	Walk(e, func(e Expression) bool {
+		if name, _, ok := InspectFunction(e); ok {
+			if _, _, ok := aggregateFunction(name); ok {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func (sc *scope) addColumn(name string, t chtype.Type) {
+	if sc.columns == nil {
+		sc.columns = make(map[string]chtype.Type)
+	}
+	if _, ok := sc.columns[name]; !ok {
+		sc.names = append(sc.names, name)
+	}
+	sc.columns[name] = t
+}
+
+// unqualified strips the table name or alias of a column.
+func (sc *scope) unqualified(name string) string {
+	if _, ok := sc.columns[name]; ok {
+		// nested columns are named like `n.x`
+		return name
+	}
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return name
+	}
+	for _, q := range sc.qualifiers {
+		if name[:i] == q {
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+// columnType returns the type of a column or a SELECT alias, and false if it does not exist.
+// The type is zero if unknown.
+func (c *typeChecker) columnType(sc *scope, col Column) (chtype.Type, bool) {
+	name := unquoteIdent(string(col))
+	if !isIdentifier(name) {
+		return chtype.Type{}, true
+	}
+	// aliases take precedence over columns, unless the alias refers to the column itself, like `toDate(d) AS d`
+	if e, ok := sc.aliases[name]; ok && !sc.resolving[name] {
+		sc.resolving[name] = true
+		defer delete(sc.resolving, name)
+		return c.typeOf(sc, e), true
+	}
+	if sc.columns == nil {
+		return chtype.Type{}, true
+	}
+	t, ok := sc.columns[sc.unqualified(name)]
+	return t, ok
+}
+
+// typeOf returns the type of an expression, or the zero Type if unknown.
+func (c *typeChecker) typeOf(sc *scope, e Expression) chtype.Type {
+	switch e := e.(type) {
+	case Column:
+		t, _ := c.columnType(sc, e)
+		return t
+	case asExpression:
+		return c.typeOf(sc, e.Left)
+	case literalValue:
+		return valueType(e.literalValue())
+	case argExpr:
+		return valueType(e.val, true)
+	case concatenatedExpression:
+		return chtype.Type{Name: "UInt8"}
+	case BinaryExpression:
+		switch e.Operator {
+		case "+", "-":
+			if t := c.typeOf(sc, e.LeftOperand).Base(); t.IsTemporal() {
+				return t
+			}
+			return chtype.Type{}
+		}
+		if comparisonOperators[e.Operator] || strings.HasSuffix(string(e.Operator), "IN") {
+			return chtype.Type{Name: "UInt8"}
+		}
+	case Tuple:
+		t := chtype.Type{Name: "Tuple"}
+		for _, v := range e {
+			vt := c.typeOf(sc, v)
+			if vt.Name == "" {
+				return chtype.Type{}
+			}
+			t.Elems = append(t.Elems, vt)
+		}
+		return t
+	case fnCall:
+		return c.functionType(sc, e)
+	}
+	return chtype.Type{}
+}
+
+// functionType returns the result type of well-known functions.
+func (c *typeChecker) functionType(sc *scope, f fnCall) chtype.Type {
+	if base, combinators, ok := aggregateFunction(f.name); ok {
+		args := make([]chtype.Type, len(f.args))
+		for i, arg := range f.args {
+			args[i] = c.typeOf(sc, arg)
+		}
+		return aggregateType(base, combinators, args)
+	}
+	switch f.name {
+	case "length":
+		return chtype.Type{Name: "UInt64"}
+	case "toDate", "today", "yesterday", "toMonday", "toStartOfWeek", "toStartOfMonth", "toStartOfQuarter", "toStartOfYear":
+		return chtype.Type{Name: "Date"}
+	case "now", "toDateTime", "toStartOfMinute", "toStartOfFiveMinutes", "toStartOfFifteenMinutes", "toStartOfHour",
+		"toStartOfDay", "toStartOfInterval":
+		return chtype.Type{Name: "DateTime"}
+	case "now64", "toDateTime64":
+		return chtype.Type{Name: "DateTime64"}
+	case "toString", "lower", "upper", "concat", "substring", "formatDateTime":
+		return chtype.Type{Name: "String"}
+	case "if":
+		if len(f.args) == 3 {
+			return c.typeOf(sc, f.args[1])
+		}
+	}
+	// type conversion functions, like toUInt32
+	if strings.HasPrefix(f.name, "to") {
+		if t, err := chtype.Parse(f.name[2:]); err == nil && len(t.Elems) == 0 {
+			switch typeCategory(t) {
+			case "numeric", "string", "temporal", "UUID", "IP":
+				return t
+			}
+		}
+	}
+	return chtype.Type{}
+}
+
+// valueType returns the type of a Go value in a literal or Arg. Unquoted strings are SQL of unknown types.
+func valueType(v any, quoted bool) chtype.Type {
+	if v == nil {
+		return chtype.Type{}
+	}
+	if _, ok := v.(interface{ Unix() int64 }); ok {
+		return chtype.Type{Name: "DateTime"}
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.String:
+		if quoted {
+			return chtype.Type{Name: "String"}
+		}
+	case reflect.Bool:
+		return chtype.Type{Name: "Bool"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return chtype.Type{Name: "Int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return chtype.Type{Name: "UInt64"}
+	case reflect.Float32, reflect.Float64:
+		return chtype.Type{Name: "Float64"}
+	}
+	return chtype.Type{}
+}
+
+// mismatch returns why l and r cannot be compared, or "" if they can or their types are unknown.
+func (c *typeChecker) mismatch(sc *scope, l, r Expression) string {
+	if lt, ok := l.(Tuple); ok {
+		if rt, ok := r.(Tuple); ok {
+			if len(lt) != len(rt) {
+				return fmt.Sprintf("cannot compare tuples of %d and %d elements", len(lt), len(rt))
+			}
+			for i := range lt {
+				if msg := c.mismatch(sc, lt[i], rt[i]); msg != "" {
+					return msg
+				}
+			}
+			return ""
+		}
+	}
+	lt, rt := c.typeOf(sc, l).Base(), c.typeOf(sc, r).Base()
+	if lt.Name == "" || rt.Name == "" {
+		return ""
+	}
+	// quoted strings are converted to the type of the other operand
+	if s, ok := constString(r); ok {
+		return literalMismatch(lt, s)
+	}
+	if s, ok := constString(l); ok {
+		return literalMismatch(rt, s)
+	}
+	if !typesComparable(lt, rt) {
+		return fmt.Sprintf("cannot compare %s with %s", lt, rt)
+	}
+	return ""
+}
+
+// constString returns the value of a quoted string literal or a string Arg.
+func constString(e Expression) (string, bool) {
+	if s, ok := stringLiteral(e); ok {
+		return s, true
+	}
+	if v, ok := InspectArg(e); ok && v != nil && reflect.ValueOf(v).Kind() == reflect.String {
+		return reflect.ValueOf(v).String(), true
+	}
+	return "", false
+}
+
+// typeCategory groups types whose values can be compared with each other.
+func typeCategory(t chtype.Type) string {
+	switch {
+	case t.IsNumeric(), t.Name == "Bool":
+		return "numeric"
+	case t.IsString():
+		return "string"
+	case t.IsTemporal():
+		return "temporal"
+	case t.IsEnum():
+		return "enum"
+	case t.Name == "IPv4", t.Name == "IPv6":
+		return "IP"
+	default:
+		return t.Name
+	}
+}
+
+// typesComparable reports whether values of the types can be compared. Wrappers like Nullable must be stripped.
+func typesComparable(a, b chtype.Type) bool {
+	ca, cb := typeCategory(a), typeCategory(b)
+	if ca == "Nothing" || cb == "Nothing" {
+		return true
+	}
+	if ca == cb {
+		switch ca {
+		case "Array":
+			return typesComparable(a.Elem().Base(), b.Elem().Base())
+		case "Tuple":
+			if len(a.Elems) != len(b.Elems) {
+				return false
+			}
+			for i := range a.Elems {
+				if !typesComparable(a.Elems[i].Base(), b.Elems[i].Base()) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	// dates are compared with numbers as timestamps, and enums with strings as names
+	pair := ca + "/" + cb
+	switch pair {
+	case "numeric/temporal", "temporal/numeric", "enum/string", "string/enum":
+		return true
+	}
+	return false
+}
+
+var dateLayouts = []string{"2006-01-02", "2006-01-02 15:04:05", "2006-01-02 15:04:05.999999999", time.RFC3339Nano}
+
+// literalMismatch returns why the quoted string s cannot be converted to type t, or "" if it can.
+func literalMismatch(t chtype.Type, s string) string {
+	ok := true
+	switch typeCategory(t) {
+	case "numeric":
+		_, err := strconv.ParseFloat(s, 64)
+		ok = err == nil || (t.Name == "Bool" && (s == "true" || s == "false"))
+	case "temporal":
+		ok = false
+		for _, layout := range dateLayouts {
+			if _, err := time.Parse(layout, s); err == nil {
+				ok = true
+				break
+			}
+		}
+	case "enum":
+		if values, err := t.EnumValues(); err == nil {
+			_, ok = values[s]
+		}
+	case "UUID":
+		ok = len(s) == 36 && strings.Count(s, "-") == 4
+	case "IP":
+		ip := net.ParseIP(s)
+		ok = ip != nil && (t.Name == "IPv6" || ip.To4() != nil)
+	case "string":
+	default:
+		return fmt.Sprintf("cannot compare %s with String", t)
+	}
+	if !ok {
+		return fmt.Sprintf("cannot parse %s as %s", quoted(s).Expression(), t)
+	}
+	return ""
+}
+
+// aggregateFunctions are names of common aggregate functions without combinators,
+// see https://clickhouse.com/docs/sql-reference/aggregate-functions/reference
+var aggregateFunctions = map[string]bool{
+	"count": true, "sum": true, "sumWithOverflow": true, "sumKahan": true, "sumMap": true, "avg": true, "avgWeighted": true,
+	"min": true, "max": true, "minMap": true, "maxMap": true, "any": true, "anyLast": true, "anyHeavy": true,
+	"argMin": true, "argMax": true, "uniq": true, "uniqExact": true, "uniqCombined": true, "uniqCombined64": true,
+	"uniqHLL12": true, "uniqTheta": true, "uniqUpTo": true, "groupArray": true, "groupUniqArray": true,
+	"groupArrayInsertAt": true, "groupBitAnd": true, "groupBitOr": true, "groupBitXor": true, "groupBitmap": true,
+	"median": true, "quantile": true, "quantiles": true, "quantileExact": true, "quantilesExact": true,
+	"quantileTiming": true, "quantilesTiming": true, "quantileTDigest": true, "quantilesTDigest": true,
+	"stddevPop": true, "stddevSamp": true, "varPop": true, "varSamp": true, "covarPop": true, "covarSamp": true,
+	"corr": true, "skewPop": true, "skewSamp": true, "kurtPop": true, "kurtSamp": true, "entropy": true,
+	"topK": true, "topKWeighted": true, "simpleLinearRegression": true, "histogram": true,
+	"sequenceMatch": true, "sequenceCount": true, "windowFunnel": true, "retention": true,
+	"any_value": true, "first_value": true, "last_value": true,
+}
+
+// aggregateCombinators are suffixes of aggregate functions,
+// see https://clickhouse.com/docs/sql-reference/aggregate-functions/combinators
+var aggregateCombinators = []string{"If", "Array", "Map", "State", "SimpleState", "Merge", "ForEach", "Distinct", "OrDefault", "OrNull", "Resample"}
+
+// aggregateFunction parses the name of an aggregate function like `sumIf` into `sum` and combinators `If`,
+// in the order they are applied to arguments.
+func aggregateFunction(name string) (base string, combinators []string, ok bool) {
+	for {
+		if aggregateFunctions[name] {
+			return name, combinators, true
+		}
+		stripped := false
+		for _, comb := range aggregateCombinators {
+			if len(name) > len(comb) && strings.HasSuffix(name, comb) {
+				name = name[:len(name)-len(comb)]
+				combinators = append([]string{comb}, combinators...)
+				stripped = true
+				break
+			}
+		}
+		if !stripped {
+			return "", nil, false
+		}
+	}
+}
+
+func unquoteIdent(s string) string {
+	return strings.ReplaceAll(s, "`", "")
+}
+
+// isIdentifier reports whether s is a possibly qualified identifier like `db.t.col`, rather than SQL like `*`.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if part == "" || (part[0] >= '0' && part[0] <= '9') {
+			return false
+		}
+		for _, r := range part {
+			if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
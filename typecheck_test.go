@@ -0,0 +1,187 @@
+package click
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/keuin/click/chtype"
+)
+
+var testSchema = MapSchema{
+	"db.events": {
+		{Name: "id", Type: chtype.MustParse("UInt64")},
+		{Name: "ts", Type: chtype.MustParse("DateTime64(3, 'UTC')")},
+		{Name: "name", Type: chtype.MustParse("LowCardinality(String)")},
+		{Name: "level", Type: chtype.MustParse("Enum8('info' = 1, 'error' = 2)")},
+		{Name: "duration", Type: chtype.MustParse("Nullable(Float64)")},
+		{Name: "tags", Type: chtype.MustParse("Array(String)")},
+		{Name: "ip", Type: chtype.MustParse("IPv4")},
+		{Name: "attrs.key", Type: chtype.MustParse("Array(String)")},
+	},
+}
+
+func TestCheckTypes(t *testing.T) {
+	events := Table("events")
+	tests := []struct {
+		name  string
+		query *SelectBuilder
+		// want are substrings of errors in order, or empty if the query is valid
+		want []string
+	}{
+		{
+			name: "valid",
+			query: Select(Column("id"), As(ToDate(Column("ts")), Column("d"))).From(events).
+				Where(And(
+					GreaterOrEqualThan(Column("ts"), LiteralExpressionQuoted("2024-01-01 00:00:00")),
+					GreaterThan(Column("ts"), Ago(Interval(1, UnitDay))),
+					Equal(Column("level"), LiteralExpressionQuoted("error")),
+					In(Column("id"), Tuple{LiteralExpression(1), LiteralExpression(2)}),
+					Equal(Column("ip"), Arg("10.0.0.1")),
+					Equal(Column("d"), Today()),
+					Equal(Column("attrs.key"), Column("tags")),
+				)),
+		},
+		{
+			name:  "unknown column",
+			query: Select(Column("id"), Column("missing")).From(events).Where(Column("e.other")),
+			want:  []string{"SELECT: missing: unknown column of events", "WHERE: e.other: unknown column"},
+		},
+		{
+			name:  "qualified column",
+			query: Select(Column("e.id"), Column("db.events.name")).From(Table("db.events").As("e")),
+		},
+		{
+			name:  "unknown table",
+			query: Select(Column("id")).From(Table("db.missing")),
+			want:  []string{"FROM: db.missing: unknown table"},
+		},
+		{
+			name:  "String and DateTime",
+			query: Select(Column("id")).From(events).Where(Equal(Column("ts"), Column("name"))),
+			want:  []string{"WHERE: (ts = name): cannot compare DateTime64(3, 'UTC') with String"},
+		},
+		{
+			name:  "unparsable literal",
+			query: Select(Column("id")).From(events).Where(Or(LessThan(Column("ts"), LiteralExpressionQuoted("yesterday")), Equal(Column("level"), LiteralExpressionQuoted("warn")))),
+			want:  []string{"cannot parse 'yesterday' as DateTime64(3, 'UTC')", "cannot parse 'warn' as Enum8"},
+		},
+		{
+			name:  "sum of String",
+			query: Select(Sum(Column("name")), Fn("avgIf", Column("duration"), Column("id"))).From(events),
+			want:  []string{"SELECT: sum(name): sum requires a numeric argument, got String"},
+		},
+		{
+			name:  "sumArray",
+			query: Select(Fn("sumArray", Column("tags"))).From(events),
+			want:  []string{"sumArray requires a numeric argument, got String"},
+		},
+		{
+			name:  "IN tuple",
+			query: Select(Column("id")).From(events).Where(In(Column("id"), Tuple{LiteralExpression(1), LiteralExpressionQuoted("x")})),
+			want:  []string{"WHERE: (id IN (1, 'x')): cannot parse 'x' as UInt64"},
+		},
+		{
+			name:  "IN tuple elements",
+			query: Select(Column("id")).From(events).Where(In(Fn("unknownFunc"), Tuple{LiteralExpression(1), Column("name")})),
+			want:  []string{"tuple elements have different types: cannot compare Int64 with String"},
+		},
+		{
+			name:  "aggregate in WHERE",
+			query: Select(Count()).From(events).Where(GreaterThan(Count(), LiteralExpression(1))),
+			want:  []string{"WHERE: count(): aggregate function is not allowed in WHERE clause"},
+		},
+		{
+			name: "grouped",
+			query: Select(As(ToDate(Column("ts")), Column("d")), Column("name"), As(Count(), Column("n"))).From(events).
+				GroupBy(Column("d"), Column("name")).
+				Having(GreaterThan(Column("n"), LiteralExpression(1))).
+				OrderBy(Desc(Column("n")), Column("d")),
+		},
+		{
+			name: "not grouped",
+			query: Select(Column("name"), Column("id"), Count()).From(events).GroupBy(Column("name")).
+				OrderBy(Plus(Column("id"), Column("duration"))),
+			want: []string{"SELECT: id: column is neither aggregated nor in GROUP BY", "ORDER BY: id: column is neither aggregated nor in GROUP BY"},
+		},
+		{
+			name:  "aggregated without GROUP BY",
+			query: Select(Column("name"), Count()).From(events),
+			want:  []string{"SELECT: name: column is neither aggregated nor in GROUP BY"},
+		},
+		{
+			name:  "nested query",
+			query: Select(Column("x")).From(TableAlias(Select(As(Column("id"), Column("x"))).From(events).Where(Equal(Column("nope"), LiteralExpression(1))), "sub")),
+			want:  []string{"WHERE: nope: unknown column of events"},
+		},
+		{
+			name:  "nested query columns",
+			query: Select(Column("x"), Column("sub.x"), Column("id")).From(TableAlias(Select(As(Column("id"), Column("x"))).From(events), "sub")),
+			want:  []string{"SELECT: id: unknown column of subquery"},
+		},
+		{
+			name:  "table function",
+			query: Select(Column("anything")).From(Numbers(10)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTypes(tt.query, testSchema)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var errs TypeErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected TypeErrors, got %v", err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors: %v", len(errs), err)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("error %d = %q, want %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectBuilder_TypeCheck(t *testing.T) {
+	q := Select(Column("id")).From(Table("events")).Where(Equal(Column("ts"), Column("name")))
+	if _, err := q.BuildString(); err != nil {
+		t.Fatalf("type checking is not enabled: %v", err)
+	}
+	q.TypeCheck(testSchema)
+	_, err := q.Build()
+	var errs TypeErrors
+	if !errors.As(err, &errs) || errs[0].Clause != ClauseWhere || errs[0].Expression != "(ts = name)" {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if _, err := q.BuildString(); err == nil {
+		t.Fatal("BuildString() error = nil")
+	}
+	if _, err := q.TypeCheck(nil).BuildString(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAggregateFunction(t *testing.T) {
+	for name, want := range map[string]string{
+		"sum":             "sum [] true",
+		"countIf":         "count [If] true",
+		"sumMap":          "sumMap [] true",
+		"uniqMergeState":  "uniq [Merge State] true",
+		"avgOrNullIf":     "avg [OrNull If] true",
+		"if":              " [] false",
+		"emptyArray":      " [] false",
+		"formatRowOrNull": " [] false",
+	} {
+		base, comb, ok := aggregateFunction(name)
+		if got := base + " [" + strings.Join(comb, " ") + "] " + map[bool]string{true: "true", false: "false"}[ok]; got != want {
+			t.Errorf("aggregateFunction(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package click
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type viewKind int
+
+const (
+	viewNormal viewKind = iota
+	viewMaterialized
+	viewLive
+)
+
+func (k viewKind) String() string {
+	return [...]string{"VIEW", "MATERIALIZED VIEW", "LIVE VIEW"}[k]
+}
+
+// CreateView creates a CREATE VIEW query, whose body is usually built with SelectBuilder.Build.
+// See https://clickhouse.com/docs/sql-reference/statements/create/view#normal-view
+func CreateView(name Table, query SelectQuery) *CreateViewBuilder {
+	return &CreateViewBuilder{kind: viewNormal, name: name, query: query}
+}
+
+// CreateMaterializedView creates a CREATE MATERIALIZED VIEW query. Rows are stored in the target table set by To,
+// or in an inner table with the engine set by Engine. Call RefreshEvery or RefreshAfter for a refreshable view.
+// See https://clickhouse.com/docs/sql-reference/statements/create/view#materialized-view
+func CreateMaterializedView(name Table, query SelectQuery) *CreateViewBuilder {
+	return &CreateViewBuilder{kind: viewMaterialized, name: name, query: query}
+}
+
+// CreateLiveView creates a CREATE LIVE VIEW query, which is an experimental feature of ClickHouse.
+// See https://clickhouse.com/docs/sql-reference/statements/create/view#live-view
+func CreateLiveView(name Table, query SelectQuery) *CreateViewBuilder {
+	return &CreateViewBuilder{kind: viewLive, name: name, query: query}
+}
+
+// CreateViewBuilder implements builder pattern for constructing CREATE VIEW SQLs.
+// Invalid combinations of clauses, like POPULATE with TO, are reported in BuildString.
+type CreateViewBuilder struct {
+	kind          viewKind
+	name          Table
+	query         SelectQuery
+	cluster       string
+	ifNotExists   bool
+	orReplace     bool
+	to            Table
+	engine        string
+	populate      bool
+	refresh       string // EVERY or AFTER
+	refreshPeriod Expression
+	refreshOffset Expression
+	refreshAppend bool
+	style         RenderStyle
+	styleSet      bool
+}
+
+// clone copies the builder, including the query, so that the copy shares no builders with b.
+func (b *CreateViewBuilder) clone() *CreateViewBuilder {
+	c := *b
+	if q, ok := b.query.(*sealedSelect); ok {
+		c.query = (*sealedSelect)((*SelectBuilder)(q).Clone())
+	}
+	return &c
+}
+
+func (b *CreateViewBuilder) OnCluster(cluster string) *CreateViewBuilder {
+	b.cluster = cluster
+	return b
+}
+
+func (b *CreateViewBuilder) IfNotExists() *CreateViewBuilder {
+	b.ifNotExists = true
+	return b
+}
+
+// OrReplace replaces the existing view, only normal views support it.
+func (b *CreateViewBuilder) OrReplace() *CreateViewBuilder {
+	b.orReplace = true
+	return b
+}
+
+// To sets the target table of a materialized view, which must be created beforehand.
+func (b *CreateViewBuilder) To(table Table) *CreateViewBuilder {
+	b.to = table
+	return b
+}
+
+// Engine sets the engine of the inner table of a materialized view, with optional table clauses,
+// e.g. `SummingMergeTree ORDER BY (day, id)`.
+func (b *CreateViewBuilder) Engine(engine string) *CreateViewBuilder {
+	b.engine = engine
+	return b
+}
+
+// Populate fills the inner table of a materialized view with existing data in the source table.
+// Rows inserted during population are lost, see the ClickHouse documentation.
+func (b *CreateViewBuilder) Populate() *CreateViewBuilder {
+	b.populate = true
+	return b
+}
+
+// RefreshEvery makes a refreshable materialized view, which reruns the query periodically at aligned times,
+// e.g. RefreshEvery(Interval(1, UnitDay)). For live views, the interval is `WITH REFRESH` in seconds.
+// See https://clickhouse.com/docs/materialized-view/refreshable-materialized-view
+func (b *CreateViewBuilder) RefreshEvery(interval Expression) *CreateViewBuilder {
+	b.refresh = "EVERY"
+	b.refreshPeriod = interval
+	return b
+}
+
+// RefreshAfter makes a refreshable materialized view, which reruns the query after the interval since the last refresh.
+func (b *CreateViewBuilder) RefreshAfter(interval Expression) *CreateViewBuilder {
+	b.refresh = "AFTER"
+	b.refreshPeriod = interval
+	return b
+}
+
+// RefreshOffset delays refreshes of RefreshEvery, e.g. RefreshEvery(Interval(1, UnitDay)) with
+// RefreshOffset(Interval(2, UnitHour)) refreshes at 02:00 every day.
+func (b *CreateViewBuilder) RefreshOffset(interval Expression) *CreateViewBuilder {
+	b.refreshOffset = interval
+	return b
+}
+
+// Append makes a refreshable materialized view append rows on refreshes, instead of replacing all rows.
+func (b *CreateViewBuilder) Append() *CreateViewBuilder {
+	b.refreshAppend = true
+	return b
+}
+
+// PrettyPrint renders every clause in its own line, and the query with indentation.
+func (b *CreateViewBuilder) PrettyPrint(v ...bool) *CreateViewBuilder {
+	if len(v) == 0 || v[0] {
+		b.style = prettyStyle
+	} else {
+		b.style = defaultStyle
+	}
+	b.styleSet = true
+	return b
+}
+
+func (b *CreateViewBuilder) BuildString() (string, error) {
+	style := defaultStyle
+	if b.styleSet {
+		style = b.style
+	}
+	if b.name == "" {
+		return "", errors.New("no view name")
+	}
+	if b.query == nil {
+		return "", errors.New("no query")
+	}
+	if err := b.validate(); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if b.orReplace {
+		sb.WriteString("OR REPLACE ")
+	}
+	sb.WriteString(b.kind.String())
+	sb.WriteByte(' ')
+	if b.ifNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(string(b.name))
+	if b.cluster != "" {
+		sb.WriteString(" ON CLUSTER ")
+		sb.WriteString(b.cluster)
+	}
+	clause := func(s string) {
+		if style.Indent == "" {
+			sb.WriteByte(' ')
+		} else {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(s)
+	}
+	if b.refreshPeriod != nil {
+		s, err := b.refreshClause()
+		if err != nil {
+			return "", err
+		}
+		clause(s)
+	}
+	if b.to != "" {
+		clause("TO " + string(b.to))
+	}
+	if b.engine != "" {
+		clause("ENGINE = " + b.engine)
+	}
+	if b.populate {
+		clause("POPULATE")
+	}
+	query, err := b.query.FromExpression(style)
+	if err != nil {
+		return "", fmt.Errorf("build view query: %w", err)
+	}
+	clause("AS " + query)
+	return sb.String(), nil
+}
+
+func (b *CreateViewBuilder) validate() error {
+	if b.orReplace && b.kind != viewNormal {
+		return fmt.Errorf("OR REPLACE is not supported by %s", b.kind)
+	}
+	if b.orReplace && b.ifNotExists {
+		return errors.New("OR REPLACE and IF NOT EXISTS are exclusive")
+	}
+	if b.kind != viewMaterialized {
+		if b.to != "" || b.engine != "" || b.populate {
+			return fmt.Errorf("TO, ENGINE and POPULATE are not supported by %s", b.kind)
+		}
+		if b.refreshPeriod != nil && b.kind != viewLive {
+			return fmt.Errorf("REFRESH is not supported by %s", b.kind)
+		}
+	}
+	if b.to != "" && (b.engine != "" || b.populate) {
+		return errors.New("ENGINE and POPULATE are not supported with TO")
+	}
+	if b.populate && b.refreshPeriod != nil {
+		return errors.New("POPULATE is not supported by refreshable materialized views")
+	}
+	if b.refreshPeriod == nil && (b.refreshOffset != nil || b.refreshAppend) {
+		return errors.New("OFFSET and APPEND require RefreshEvery or RefreshAfter")
+	}
+	return nil
+}
+
+// refreshClause renders `REFRESH EVERY 1 DAY [OFFSET 2 HOUR] [APPEND]`, or `WITH REFRESH 60` for live views.
+func (b *CreateViewBuilder) refreshClause() (string, error) {
+	period, err := refreshInterval(b.refreshPeriod)
+	if err != nil {
+		return "", err
+	}
+	if b.kind == viewLive {
+		if b.refresh != "EVERY" || b.refreshOffset != nil || b.refreshAppend {
+			return "", errors.New("LIVE VIEW only supports RefreshEvery")
+		}
+		v, unit, _ := InspectInterval(b.refreshPeriod)
+		if unit != UnitSecond {
+			return "", errors.New("LIVE VIEW refresh interval must be in seconds")
+		}
+		return "WITH REFRESH " + v.Expression(), nil
+	}
+	s := "REFRESH " + b.refresh + " " + period
+	if b.refreshOffset != nil {
+		if b.refresh != "EVERY" {
+			return "", errors.New("OFFSET is only supported by RefreshEvery")
+		}
+		offset, err := refreshInterval(b.refreshOffset)
+		if err != nil {
+			return "", err
+		}
+		s += " OFFSET " + offset
+	}
+	if b.refreshAppend {
+		s += " APPEND"
+	}
+	return s, nil
+}
+
+// refreshInterval renders an interval without the INTERVAL keyword, e.g. `1 DAY`.
+func refreshInterval(e Expression) (string, error) {
+	v, unit, ok := InspectInterval(e)
+	if !ok {
+		return "", fmt.Errorf("refresh period is not an interval: %s", e.Expression())
+	}
+	return v.Expression() + " " + string(unit), nil
+}
@@ -0,0 +1,105 @@
+package click
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateView(t *testing.T) {
+	q := must(Select(Column("day"), As(Count(), Column("n"))).
+		From(Table("events")).
+		GroupBy(Column("day")).
+		Build())
+	tests := []struct {
+		name string
+		b    *CreateViewBuilder
+		want string
+	}{
+		{
+			name: "view",
+			b:    CreateView("db.v", q).OrReplace().OnCluster("main"),
+			want: "CREATE OR REPLACE VIEW db.v ON CLUSTER main AS (\nSELECT day, count() AS n FROM events GROUP BY day\n)",
+		},
+		{
+			name: "materialized view to table",
+			b:    CreateMaterializedView("mv", q).IfNotExists().To("daily"),
+			want: "CREATE MATERIALIZED VIEW IF NOT EXISTS mv TO daily AS (\nSELECT day, count() AS n FROM events GROUP BY day\n)",
+		},
+		{
+			name: "materialized view with engine",
+			b:    CreateMaterializedView("mv", q).Engine("SummingMergeTree ORDER BY day").Populate(),
+			want: "CREATE MATERIALIZED VIEW mv ENGINE = SummingMergeTree ORDER BY day POPULATE AS (\nSELECT day, count() AS n FROM events GROUP BY day\n)",
+		},
+		{
+			name: "refreshable",
+			b: CreateMaterializedView("mv", q).
+				RefreshEvery(Interval(1, UnitDay)).RefreshOffset(Interval(2, UnitHour)).Append().To("daily"),
+			want: "CREATE MATERIALIZED VIEW mv REFRESH EVERY 1 DAY OFFSET 2 HOUR APPEND TO daily AS (\nSELECT day, count() AS n FROM events GROUP BY day\n)",
+		},
+		{
+			name: "refresh after",
+			b:    CreateMaterializedView("mv", q).RefreshAfter(DurationInterval(30 * time.Minute)).Engine("MergeTree ORDER BY day"),
+			want: "CREATE MATERIALIZED VIEW mv REFRESH AFTER 30 MINUTE ENGINE = MergeTree ORDER BY day AS (\nSELECT day, count() AS n FROM events GROUP BY day\n)",
+		},
+		{
+			name: "live view",
+			b:    CreateLiveView("lv", q).RefreshEvery(Interval(60, UnitSecond)),
+			want: "CREATE LIVE VIEW lv WITH REFRESH 60 AS (\nSELECT day, count() AS n FROM events GROUP BY day\n)",
+		},
+		{
+			name: "pretty",
+			b:    CreateMaterializedView("mv", q).To("daily").PrettyPrint(),
+			want: "CREATE MATERIALIZED VIEW mv\nTO daily\nAS (\n\tSELECT\n\t\tday,\n\t\tcount() AS n\n\tFROM\n\t\tevents\n\tGROUP BY\n\t\tday\n)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.b.BuildString()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateView_Invalid(t *testing.T) {
+	q := must(Select(Column("a")).From(Table("t")).Build())
+	tests := map[string]*CreateViewBuilder{
+		"no query":               CreateView("v", nil),
+		"no name":                CreateView("", q),
+		"replace mv":             CreateMaterializedView("mv", q).OrReplace(),
+		"view with engine":       CreateView("v", q).Engine("MergeTree"),
+		"view with refresh":      CreateView("v", q).RefreshEvery(Interval(1, UnitHour)),
+		"populate with to":       CreateMaterializedView("mv", q).To("t2").Populate(),
+		"populate with refresh":  CreateMaterializedView("mv", q).Engine("MergeTree").Populate().RefreshEvery(Interval(1, UnitHour)),
+		"offset without refresh": CreateMaterializedView("mv", q).To("t2").RefreshOffset(Interval(1, UnitHour)),
+		"offset with after":      CreateMaterializedView("mv", q).To("t2").RefreshAfter(Interval(1, UnitHour)).RefreshOffset(Interval(1, UnitHour)),
+		"not an interval":        CreateMaterializedView("mv", q).To("t2").RefreshEvery(LiteralExpression(1)),
+		"live view in minutes":   CreateLiveView("lv", q).RefreshEvery(Interval(1, UnitMinute)),
+	}
+	for name, b := range tests {
+		if s, err := b.BuildString(); err == nil {
+			t.Errorf("%s: expected error, got %v", name, s)
+		}
+	}
+}
+
+func TestPrettyString_CreateView(t *testing.T) {
+	b := CreateView("v", must(Select(Column("a")).From(Table("t")).Build()))
+	got, err := PrettyString(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "CREATE VIEW v\nAS (\n\tSELECT\n\t\ta\n\tFROM\n\t\tt\n)"; got != want {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+	if got := must(b.BuildString()); got != "CREATE VIEW v AS (\nSELECT a FROM t\n)" {
+		t.Errorf("PrettyString modified the builder: %v", got)
+	}
+	if c := b.clone(); c.query == b.query {
+		t.Error("clone shares the query")
+	}
+}
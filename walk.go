@@ -0,0 +1,513 @@
+package click
+
+import (
+	"fmt"
+)
+
+// NodeKind is the kind of built-in expression types, which are otherwise opaque.
+type NodeKind int
+
+const (
+	// KindUnknown is any Expression implemented outside this package.
+	KindUnknown NodeKind = iota
+	// KindColumn is Column.
+	KindColumn
+	// KindLiteral is created by LiteralExpression, LiteralExpressionQuoted or LiteralExpressions.
+	KindLiteral
+	// KindAlias is created by Alias or QueryParameter, which are rendered verbatim.
+	KindAlias
+	// KindArg is created by Arg.
+	KindArg
+	// KindRaw is created by Raw. Its children are its arguments.
+	KindRaw
+	// KindFunction is created by Fn and function shortcuts like Sum.
+	KindFunction
+	// KindBinary is BinaryExpression, created by comparison functions like Equal and In.
+	KindBinary
+	// KindConcatenation is created by And, Or and Concatenate.
+	KindConcatenation
+	// KindAs is created by As. Its children are the expression and the alias.
+	KindAs
+	// KindOrderBy is created by Asc and Desc.
+	KindOrderBy
+	// KindTuple is Tuple.
+	KindTuple
+	// KindCase is CaseExpression. Its children are the operand, conditions and results interleaved, and the else result.
+	KindCase
+	// KindLambda is LambdaExpression. Its only child is the body.
+	KindLambda
+	// KindLambdaParameter is LambdaParameter.
+	KindLambdaParameter
+	// KindInterval is created by Interval. Its only child is the value.
+	KindInterval
+)
+
+var nodeKindNames = [...]string{"Unknown", "Column", "Literal", "Alias", "Arg", "Raw", "Function", "Binary", "Concatenation", "As", "OrderBy", "Tuple", "Case", "Lambda", "LambdaParameter", "Interval"}
+
+func (k NodeKind) String() string {
+	if k < 0 || int(k) >= len(nodeKindNames) {
+		return fmt.Sprintf("NodeKind(%d)", int(k))
+	}
+	return nodeKindNames[k]
+}
+
+// KindOf returns the kind of expression.
+func KindOf(e Expression) NodeKind {
+	switch e.(type) {
+	case Column:
+		return KindColumn
+	case literalValue:
+		return KindLiteral
+	case alias:
+		return KindAlias
+	case argExpr:
+		return KindArg
+	case rawExpr:
+		return KindRaw
+	case fnCall:
+		return KindFunction
+	case BinaryExpression:
+		return KindBinary
+	case concatenatedExpression:
+		return KindConcatenation
+	case asExpression:
+		return KindAs
+	case orderByExpression:
+		return KindOrderBy
+	case Tuple:
+		return KindTuple
+	case CaseExpression:
+		return KindCase
+	case LambdaExpression:
+		return KindLambda
+	case LambdaParameter:
+		return KindLambdaParameter
+	case intervalExpr:
+		return KindInterval
+	default:
+		return KindUnknown
+	}
+}
+
+// InspectFunction returns the name and arguments of a function call.
+func InspectFunction(e Expression) (name string, args []Expression, ok bool) {
+	f, ok := e.(fnCall)
+	if !ok {
+		return "", nil, false
+	}
+	return f.name, cloneExpressions(f.args), true
+}
+
+// InspectConcatenation returns the operator and operands of a concatenation, like And and Or.
+func InspectConcatenation(e Expression) (op Operator, operands []Expression, ok bool) {
+	c, ok := e.(concatenatedExpression)
+	if !ok {
+		return "", nil, false
+	}
+	return c.Op, cloneExpressions(c.Expr), true
+}
+
+// InspectAs returns the expression and the alias of an As expression.
+func InspectAs(e Expression) (expr, alias Expression, ok bool) {
+	a, ok := e.(asExpression)
+	if !ok {
+		return nil, nil, false
+	}
+	return a.Left, a.Right, true
+}
+
+// InspectOrderBy returns the expression and the direction of Asc and Desc.
+func InspectOrderBy(e Expression) (expr Expression, direction OrderDirection, ok bool) {
+	o, ok := e.(orderByExpression)
+	if !ok {
+		return nil, OrderDefault, false
+	}
+	return o.expression, o.orderDirection, true
+}
+
+// InspectLiteral returns the value of a literal, and whether it is a quoted string rather than a SQL expression.
+func InspectLiteral(e Expression) (v any, quoted bool, ok bool) {
+	l, ok := e.(literalValue)
+	if !ok {
+		return nil, false, false
+	}
+	v, quoted = l.literalValue()
+	return v, quoted, true
+}
+
+// InspectArg returns the value of a bound argument created by Arg.
+func InspectArg(e Expression) (v any, ok bool) {
+	a, ok := e.(argExpr)
+	return a.val, ok
+}
+
+// Children returns direct sub-expressions of built-in expression types, in rendering order.
+// Expressions of KindUnknown have no children.
+func Children(e Expression) []Expression {
+	return cloneExpressions(children(e))
+}
+
+// WithChildren returns a copy of e whose direct sub-expressions are replaced.
+// The number of children must be the same as Children(e).
+func WithChildren(e Expression, ch []Expression) (Expression, error) {
+	if n := len(children(e)); n != len(ch) {
+		return nil, fmt.Errorf("%s expression has %d children, got %d", KindOf(e), n, len(ch))
+	}
+	for i := range ch {
+		if ch[i] == nil {
+			return nil, fmt.Errorf("nil child at index %d", i)
+		}
+	}
+	if len(ch) == 0 {
+		return e, nil
+	}
+	return withChildren(e, cloneExpressions(ch)), nil
+}
+
+// Walk traverses the expression tree in depth-first pre-order.
+// If fn returns false, children of the expression are skipped.
+func Walk(e Expression, fn func(e Expression) bool) {
+	if e == nil || !fn(e) {
+		return
+	}
+	for _, c := range children(e) {
+		Walk(c, fn)
+	}
+}
+
+// Rewrite replaces expressions in the tree bottom-up: fn is called on every expression after its children
+// are rewritten, and the returned expression replaces it. The original tree is not modified.
+func Rewrite(e Expression, fn func(e Expression) (Expression, error)) (Expression, error) {
+	if e == nil {
+		return nil, nil
+	}
+	if ch := children(e); len(ch) > 0 {
+		newChildren := make([]Expression, len(ch))
+		for i := range ch {
+			var err error
+			if newChildren[i], err = Rewrite(ch[i], fn); err != nil {
+				return nil, err
+			}
+			if newChildren[i] == nil {
+				return nil, fmt.Errorf("rewrite %s expression: nil child at index %d", KindOf(e), i)
+			}
+		}
+		e = withChildren(e, newChildren)
+	}
+	return fn(e)
+}
+
+// ReferencedColumns returns distinct columns referenced in the expression tree, in the order of first appearance.
+func ReferencedColumns(e Expression) []Column {
+	var ret []Column
+	seen := make(map[Column]bool)
+	Walk(e, func(e Expression) bool {
+		if c, ok := e.(Column); ok && !seen[c] {
+			seen[c] = true
+			ret = append(ret, c)
+		}
+		return true
+	})
+	return ret
+}
+
+// children returns direct sub-expressions of built-in expression types, in rendering order.
+func children(e Expression) []Expression {
+	switch e := e.(type) {
+	case concatenatedExpression:
+		return e.Expr
+	case BinaryExpression:
+		return []Expression{e.LeftOperand, e.RightOperand}
+	case fnCall:
+		return e.args
+	case asExpression:
+		return []Expression{e.Left, e.Right}
+	case orderByExpression:
+		return []Expression{e.expression}
+	case Tuple:
+		return e
+	case rawExpr:
+		return e.args
+	case CaseExpression:
+		return e.children()
+	case LambdaExpression:
+		return []Expression{e.body}
+	case intervalExpr:
+		return []Expression{e.value}
+	default:
+		return nil
+	}
+}
+
+// withChildren returns a copy of e whose sub-expressions are replaced with ch,
+// which must be of the same length as children(e).
+func withChildren(e Expression, ch []Expression) Expression {
+	switch e := e.(type) {
+	case concatenatedExpression:
+		e.Expr = ch
+		return e
+	case BinaryExpression:
+		e.LeftOperand, e.RightOperand = ch[0], ch[1]
+		return e
+	case fnCall:
+		e.args = ch
+		return e
+	case asExpression:
+		e.Left, e.Right = ch[0], ch[1]
+		return e
+	case orderByExpression:
+		e.expression = ch[0]
+		return e
+	case Tuple:
+		return Tuple(ch)
+	case rawExpr:
+		e.args = ch
+		return e
+	case CaseExpression:
+		return e.withChildren(ch)
+	case LambdaExpression:
+		e.body = ch[0]
+		return e
+	case intervalExpr:
+		e.value = ch[0]
+		return e
+	default:
+		return e
+	}
+}
+
+// Clause is a clause of SELECT query.
+type Clause int
+
+const (
+	ClauseSelect Clause = iota
+	// ClauseFrom contains arguments of table functions in FROM clause.
+	ClauseFrom
+	ClausePrewhere
+	ClauseWhere
+	ClauseGroupBy
+	ClauseHaving
+	ClauseOrderBy
+)
+
+var clauseNames = [...]string{"SELECT", "FROM", "PREWHERE", "WHERE", "GROUP BY", "HAVING", "ORDER BY"}
+
+func (c Clause) String() string {
+	if c < 0 || int(c) >= len(clauseNames) {
+		return fmt.Sprintf("Clause(%d)", int(c))
+	}
+	return clauseNames[c]
+}
+
+// Walk traverses expression trees of all clauses in rendering order, including nested queries in FROM clause,
+// see Walk for traversal order of each tree.
+func (s *SelectBuilder) Walk(fn func(clause Clause, e Expression) bool) {
+	walkAll := func(clause Clause, exprs ...Expression) {
+		for _, e := range exprs {
+			Walk(e, func(e Expression) bool {
+				return fn(clause, e)
+			})
+		}
+	}
+	walkAll(ClauseSelect, s.selects...)
+	walkFrom(s.from, func(f FromExpression) {
+		switch f := f.(type) {
+		case tableFunction:
+			walkAll(ClauseFrom, f.args...)
+		default:
+			if sub, ok := InspectSubquery(f); ok {
+				sub.Walk(fn)
+			}
+		}
+	})
+	walkAll(ClausePrewhere, s.prewhere)
+	walkAll(ClauseWhere, s.where)
+	walkAll(ClauseGroupBy, s.groupBy...)
+	walkAll(ClauseHaving, s.having)
+	walkAll(ClauseOrderBy, s.orderBy...)
+}
+
+// walkFrom calls fn on the FROM source and its aliased sources, outermost first.
+func walkFrom(f FromExpression, fn func(f FromExpression)) {
+	for f != nil {
+		fn(f)
+		a, ok := f.(tableAlias)
+		if !ok {
+			return
+		}
+		f = a.source
+	}
+}
+
+// Rewrite returns a copy of the builder with expression trees of all clauses rewritten, see Rewrite.
+// Nested queries in FROM clause are rewritten as well. The builder itself is not modified.
+func (s *SelectBuilder) Rewrite(fn func(clause Clause, e Expression) (Expression, error)) (*SelectBuilder, error) {
+	c := s.Clone()
+	rewriteAll := func(clause Clause, exprs []Expression) error {
+		for i := range exprs {
+			var err error
+			exprs[i], err = Rewrite(exprs[i], func(e Expression) (Expression, error) {
+				return fn(clause, e)
+			})
+			if err != nil {
+				return fmt.Errorf("rewrite %s clause: %w", clause, err)
+			}
+		}
+		return nil
+	}
+	rewriteOne := func(clause Clause, e *Expression) error {
+		if *e == nil {
+			return nil
+		}
+		exprs := []Expression{*e}
+		if err := rewriteAll(clause, exprs); err != nil {
+			return err
+		}
+		*e = exprs[0]
+		return nil
+	}
+	if err := rewriteAll(ClauseSelect, c.selects); err != nil {
+		return nil, err
+	}
+	from, err := rewriteFrom(c.from, func(f FromExpression) (FromExpression, error) {
+		switch f := f.(type) {
+		case tableFunction:
+			f.args = cloneExpressions(f.args)
+			return f, rewriteAll(ClauseFrom, f.args)
+		case *SelectBuilder:
+			return f.Rewrite(fn)
+		}
+		return f, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.from = from
+	if err := rewriteOne(ClausePrewhere, &c.prewhere); err != nil {
+		return nil, err
+	}
+	if err := rewriteOne(ClauseWhere, &c.where); err != nil {
+		return nil, err
+	}
+	if err := rewriteAll(ClauseGroupBy, c.groupBy); err != nil {
+		return nil, err
+	}
+	if err := rewriteOne(ClauseHaving, &c.having); err != nil {
+		return nil, err
+	}
+	if err := rewriteAll(ClauseOrderBy, c.orderBy); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RewriteFrom returns a copy of the builder with FROM sources replaced, e.g. to rename tables.
+// fn is called on every source, including aliased sources and sources of nested queries, innermost first.
+// The builder itself is not modified.
+func (s *SelectBuilder) RewriteFrom(fn func(f FromExpression) (FromExpression, error)) (*SelectBuilder, error) {
+	c := s.Clone()
+	from, err := rewriteFrom(c.from, func(f FromExpression) (FromExpression, error) {
+		if sub, ok := f.(*SelectBuilder); ok {
+			var err error
+			if f, err = sub.RewriteFrom(fn); err != nil {
+				return nil, err
+			}
+		}
+		return fn(f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.from = from
+	return c, nil
+}
+
+// rewriteFrom replaces the FROM source and its aliased sources bottom-up with fn.
+// Sealed queries are converted to builders, so that they can be rewritten.
+func rewriteFrom(f FromExpression, fn func(f FromExpression) (FromExpression, error)) (FromExpression, error) {
+	if f == nil {
+		return nil, nil
+	}
+	switch ff := f.(type) {
+	case tableAlias:
+		source, err := rewriteFrom(ff.source, fn)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			return nil, fmt.Errorf("rewrite FROM clause: nil source of alias %s", ff.alias)
+		}
+		ff.source = source
+		f = ff
+	case *sealedSelect, sealedSelect:
+		sub, _ := InspectSubquery(ff)
+		f = sub.Clone()
+	}
+	return fn(f)
+}
+
+// FromSource returns the source in FROM clause, or nil if absent.
+func (s *SelectBuilder) FromSource() FromExpression {
+	return s.from
+}
+
+// InspectSubquery returns a copy of the nested query, if the FROM source is a query built by this package.
+func InspectSubquery(f FromExpression) (*SelectBuilder, bool) {
+	switch f := f.(type) {
+	case *SelectBuilder:
+		return f.Clone(), true
+	case SelectQuery:
+		if b, ok := selectBuilderOf(f); ok {
+			return b.Clone(), true
+		}
+	}
+	return nil, false
+}
+
+// InspectTableAlias returns the source and the alias of a FROM source created by TableAlias.
+func InspectTableAlias(f FromExpression) (source FromExpression, alias string, ok bool) {
+	a, ok := f.(tableAlias)
+	if !ok {
+		return nil, "", false
+	}
+	return a.source, a.alias, true
+}
+
+// InspectTableFunction returns the name and arguments of a table function.
+// For table functions with a path, like S3 and File, arguments are rendered as quoted strings.
+func InspectTableFunction(f FromExpression) (name string, args []Expression, ok bool) {
+	switch f := f.(type) {
+	case tableFunction:
+		return f.name, cloneExpressions(f.args), true
+	case fileLikeFunction:
+		return f.name, f.arguments(), true
+	default:
+		return "", nil, false
+	}
+}
+
+// rewrite is Rewrite with an infallible fn, used internally.
+func (s *SelectBuilder) rewrite(fn func(Expression) Expression) *SelectBuilder {
+	return must(s.Rewrite(func(_ Clause, e Expression) (Expression, error) {
+		return fn(e), nil
+	}))
+}
+
+// rewriteExpression is Rewrite with an infallible fn, used internally.
+func rewriteExpression(e Expression, fn func(Expression) Expression) Expression {
+	return must(Rewrite(e, func(e Expression) (Expression, error) {
+		return fn(e), nil
+	}))
+}
+
+// selectBuilderOf returns the builder underneath queries built by this package.
+func selectBuilderOf(q SelectQuery) (*SelectBuilder, bool) {
+	switch q := q.(type) {
+	case *sealedSelect:
+		return (*SelectBuilder)(q), true
+	case sealedSelect:
+		return (*SelectBuilder)(&q), true
+	default:
+		return nil, false
+	}
+}
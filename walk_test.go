@@ -0,0 +1,165 @@
+package click
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		expr Expression
+		want NodeKind
+	}{
+		{Column("a"), KindColumn},
+		{LiteralExpression(1), KindLiteral},
+		{LiteralExpressionQuoted("x"), KindLiteral},
+		{Alias("x"), KindAlias},
+		{Arg(1), KindArg},
+		{Raw("a = ?", 1), KindRaw},
+		{Sum(Column("a")), KindFunction},
+		{Equal(Column("a"), LiteralExpression(1)), KindBinary},
+		{And(Column("a"), Column("b")), KindConcatenation},
+		{As(Column("a"), Alias("b")), KindAs},
+		{Desc(Column("a")), KindOrderBy},
+		{Tuple{Column("a")}, KindTuple},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.expr); got != tt.want {
+			t.Errorf("KindOf(%s) = %s, want %s", tt.expr.Expression(), got, tt.want)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	name, args, ok := InspectFunction(Sum(Column("a")))
+	if !ok || name != "sum" || !reflect.DeepEqual(args, []Expression{Column("a")}) {
+		t.Fatal(name, args, ok)
+	}
+	op, operands, ok := InspectConcatenation(Or(Column("a"), Column("b")))
+	if !ok || op != OpOr || len(operands) != 2 {
+		t.Fatal(op, operands, ok)
+	}
+	expr, dir, ok := InspectOrderBy(Desc(Column("a")))
+	if !ok || expr != Column("a") || dir != OrderDescending {
+		t.Fatal(expr, dir, ok)
+	}
+	v, quoted, ok := InspectLiteral(LiteralExpressionQuoted("x"))
+	if !ok || v != "x" || !quoted {
+		t.Fatal(v, quoted, ok)
+	}
+	if _, _, ok := InspectFunction(Column("a")); ok {
+		t.Fatal("column is not a function")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	e := And(Equal(Column("a"), LiteralExpression(1)), In(Column("b"), Tuple{Column("c"), Column("a")}))
+	if cols := ReferencedColumns(e); !reflect.DeepEqual(cols, []Column{"a", "b", "c"}) {
+		t.Fatal(cols)
+	}
+	var visited int
+	Walk(e, func(e Expression) bool {
+		visited++
+		return KindOf(e) != KindBinary
+	})
+	if visited != 3 {
+		t.Fatalf("expected to skip children of binary expressions, visited %d", visited)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	e := And(Equal(Column("a"), LiteralExpression(1)), Sum(Column("b")))
+	got, err := Rewrite(e, func(e Expression) (Expression, error) {
+		if c, ok := e.(Column); ok {
+			return Column("t." + c), nil
+		}
+		return e, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Expression(); v != "((t.a = 1) AND sum(t.b))" {
+		t.Fatal(v)
+	}
+	if v := e.Expression(); v != "((a = 1) AND sum(b))" {
+		t.Fatalf("original expression is modified: %s", v)
+	}
+	errStop := errors.New("stop")
+	if _, err := Rewrite(e, func(Expression) (Expression, error) { return nil, errStop }); !errors.Is(err, errStop) {
+		t.Fatal(err)
+	}
+}
+
+func TestWithChildren(t *testing.T) {
+	e := must(WithChildren(Equal(Column("a"), Column("b")), []Expression{Column("c"), Column("d")}))
+	if v := e.Expression(); v != "(c = d)" {
+		t.Fatal(v)
+	}
+	if _, err := WithChildren(Column("a"), []Expression{Column("b")}); err == nil {
+		t.Fatal("expected error on children count mismatch")
+	}
+}
+
+func TestSelectBuilder_Walk(t *testing.T) {
+	sub := Select(Column("a")).From(Table("events")).Where(Equal(Column("b"), LiteralExpression(1)))
+	q := Select(Sum(Column("a"))).From(TableAlias(sub, "s")).GroupBy(Column("c"))
+	got := make(map[Clause][]Column)
+	q.Walk(func(clause Clause, e Expression) bool {
+		if c, ok := e.(Column); ok {
+			got[clause] = append(got[clause], c)
+		}
+		return true
+	})
+	want := map[Clause][]Column{
+		ClauseSelect:  {"a", "a"},
+		ClauseWhere:   {"b"},
+		ClauseGroupBy: {"c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+}
+
+func TestSelectBuilder_Rewrite_TenantFilter(t *testing.T) {
+	q := Select(Column("a")).From(TableAlias(Select(Column("a")).From(Table("events")).Where(Column("x")), "s"))
+	got, err := q.Rewrite(func(clause Clause, e Expression) (Expression, error) {
+		if clause == ClauseWhere && e == Column("x") {
+			return Column("y"), nil
+		}
+		return e, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := must(got.BuildString()); v != "SELECT a FROM (\nSELECT a FROM events WHERE y\n) AS s" {
+		t.Fatal(v)
+	}
+	if v := must(q.BuildString()); v != "SELECT a FROM (\nSELECT a FROM events WHERE x\n) AS s" {
+		t.Fatalf("original query is modified: %s", v)
+	}
+}
+
+func TestSelectBuilder_RewriteFrom(t *testing.T) {
+	sub := must(Select(Column("a")).From(Table("events")).Build())
+	q := Select(Column("a")).From(TableAlias(sub, "s")).Where(Column("b"))
+	got, err := q.RewriteFrom(func(f FromExpression) (FromExpression, error) {
+		if t, ok := f.(Table); ok {
+			return Table("db." + t), nil
+		}
+		return f, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := must(got.BuildString()); v != "SELECT a FROM (\nSELECT a FROM db.events\n) AS s WHERE b" {
+		t.Fatal(v)
+	}
+	source, alias, ok := InspectTableAlias(got.FromSource())
+	if !ok || alias != "s" {
+		t.Fatal(source, alias, ok)
+	}
+	if _, ok := InspectSubquery(source); !ok {
+		t.Fatal("expected nested query")
+	}
+}